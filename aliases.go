@@ -0,0 +1,115 @@
+package agui
+
+// Event Aliases
+// These functions wrap the New* event factories and return the Event interface,
+// so events of different types can be mixed in a single []Event literal.
+
+// Events returns its arguments as a slice.
+func Events(events ...Event) []Event {
+	return events
+}
+
+// RunStarted returns NewRunStartedEvent(threadID, runID) as an Event.
+func RunStarted(threadID, runID string) Event {
+	return NewRunStartedEvent(threadID, runID)
+}
+
+// RunFinished returns NewRunFinishedEvent(threadID, runID, result) as an Event.
+func RunFinished(threadID, runID string, result interface{}) Event {
+	return NewRunFinishedEvent(threadID, runID, result)
+}
+
+// RunError returns NewRunErrorEvent(message, code) as an Event.
+func RunError(message, code string) Event {
+	return NewRunErrorEvent(message, code)
+}
+
+// StepStarted returns NewStepStartedEvent(stepName) as an Event.
+func StepStarted(stepName string) Event {
+	return NewStepStartedEvent(stepName)
+}
+
+// StepFinished returns NewStepFinishedEvent(stepName) as an Event.
+func StepFinished(stepName string) Event {
+	return NewStepFinishedEvent(stepName)
+}
+
+// StepProgress returns NewStepProgressEvent(stepName, progress, message) as an Event.
+func StepProgress(stepName string, progress float64, message string) Event {
+	return NewStepProgressEvent(stepName, progress, message)
+}
+
+// TextMessageStart returns NewTextMessageStartEvent(messageID) as an Event.
+func TextMessageStart(messageID string) Event {
+	return NewTextMessageStartEvent(messageID)
+}
+
+// TextMessageContent returns NewTextMessageContentEvent(messageID, delta) as an Event.
+func TextMessageContent(messageID, delta string) Event {
+	return NewTextMessageContentEvent(messageID, delta)
+}
+
+// TextMessageEnd returns NewTextMessageEndEvent(messageID) as an Event.
+func TextMessageEnd(messageID string) Event {
+	return NewTextMessageEndEvent(messageID)
+}
+
+// ToolCallStart returns NewToolCallStartEvent(toolCallID, toolCallName, parentMessageID) as an Event.
+func ToolCallStart(toolCallID, toolCallName, parentMessageID string) Event {
+	return NewToolCallStartEvent(toolCallID, toolCallName, parentMessageID)
+}
+
+// ToolCallArgs returns NewToolCallArgsEvent(toolCallID, delta) as an Event.
+func ToolCallArgs(toolCallID, delta string) Event {
+	return NewToolCallArgsEvent(toolCallID, delta)
+}
+
+// ToolCallEnd returns NewToolCallEndEvent(toolCallID) as an Event.
+func ToolCallEnd(toolCallID string) Event {
+	return NewToolCallEndEvent(toolCallID)
+}
+
+// ToolCallResult returns NewToolCallResultEvent(messageID, toolCallID, content) as an Event.
+func ToolCallResult(messageID, toolCallID, content string) Event {
+	return NewToolCallResultEvent(messageID, toolCallID, content)
+}
+
+// ToolCallResultStart returns NewToolCallResultStartEvent(messageID, toolCallID) as an Event.
+func ToolCallResultStart(messageID, toolCallID string) Event {
+	return NewToolCallResultStartEvent(messageID, toolCallID)
+}
+
+// ToolCallResultChunk returns NewToolCallResultChunkEvent(messageID, toolCallID, delta) as an Event.
+func ToolCallResultChunk(messageID, toolCallID, delta string) Event {
+	return NewToolCallResultChunkEvent(messageID, toolCallID, delta)
+}
+
+// ToolCallResultEnd returns NewToolCallResultEndEvent(messageID, toolCallID) as an Event.
+func ToolCallResultEnd(messageID, toolCallID string) Event {
+	return NewToolCallResultEndEvent(messageID, toolCallID)
+}
+
+// StateSnapshot returns NewStateSnapshotEvent(snapshot) as an Event.
+func StateSnapshot(snapshot State) Event {
+	return NewStateSnapshotEvent(snapshot)
+}
+
+// StateDelta returns NewStateDeltaEvent(delta) as an Event.
+func StateDelta(delta []interface{}) Event {
+	return NewStateDeltaEvent(delta)
+}
+
+// MessagesSnapshot returns NewMessagesSnapshotEvent(messages) as an Event.
+func MessagesSnapshot(messages []Message) Event {
+	return NewMessagesSnapshotEvent(messages)
+}
+
+// Raw returns NewRawEvent(event, source) as an Event.
+func Raw(event interface{}, source string) Event {
+	return NewRawEvent(event, source)
+}
+
+// Custom returns NewCustomEvent(name, value) as an Event.
+func Custom(name string, value interface{}) Event {
+	return NewCustomEvent(name, value)
+}
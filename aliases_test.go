@@ -0,0 +1,55 @@
+package agui
+
+import (
+	"testing"
+)
+
+func TestEventAliases(t *testing.T) {
+	events := []Event{
+		RunStarted("thread_1", "run_1"),
+		StepStarted("plan"),
+		TextMessageStart("msg_1"),
+		TextMessageContent("msg_1", "Hello"),
+		TextMessageEnd("msg_1"),
+		ToolCallStart("tool_call_1", "search", "msg_1"),
+		ToolCallArgs("tool_call_1", `{"q":"weather"}`),
+		ToolCallEnd("tool_call_1"),
+		ToolCallResult("msg_2", "tool_call_1", "Sunny"),
+		StateSnapshot(map[string]interface{}{"count": 1}),
+		Custom("ping", true),
+		StepFinished("plan"),
+		RunFinished("thread_1", "run_1", nil),
+	}
+
+	expected := []EventType{
+		EventTypeRunStarted,
+		EventTypeStepStarted,
+		EventTypeTextMessageStart,
+		EventTypeTextMessageContent,
+		EventTypeTextMessageEnd,
+		EventTypeToolCallStart,
+		EventTypeToolCallArgs,
+		EventTypeToolCallEnd,
+		EventTypeToolCallResult,
+		EventTypeStateSnapshot,
+		EventTypeCustom,
+		EventTypeStepFinished,
+		EventTypeRunFinished,
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, event := range events {
+		if event.GetType() != expected[i] {
+			t.Errorf("Event %d: expected type %s, got %s", i, expected[i], event.GetType())
+		}
+		if err := event.Validate(); err != nil {
+			t.Errorf("Event %d is invalid: %v", i, err)
+		}
+	}
+
+	if got := Events(RunError("boom", "E1"), StepProgress("plan", 0.5, "")); len(got) != 2 {
+		t.Errorf("Expected Events to return 2 events, got %d", len(got))
+	}
+}
@@ -0,0 +1,81 @@
+package agui
+
+// AnonymizeIDs returns copies of events with every thread, run, message and tool
+// call ID replaced by mapper(oldID), including references such as parent message
+// IDs and the messages inside MESSAGES_SNAPSHOT events. mapper is called once per
+// distinct ID and its result reused, so linked events stay consistent. Empty IDs
+// are left empty. The original events are not modified.
+func AnonymizeIDs(events []Event, mapper func(oldID string) string) []Event {
+	mapped := make(map[string]string)
+	rekey := func(id *string) {
+		if *id == "" {
+			return
+		}
+		newID, ok := mapped[*id]
+		if !ok {
+			newID = mapper(*id)
+			mapped[*id] = newID
+		}
+		*id = newID
+	}
+
+	result := make([]Event, len(events))
+	for i, event := range events {
+		copied := CloneEvent(event)
+		switch e := copied.(type) {
+		case *RunStartedEvent:
+			rekey(&e.ThreadID)
+			rekey(&e.RunID)
+			rekey(&e.ParentRunID)
+		case *RunFinishedEvent:
+			rekey(&e.ThreadID)
+			rekey(&e.RunID)
+		case *TextMessageStartEvent:
+			rekey(&e.MessageID)
+		case *TextMessageContentEvent:
+			rekey(&e.MessageID)
+		case *TextMessageEndEvent:
+			rekey(&e.MessageID)
+		case *ToolCallStartEvent:
+			rekey(&e.ToolCallID)
+			rekey(&e.ParentMessageID)
+		case *ToolCallArgsEvent:
+			rekey(&e.ToolCallID)
+		case *ToolCallEndEvent:
+			rekey(&e.ToolCallID)
+		case *ToolCallResultEvent:
+			rekey(&e.MessageID)
+			rekey(&e.ToolCallID)
+		case *ToolCallResultStartEvent:
+			rekey(&e.MessageID)
+			rekey(&e.ToolCallID)
+		case *ToolCallResultChunkEvent:
+			rekey(&e.MessageID)
+			rekey(&e.ToolCallID)
+		case *ToolCallResultEndEvent:
+			rekey(&e.MessageID)
+			rekey(&e.ToolCallID)
+		case *MessagesSnapshotEvent:
+			for _, message := range e.Messages {
+				switch m := message.(type) {
+				case *DeveloperMessage:
+					rekey(&m.ID)
+				case *SystemMessage:
+					rekey(&m.ID)
+				case *UserMessage:
+					rekey(&m.ID)
+				case *AssistantMessage:
+					rekey(&m.ID)
+					for j := range m.ToolCalls {
+						rekey(&m.ToolCalls[j].ID)
+					}
+				case *ToolMessage:
+					rekey(&m.ID)
+					rekey(&m.ToolCallID)
+				}
+			}
+		}
+		result[i] = copied
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package agui
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnonymizeIDs(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_secret", "run_secret"),
+		NewMessagesSnapshotEvent([]Message{
+			NewUserMessage("msg_user", "Hi", ""),
+			NewAssistantMessage("msg_assistant", "", "", []ToolCall{searchCall("tc_secret")}),
+			NewToolMessage("msg_tool", "Sunny", "tc_secret", "", ""),
+		}),
+		NewTextMessageStartEvent("msg_assistant"),
+		NewToolCallStartEvent("tc_secret", "search", "msg_assistant"),
+		NewToolCallEndEvent("tc_secret"),
+		NewToolCallResultEvent("msg_tool", "tc_secret", "Sunny"),
+		NewRunFinishedEvent("thread_secret", "run_secret", nil),
+	}
+
+	calls := 0
+	anonymized := AnonymizeIDs(events, func(oldID string) string {
+		calls++
+		return fmt.Sprintf("id_%d", calls)
+	})
+
+	// The mapper runs once per distinct ID, in order of first appearance
+	if calls != 6 {
+		t.Errorf("Expected 6 mapper calls, got %d", calls)
+	}
+
+	started := anonymized[0].(*RunStartedEvent)
+	finished := anonymized[6].(*RunFinishedEvent)
+	if started.ThreadID != "id_1" || started.RunID != "id_2" || finished.ThreadID != "id_1" || finished.RunID != "id_2" {
+		t.Errorf("Inconsistent run IDs: %+v / %+v", started, finished)
+	}
+
+	snapshot := anonymized[1].(*MessagesSnapshotEvent)
+	assistant := snapshot.Messages[1].(*AssistantMessage)
+	tool := snapshot.Messages[2].(*ToolMessage)
+	toolStart := anonymized[3].(*ToolCallStartEvent)
+	result := anonymized[5].(*ToolCallResultEvent)
+
+	if assistant.ID != anonymized[2].(*TextMessageStartEvent).MessageID || toolStart.ParentMessageID != assistant.ID {
+		t.Error("Assistant message ID was not re-keyed consistently")
+	}
+	toolCallIDs := []string{assistant.ToolCalls[0].ID, tool.ToolCallID, toolStart.ToolCallID, anonymized[4].(*ToolCallEndEvent).ToolCallID, result.ToolCallID}
+	for _, id := range toolCallIDs {
+		if id != toolCallIDs[0] || id == "tc_secret" {
+			t.Errorf("Tool call IDs were not re-keyed consistently: %v", toolCallIDs)
+			break
+		}
+	}
+	if result.MessageID != tool.ID {
+		t.Errorf("Tool message ID %s does not match result message ID %s", tool.ID, result.MessageID)
+	}
+	if err := snapshot.ValidateConversation(); err != nil {
+		t.Errorf("Anonymized snapshot is incoherent: %v", err)
+	}
+
+	// Originals are unchanged
+	if events[0].(*RunStartedEvent).ThreadID != "thread_secret" {
+		t.Error("Original run started event was modified")
+	}
+	if events[1].(*MessagesSnapshotEvent).Messages[1].(*AssistantMessage).ToolCalls[0].ID != "tc_secret" {
+		t.Error("Original snapshot was modified")
+	}
+}
+
+func TestAnonymizeIDsKeepsPayloads(t *testing.T) {
+	type payload struct {
+		At      time.Time
+		Counter opaqueCounter
+	}
+	value := payload{At: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Counter: opaqueCounter{n: 3}}
+	events := []Event{
+		NewCustomEvent("audit", value),
+		NewRawEvent(value, "upstream"),
+		NewRunFinishedEvent("thread_1", "run_1", value),
+	}
+
+	anonymized := AnonymizeIDs(events, func(oldID string) string { return "anon_" + oldID })
+
+	got := []interface{}{
+		anonymized[0].(*CustomEvent).Value,
+		anonymized[1].(*RawEvent).Event,
+		anonymized[2].(*RunFinishedEvent).Result,
+	}
+	for i, v := range got {
+		if !reflect.DeepEqual(v, value) {
+			t.Errorf("Event %d: expected payload %+v, got %+v", i, value, v)
+		}
+	}
+	if finished := anonymized[2].(*RunFinishedEvent); finished.RunID != "anon_run_1" {
+		t.Errorf("Expected the run ID to be anonymized, got %s", finished.RunID)
+	}
+}
@@ -0,0 +1,144 @@
+package agui
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchingEncoder wraps an Encoder and buffers encoded events, writing each
+// batch to the underlying writer in a single Write. Events are still encoded as
+// separate values, followed by the Encoder's delimiter. A batch is written when
+// it reaches the maximum size, when the flush interval elapses, or on an
+// explicit Flush or Close. It is safe for concurrent use.
+type BatchingEncoder struct {
+	mu       sync.Mutex
+	encoder  *Encoder
+	buffer   bytes.Buffer
+	buffered *Encoder // encodes into buffer with the wrapped Encoder's options
+	count    int
+	maxBatch int
+	interval time.Duration
+	timer    *time.Timer
+	err      error // error from an interval flush, reported by the next call
+	closed   bool
+}
+
+// BatchingEncoderOption configures a BatchingEncoder.
+type BatchingEncoderOption func(*BatchingEncoder)
+
+// WithFlushInterval makes the BatchingEncoder write buffered events at most d
+// after the first event of a batch was encoded.
+func WithFlushInterval(d time.Duration) BatchingEncoderOption {
+	return func(b *BatchingEncoder) {
+		b.interval = d
+	}
+}
+
+// WithMaxBatch makes the BatchingEncoder write buffered events as soon as n
+// events are buffered.
+func WithMaxBatch(n int) BatchingEncoderOption {
+	return func(b *BatchingEncoder) {
+		b.maxBatch = n
+	}
+}
+
+// NewBatchingEncoder creates a new BatchingEncoder that writes batches through encoder.
+// Without options, events are only written by Flush and Close.
+func NewBatchingEncoder(encoder *Encoder, opts ...BatchingEncoderOption) *BatchingEncoder {
+	b := &BatchingEncoder{encoder: encoder}
+	buffered := *encoder
+	buffered.writer = &b.buffer
+	b.buffered = &buffered
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Encode validates and encodes event into the current batch, writing the batch
+// if it is full.
+func (b *BatchingEncoder) Encode(event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("agui: batching encoder is closed")
+	}
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	if err := b.buffered.Encode(event); err != nil {
+		return err
+	}
+	b.count++
+
+	if b.maxBatch > 0 && b.count >= b.maxBatch {
+		return b.flush()
+	}
+	if b.interval > 0 && b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flushOnInterval)
+	}
+	return nil
+}
+
+// Flush writes any buffered events.
+func (b *BatchingEncoder) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// Close writes any buffered events and stops the encoder. Encode fails after Close.
+func (b *BatchingEncoder) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if err := b.takeErr(); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// flushOnInterval writes the current batch when the flush interval elapses.
+func (b *BatchingEncoder) flushOnInterval() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timer = nil
+	if err := b.flush(); err != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// takeErr returns and clears the error from the last interval flush.
+func (b *BatchingEncoder) takeErr() error {
+	err := b.err
+	b.err = nil
+	return err
+}
+
+// flush writes the buffered events in one Write. The caller must hold b.mu.
+func (b *BatchingEncoder) flush() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if b.buffer.Len() == 0 {
+		return nil
+	}
+	defer func() {
+		b.buffer.Reset()
+		b.count = 0
+	}()
+	if _, err := b.encoder.writer.Write(b.buffer.Bytes()); err != nil {
+		return fmt.Errorf("agui: failed to write encoded data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package agui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingWriter records every Write call it receives.
+type recordingWriter struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *recordingWriter) Writes() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.writes...)
+}
+
+func TestBatchingEncoderMaxBatch(t *testing.T) {
+	w := &recordingWriter{}
+	encoder := NewBatchingEncoder(NewEncoder(w, WithDelimiter([]byte("\n"))), WithMaxBatch(3))
+
+	for _, delta := range []string{"a", "b", "c", "d"} {
+		if err := encoder.Encode(NewTextMessageContentEvent("msg_1", delta)); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+
+	writes := w.Writes()
+	if len(writes) != 1 {
+		t.Fatalf("Expected 1 write after 3 events, got %d", len(writes))
+	}
+	if lines := strings.Split(strings.TrimSuffix(writes[0], "\n"), "\n"); len(lines) != 3 {
+		t.Errorf("Expected 3 JSON values in one write, got %d: %q", len(lines), writes[0])
+	}
+
+	if err := encoder.Close(); err != nil {
+		t.Fatalf("Failed to close encoder: %v", err)
+	}
+	if writes := w.Writes(); len(writes) != 2 || !strings.Contains(writes[1], `"delta":"d"`) {
+		t.Errorf("Expected Close to flush the remaining event, got %q", writes)
+	}
+	if err := encoder.Encode(NewTextMessageContentEvent("msg_1", "e")); err == nil {
+		t.Error("Expected error encoding after Close")
+	}
+}
+
+func TestBatchingEncoderFlushInterval(t *testing.T) {
+	w := &recordingWriter{}
+	encoder := NewBatchingEncoder(NewEncoder(w), WithFlushInterval(10*time.Millisecond))
+	defer encoder.Close()
+
+	for _, delta := range []string{"a", "b"} {
+		if err := encoder.Encode(NewTextMessageContentEvent("msg_1", delta)); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+	if len(w.Writes()) != 0 {
+		t.Fatal("Expected no write before the flush interval")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(w.Writes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if writes := w.Writes(); len(writes) != 1 || strings.Count(writes[0], "TEXT_MESSAGE_CONTENT") != 2 {
+		t.Errorf("Expected both events in one interval write, got %q", writes)
+	}
+}
+
+func TestBatchingEncoderFlush(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewBatchingEncoder(NewEncoder(&buf))
+
+	if err := encoder.Encode(NewTextMessageStartEvent("msg_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatal("Expected no output before Flush")
+	}
+	if err := encoder.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if _, err := DecodeEventFromBytes(buf.Bytes()); err != nil {
+		t.Errorf("Flushed output does not decode: %v", err)
+	}
+
+	// Invalid events are rejected without being buffered
+	if err := encoder.Encode(&TextMessageStartEvent{}); err == nil {
+		t.Error("Expected validation error")
+	}
+}
+
+func TestBatchingEncoderTraceContext(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := ContextWithTraceParent(context.Background(), traceParent)
+
+	var buf bytes.Buffer
+	encoder := NewBatchingEncoder(NewEncoder(&buf, WithDelimiter([]byte("\n")), WithTraceContext(ctx)))
+	for _, event := range []Event{NewRunStartedEvent("thread_1", "run_1"), NewTextMessageStartEvent("msg_1")} {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		event, err := DecodeEventFromBytes([]byte(line))
+		if err != nil {
+			t.Fatalf("Failed to decode line %d: %v", i, err)
+		}
+		if got, ok := TraceParent(event); !ok || got != traceParent {
+			t.Errorf("TraceParent of line %d = %q, %v", i, got, ok)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package agui
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// BudgetChunker splits text into TextMessageContentEvents whose JSON encoding
+// fits in a per-frame byte budget, accounting for the event's other fields and
+// for characters that JSON escaping expands.
+type BudgetChunker struct {
+	budget int
+}
+
+// NewBudgetChunker creates a BudgetChunker for frames of at most budget bytes.
+func NewBudgetChunker(budget int) *BudgetChunker {
+	return &BudgetChunker{budget: budget}
+}
+
+// Chunk splits text into content events for messageID, in order, each encoding
+// to at most the chunker's budget. Text is only split on rune boundaries. It
+// returns an error if the budget cannot fit an event with a single character.
+func (c *BudgetChunker) Chunk(messageID, text string) ([]*TextMessageContentEvent, error) {
+	var events []*TextMessageContentEvent
+	for len(text) > 0 {
+		event := NewTextMessageContentEvent(messageID, "")
+		empty, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+		}
+		size := len(empty)
+
+		end := 0
+		for end < len(text) {
+			r, width := utf8.DecodeRuneInString(text[end:])
+			escaped, err := escapedLen(r)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+			}
+			if size+escaped > c.budget {
+				break
+			}
+			size += escaped
+			end += width
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("frame budget of %d bytes is too small for a content event of message %s", c.budget, messageID)
+		}
+
+		event.Delta = text[:end]
+		events = append(events, event)
+		text = text[end:]
+	}
+	return events, nil
+}
+
+// escapedLen returns the number of bytes r takes inside a JSON-encoded string.
+func escapedLen(r rune) (int, error) {
+	data, err := json.Marshal(string(r))
+	if err != nil {
+		return 0, err
+	}
+	return len(data) - 2, nil
+}
@@ -0,0 +1,45 @@
+package agui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBudgetChunker(t *testing.T) {
+	text := strings.Repeat("Hello, 世界! \"quoted\" <tag> & 🙂\n", 20)
+	const budget = 160
+
+	events, err := NewBudgetChunker(budget).Chunk("msg_1", text)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) < 2 {
+		t.Fatalf("Expected the text to be split, got %d event(s)", len(events))
+	}
+
+	var reassembled strings.Builder
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("Failed to encode event %d: %v", i, err)
+		}
+		if len(data) > budget {
+			t.Errorf("Event %d encodes to %d bytes, over the %d byte budget", i, len(data), budget)
+		}
+		if err := event.Validate(); err != nil {
+			t.Errorf("Event %d is invalid: %v", i, err)
+		}
+		if !strings.HasPrefix(text[reassembled.Len():], event.Delta) {
+			t.Fatalf("Event %d does not continue the text", i)
+		}
+		reassembled.WriteString(event.Delta)
+	}
+	if reassembled.String() != text {
+		t.Error("Chunks do not reassemble to the original text")
+	}
+
+	if _, err := NewBudgetChunker(20).Chunk("msg_1", text); err == nil {
+		t.Error("Expected error for a budget too small for any content")
+	}
+}
@@ -0,0 +1,133 @@
+package agui
+
+import (
+	"reflect"
+)
+
+// CloneEvent returns a deep copy of event. The copy shares no pointers, maps or
+// slices with the original, so either can be modified independently. The one
+// exception is unexported fields of structs in payloads, such as those of a
+// time.Time, which are copied by value.
+func CloneEvent(event Event) Event {
+	if event == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(event)).Interface().(Event)
+}
+
+// CloneMessage returns a deep copy of message.
+func CloneMessage(message Message) Message {
+	if message == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(message)).Interface().(Message)
+}
+
+// WithFreshTimestamp returns a deep copy of event stamped with the current time.
+// The original event is left unchanged, which makes it suitable for re-broadcasting
+// recorded events.
+func WithFreshTimestamp(event Event) Event {
+	clone := CloneEvent(event)
+	if stamper, ok := clone.(interface{ SetTimestamp(...TimestampOption) }); ok {
+		stamper.SetTimestamp()
+	}
+	return clone
+}
+
+// withBase returns a shallow copy of event with fn applied to the copy's
+// BaseEvent, leaving event unchanged. Only the concrete event struct is copied;
+// its payload is shared with event. Events of types defined outside this
+// package are returned as they are.
+func withBase(event Event, fn func(*BaseEvent)) Event {
+	v := reflect.ValueOf(event)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return event
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	copied, ok := c.Interface().(interface{ base() *BaseEvent })
+	if !ok {
+		return event
+	}
+	fn(copied.base())
+	return c.Interface().(Event)
+}
+
+// EventsEqual reports whether a and b are deeply equal, ignoring their timestamps
+// and the sequence numbers stamped by a SequencingEncoder.
+func EventsEqual(a, b Event) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	mask := func(b *BaseEvent) {
+		b.Timestamp = nil
+		b.Seq = nil
+	}
+	return reflect.DeepEqual(withBase(a, mask), withBase(b, mask))
+}
+
+// deepCopy recursively copies v. Unexported struct fields cannot be reached by
+// reflection, so they are copied by value, sharing whatever they point to;
+// exported fields are copied deeply.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+
+	default:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		return c
+	}
+}
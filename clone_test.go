@@ -0,0 +1,86 @@
+package agui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCloneEvent(t *testing.T) {
+	original := NewMessagesSnapshotEvent([]Message{
+		NewAssistantMessage("msg_1", "", "", []ToolCall{searchCall("tc_1")}),
+	})
+	original.RawEvent = map[string]interface{}{"source": "archive", "tags": []interface{}{"a"}}
+
+	clone := CloneEvent(original).(*MessagesSnapshotEvent)
+	if !reflect.DeepEqual(clone, original) {
+		t.Fatalf("Clone differs from original:\n%+v\n%+v", clone, original)
+	}
+
+	clone.Messages[0].(*AssistantMessage).ToolCalls[0].ID = "changed"
+	clone.RawEvent.(map[string]interface{})["tags"].([]interface{})[0] = "changed"
+	*clone.Timestamp = 0
+
+	if original.Messages[0].(*AssistantMessage).ToolCalls[0].ID != "tc_1" {
+		t.Error("Modifying the clone's messages changed the original")
+	}
+	if original.RawEvent.(map[string]interface{})["tags"].([]interface{})[0] != "a" {
+		t.Error("Modifying the clone's raw event changed the original")
+	}
+	if *original.Timestamp == 0 {
+		t.Error("Modifying the clone's timestamp changed the original")
+	}
+}
+
+func TestWithFreshTimestamp(t *testing.T) {
+	recorded := NewTextMessageContentEvent("msg_1", "Hello")
+	old := int64(1000)
+	recorded.Timestamp = &old
+
+	fresh := WithFreshTimestamp(recorded).(*TextMessageContentEvent)
+
+	if *recorded.Timestamp != 1000 {
+		t.Errorf("Original timestamp changed to %d", *recorded.Timestamp)
+	}
+	if fresh.Timestamp == nil || *fresh.Timestamp <= old {
+		t.Errorf("Expected a newer timestamp than %d, got %v", old, fresh.Timestamp)
+	}
+	if fresh.MessageID != recorded.MessageID || fresh.Delta != recorded.Delta {
+		t.Errorf("Clone content differs: %+v", fresh)
+	}
+}
+
+// opaqueCounter has only unexported fields, like many library types.
+type opaqueCounter struct {
+	n int
+}
+
+// clonePayload mixes a time.Time, a struct with unexported fields and an
+// exported map, for checking that clones keep all of them.
+type clonePayload struct {
+	At      time.Time
+	Counter opaqueCounter
+	Tags    map[string]string
+}
+
+func TestCloneEventUnexportedFields(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	original := NewCustomEvent("payload", clonePayload{At: at, Counter: opaqueCounter{n: 7}, Tags: map[string]string{"k": "v"}})
+
+	clone := CloneEvent(original).(*CustomEvent)
+	value := clone.Value.(clonePayload)
+	if !value.At.Equal(at) {
+		t.Errorf("Expected the clone to keep time %v, got %v", at, value.At)
+	}
+	if value.Counter.n != 7 {
+		t.Errorf("Expected the clone to keep unexported field 7, got %d", value.Counter.n)
+	}
+	if !reflect.DeepEqual(clone, original) {
+		t.Errorf("Clone differs from original:\n%+v\n%+v", clone, original)
+	}
+
+	value.Tags["k"] = "changed"
+	if original.Value.(clonePayload).Tags["k"] != "v" {
+		t.Error("Modifying the clone's exported map changed the original")
+	}
+}
@@ -1,9 +1,14 @@
 package agui
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"strings"
 )
 
 // Predefined encoding/decoding errors
@@ -14,6 +19,12 @@ var (
 	ErrUnmarshalFailed    = fmt.Errorf("agui: failed to unmarshal")
 	ErrMarshalFailed      = fmt.Errorf("agui: failed to marshal")
 	ErrValidationFailed   = fmt.Errorf("agui: validation failed")
+	ErrInvalidSequence    = fmt.Errorf("agui: invalid event sequence")
+	ErrToolArgsTooLarge   = fmt.Errorf("agui: tool call arguments too large")
+	ErrRunEnded           = fmt.Errorf("agui: run already ended")
+	ErrStateTooLarge      = fmt.Errorf("agui: state payload too large")
+	ErrRunFailed          = fmt.Errorf("agui: run failed")
+	ErrMaxEventsReached   = fmt.Errorf("agui: maximum number of events reached")
 )
 
 // EventProbe is used to determine the type of an incoming event by examining the type field.
@@ -34,12 +45,29 @@ type MessageProbe struct {
 
 // Encoder provides functionality to encode AG-UI protocol data structures to JSON.
 type Encoder struct {
-	writer io.Writer
+	writer      io.Writer
+	delimiter   []byte
+	traceParent string
+}
+
+// EncoderOption configures an Encoder.
+type EncoderOption func(*Encoder)
+
+// WithDelimiter makes the Encoder append sep after each encoded value.
+// By default no delimiter is written.
+func WithDelimiter(sep []byte) EncoderOption {
+	return func(e *Encoder) {
+		e.delimiter = append([]byte(nil), sep...)
+	}
 }
 
 // NewEncoder creates a new Encoder that writes to the provided io.Writer.
-func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{writer: w}
+func NewEncoder(w io.Writer, opts ...EncoderOption) *Encoder {
+	e := &Encoder{writer: w}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // Encode marshals and writes an AG-UI data structure to the underlying writer.
@@ -51,11 +79,19 @@ func (e *Encoder) Encode(v interface{}) error {
 		}
 	}
 
+	if event, ok := v.(Event); ok && e.traceParent != "" {
+		v = withTraceParent(event, e.traceParent)
+	}
+
 	data, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("%w: %v", ErrMarshalFailed, err)
 	}
 
+	if len(e.delimiter) > 0 {
+		data = append(data, e.delimiter...)
+	}
+
 	_, err = e.writer.Write(data)
 	if err != nil {
 		return fmt.Errorf("agui: failed to write encoded data: %w", err)
@@ -78,6 +114,48 @@ func EncodeEvent(event Event) ([]byte, error) {
 	return data, nil
 }
 
+// EncodeEventsToChan encodes each event received from events into a frame on the
+// returned channel, for transports such as WebSocket that send []byte frames.
+// It stops when events is closed, when an event fails to encode, or when ctx is
+// canceled; in the last two cases the error is sent on the error channel.
+// Both returned channels are closed when encoding stops.
+func EncodeEventsToChan(ctx context.Context, events <-chan Event) (<-chan []byte, <-chan error) {
+	frameChan := make(chan []byte, 10)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(frameChan)
+		defer close(errorChan)
+
+		for {
+			select {
+			case <-ctx.Done():
+				errorChan <- ctx.Err()
+				return
+			case event, ok := <-events:
+				if !ok {
+					return // Normal end of stream
+				}
+
+				data, err := EncodeEvent(event)
+				if err != nil {
+					errorChan <- err
+					return
+				}
+
+				select {
+				case frameChan <- data:
+				case <-ctx.Done():
+					errorChan <- ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+
+	return frameChan, errorChan
+}
+
 // EncodeMessage marshals a Message to JSON bytes.
 func EncodeMessage(message Message) ([]byte, error) {
 	if err := message.Validate(); err != nil {
@@ -92,18 +170,279 @@ func EncodeMessage(message Message) ([]byte, error) {
 	return data, nil
 }
 
+// Encode validates the RunAgentInput and marshals it to JSON bytes.
+func (r *RunAgentInput) Encode() ([]byte, error) {
+	if err := r.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidationFailed, err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+
+	return data, nil
+}
+
+// DecodeRunAgentInput decodes a RunAgentInput from JSON bytes, restoring each
+// message to its concrete type based on its role.
+func DecodeRunAgentInput(data []byte) (*RunAgentInput, error) {
+	var input RunAgentInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("%w: RunAgentInput: %v", ErrUnmarshalFailed, err)
+	}
+	return &input, input.Validate()
+}
+
+// maxDelimitedValueSize bounds the size, in bytes, of a single unit read from
+// framed input: a value between delimiters (WithDecodeDelimiter), a line of an
+// SSE stream (NewSSEStreamDecoder) or the payload of a length-prefixed frame
+// (FramedDecoder; FramedEncoder refuses to write larger frames). It keeps a
+// malformed stream from growing a read buffer without limit.
+const maxDelimitedValueSize = 64 << 20
+
 // Decoder provides functionality to decode AG-UI protocol data structures from JSON.
 type Decoder struct {
-	decoder *json.Decoder
+	decoder  *json.Decoder
+	scanner  *bufio.Scanner
+	consumed int64     // bytes consumed by scanner
+	sse      *sseState // set when scanner reads SSE lines, see NewSSEStreamDecoder
+	config   *decodeConfig
+}
+
+// decodeConfig holds the settings applied by DecoderOptions.
+type decodeConfig struct {
+	delimiter []byte
+	strict    bool
+	lenient   bool
+
+	timestampField       string
+	caseInsensitiveTypes bool
+	caseInsensitiveRoles bool
+	lenientTextRoles     bool
+	rawDelta             bool
+	preferRawEvent       bool
+	requireTimestamp     bool
+	maxEvents            int
+	onEvent              func(Event)
+	onError              func(error)
+
+	maxToolArgsSize int
+	toolArgsSize    map[string]int // accumulated ToolCallArgsEvent bytes per tool call
+	maxStateSize    int
+}
+
+// DecoderOption configures a Decoder.
+type DecoderOption func(*decodeConfig)
+
+// WithDecodeDelimiter makes the Decoder split the input on sep instead of relying
+// on JSON value boundaries. It is the counterpart of the WithDelimiter encoder option.
+// Empty segments between delimiters are skipped.
+func WithDecodeDelimiter(sep []byte) DecoderOption {
+	return func(c *decodeConfig) {
+		c.delimiter = append([]byte(nil), sep...)
+	}
+}
+
+// WithStrictDecoding makes decoding fail when an event or message contains fields
+// that do not belong to its concrete type, such as toolCalls on a user message.
+// By default unknown fields are silently ignored.
+func WithStrictDecoding() DecoderOption {
+	return func(c *decodeConfig) {
+		c.strict = true
+	}
+}
+
+// WithLenientJSON makes decoding tolerate "//" and "/* */" comments and trailing
+// commas, as found in hand-edited fixture files. The input is rewritten into strict
+// JSON before decoding; a streaming Decoder buffers its whole input to do so.
+// It is intended for tooling and fixtures, not for production streams.
+func WithLenientJSON() DecoderOption {
+	return func(c *decodeConfig) {
+		c.lenient = true
+	}
+}
+
+// WithTimestampField makes event decoding read the timestamp from the given
+// JSON key instead of "timestamp", for producers that rename the field.
+func WithTimestampField(name string) DecoderOption {
+	return func(c *decodeConfig) {
+		c.timestampField = name
+	}
+}
+
+// WithCaseInsensitiveTypes makes event decoding accept event types in any
+// case, such as "run_started", by upper-casing the type before matching it.
+func WithCaseInsensitiveTypes() DecoderOption {
+	return func(c *decodeConfig) {
+		c.caseInsensitiveTypes = true
+	}
+}
+
+// WithCaseInsensitiveRoles makes message decoding accept roles in any case,
+// such as "User", by lower-casing the role before matching it.
+func WithCaseInsensitiveRoles() DecoderOption {
+	return func(c *decodeConfig) {
+		c.caseInsensitiveRoles = true
+	}
+}
+
+// WithLenientTextRoles makes TextMessageStartEvent decoding accept the roles
+// allowed by LenientTextRoles. A MessageStreamDecoder created with it also
+// reassembles each text message with its declared role, as WithLenientRoles
+// does for a TextMessageAssembler.
+func WithLenientTextRoles() DecoderOption {
+	return func(c *decodeConfig) {
+		c.lenientTextRoles = true
+	}
+}
+
+// WithRawDelta makes StateDeltaEvent decoding keep each operation as the exact
+// bytes received in RawDelta, leaving Delta nil, so deltas can be passed on
+// without reordering keys or reformatting values.
+func WithRawDelta() DecoderOption {
+	return func(c *decodeConfig) {
+		c.rawDelta = true
+	}
+}
+
+// WithPreferRawEvent makes event decoding return the event carried in the
+// rawEvent field instead of the outer event, when rawEvent is an object with a
+// recognized event type. This unwraps events forwarded by AG-UI proxies, across
+// any number of hops.
+func WithPreferRawEvent() DecoderOption {
+	return func(c *decodeConfig) {
+		c.preferRawEvent = true
+	}
+}
+
+// WithRequireTimestamp makes event decoding reject events without a timestamp,
+// as ValidateStrict does.
+func WithRequireTimestamp() DecoderOption {
+	return func(c *decodeConfig) {
+		c.requireTimestamp = true
+	}
+}
+
+// WithMaxEvents makes the StreamDecoder methods stop after delivering n values,
+// sending ErrMaxEventsReached on the error channel before closing both channels.
+// The error is sent as soon as the n-th value is delivered, without reading
+// further input. By default streams are decoded until EOF.
+func WithMaxEvents(n int) DecoderOption {
+	return func(c *decodeConfig) {
+		c.maxEvents = n
+	}
+}
+
+// WithOnEvent registers fn to be called by the StreamDecoder methods for every
+// decoded event, synchronously in the decode goroutine and before the event is
+// sent on the channel. fn must not block for long, as it stalls decoding.
+func WithOnEvent(fn func(Event)) DecoderOption {
+	return func(c *decodeConfig) {
+		c.onEvent = fn
+	}
+}
+
+// WithOnError registers fn to be called by the StreamDecoder methods for every
+// error, synchronously in the decode goroutine and before the error is sent on
+// the channel.
+func WithOnError(fn func(error)) DecoderOption {
+	return func(c *decodeConfig) {
+		c.onError = fn
+	}
+}
+
+// newDecodeConfig applies opts to a default decodeConfig.
+func newDecodeConfig(opts []DecoderOption) *decodeConfig {
+	c := &decodeConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// utf8BOM is the UTF-8 byte order mark some editors write at the start of a file.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// preprocess rewrites input bytes as required by the configuration. A leading
+// UTF-8 byte order mark and surrounding whitespace are always removed.
+func (c *decodeConfig) preprocess(data []byte) []byte {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	data = bytes.TrimPrefix(data, utf8BOM)
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if c.lenient {
+		return stripJSONExtensions(data)
+	}
+	return data
+}
+
+// unmarshal decodes data into v according to the configuration.
+func (c *decodeConfig) unmarshal(data []byte, v interface{}) error {
+	if !c.strict {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
 }
 
 // NewDecoder creates a new Decoder that reads from the provided io.Reader.
-func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{decoder: json.NewDecoder(r)}
+func NewDecoder(r io.Reader, opts ...DecoderOption) *Decoder {
+	d := &Decoder{config: newDecodeConfig(opts)}
+	if len(d.config.delimiter) > 0 {
+		d.scanner = bufio.NewScanner(r)
+		d.scanner.Buffer(nil, maxDelimitedValueSize)
+		split := splitOnDelimiter(d.config.delimiter)
+		d.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			advance, token, err := split(data, atEOF)
+			d.consumed += int64(advance)
+			return advance, token, err
+		})
+	} else {
+		if d.config.lenient {
+			r = &lenientReader{source: r}
+		}
+		d.decoder = json.NewDecoder(r)
+	}
+	return d
 }
 
-// DecodeEvent reads and decodes a single AG-UI event from the underlying reader.
-func (d *Decoder) DecodeEvent() (Event, error) {
+// splitOnDelimiter returns a bufio.SplitFunc that splits input on sep.
+func splitOnDelimiter(sep []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, sep); i >= 0 {
+			return i + len(sep), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// readRaw reads the next raw JSON value from the underlying reader.
+// It returns io.EOF unwrapped when the input is exhausted.
+func (d *Decoder) readRaw() (json.RawMessage, error) {
+	if d.sse != nil {
+		return d.readSSE()
+	}
+	if d.scanner != nil {
+		for d.scanner.Scan() {
+			token := bytes.TrimSpace(d.config.preprocess(d.scanner.Bytes()))
+			if len(token) == 0 {
+				continue
+			}
+			return append(json.RawMessage(nil), token...), nil
+		}
+		if err := d.scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+		}
+		return nil, io.EOF
+	}
+
 	var rawData json.RawMessage
 	if err := d.decoder.Decode(&rawData); err != nil {
 		if err == io.EOF {
@@ -111,61 +450,233 @@ func (d *Decoder) DecodeEvent() (Event, error) {
 		}
 		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
 	}
+	return rawData, nil
+}
+
+// InputOffset returns the offset in the input just past the last value read,
+// counting bytes as read from the underlying reader. With WithLenientJSON and no
+// delimiter, the offset is into the input after comments and trailing commas
+// have been removed.
+func (d *Decoder) InputOffset() int64 {
+	if d.scanner != nil {
+		return d.consumed
+	}
+	return d.decoder.InputOffset()
+}
+
+// atOffset annotates a decode error with the input offset at which the failing
+// value started. io.EOF is returned unchanged.
+func atOffset(err error, offset int64) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return fmt.Errorf("%w (at input offset %d)", err, offset)
+}
+
+// DecodeEvent reads and decodes a single AG-UI event from the underlying reader.
+// Errors report the input offset at which the event started.
+func (d *Decoder) DecodeEvent() (Event, error) {
+	start := d.InputOffset()
+	rawData, err := d.readRaw()
+	if err != nil {
+		return nil, atOffset(err, start)
+	}
 
 	var probe EventProbe
 	if err := json.Unmarshal(rawData, &probe); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+		return nil, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start)
 	}
 	probe.RawData = rawData
 
 	// Re-decode the raw data into the specific event type
-	return decodeEventFromProbe(&probe)
+	event, err := decodeEventFromProbe(&probe, d.config)
+	return event, atOffset(err, start)
 }
 
-// DecodeMessage reads and decodes a single AG-UI message from the underlying reader.
-func (d *Decoder) DecodeMessage() (Message, error) {
-	var rawData json.RawMessage
-	if err := d.decoder.Decode(&rawData); err != nil {
+// DecodeAll reads and decodes events until the input is exhausted and returns
+// them in order. If an event fails to decode, DecodeAll stops and returns the
+// events decoded before it together with the error.
+func (d *Decoder) DecodeAll() ([]Event, error) {
+	var events []Event
+	for {
+		event, err := d.DecodeEvent()
 		if err == io.EOF {
-			return nil, err
+			return events, nil
 		}
-		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+// DecodeMessage reads and decodes a single AG-UI message from the underlying reader.
+// Errors report the input offset at which the message started.
+func (d *Decoder) DecodeMessage() (Message, error) {
+	start := d.InputOffset()
+	rawData, err := d.readRaw()
+	if err != nil {
+		return nil, atOffset(err, start)
 	}
 
 	var probe MessageProbe
 	if err := json.Unmarshal(rawData, &probe); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+		return nil, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start)
 	}
 	probe.RawData = rawData
 
 	// Re-decode the raw data into the specific message type
-	return decodeMessageFromProbe(&probe)
+	message, err := decodeMessageFromProbe(&probe, d.config)
+	return message, atOffset(err, start)
 }
 
 // DecodeEventFromBytes decodes an Event from JSON bytes.
-func DecodeEventFromBytes(data []byte) (Event, error) {
+func DecodeEventFromBytes(data []byte, opts ...DecoderOption) (Event, error) {
+	config := newDecodeConfig(opts)
+	data = config.preprocess(data)
+
 	var probe EventProbe
 	if err := json.Unmarshal(data, &probe); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
 	}
 
 	probe.RawData = data
-	return decodeEventFromProbe(&probe)
+	return decodeEventFromProbe(&probe, config)
 }
 
 // DecodeMessageFromBytes decodes a Message from JSON bytes.
-func DecodeMessageFromBytes(data []byte) (Message, error) {
+func DecodeMessageFromBytes(data []byte, opts ...DecoderOption) (Message, error) {
+	config := newDecodeConfig(opts)
+	data = config.preprocess(data)
+
 	var probe MessageProbe
 	if err := json.Unmarshal(data, &probe); err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
 	}
 
 	probe.RawData = data
-	return decodeMessageFromProbe(&probe)
+	return decodeMessageFromProbe(&probe, config)
+}
+
+// DecodeEventAt decodes a single Event whose JSON value starts at offset in r,
+// which may be preceded by whitespace. It returns the event together with the
+// offset just past the end of the value, so events can be read from indexed
+// archives without scanning from the start.
+func DecodeEventAt(r io.ReaderAt, offset int64) (Event, int64, error) {
+	if offset < 0 {
+		return nil, offset, fmt.Errorf("agui: negative offset: %d", offset)
+	}
+
+	decoder := json.NewDecoder(io.NewSectionReader(r, offset, math.MaxInt64-offset))
+	var rawData json.RawMessage
+	if err := decoder.Decode(&rawData); err != nil {
+		if err == io.EOF {
+			return nil, offset, err
+		}
+		return nil, offset, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+	}
+	end := offset + decoder.InputOffset()
+
+	event, err := DecodeEventFromBytes(rawData)
+	return event, end, err
+}
+
+// decodeEventFromProbe decodes an event based on the probed type and applies
+// the checks enabled in config.
+func decodeEventFromProbe(probe *EventProbe, config *decodeConfig) (Event, error) {
+	if config.preferRawEvent && probe.RawData != nil {
+		if inner, ok := innerEventProbe(probe.RawData, config); ok {
+			return decodeEventFromProbe(inner, config)
+		}
+	}
+	if config.timestampField != "" && config.timestampField != "timestamp" && probe.RawData != nil {
+		data, err := renameJSONField(probe.RawData, config.timestampField, "timestamp")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+		}
+		probe.RawData = data
+	}
+	if config.caseInsensitiveTypes {
+		if canonical := EventType(strings.ToUpper(string(probe.Type))); canonical != probe.Type {
+			probe.Type = canonical
+			if probe.RawData != nil {
+				data, err := setJSONField(probe.RawData, "type", canonical)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+				}
+				probe.RawData = data
+			}
+		}
+	}
+
+	event, err := decodeEventByType(probe, config)
+	if err != nil {
+		return event, err
+	}
+	if config.requireTimestamp {
+		if err := requireTimestamp(event); err != nil {
+			return event, err
+		}
+	}
+	return event, config.checkEvent(event)
+}
+
+// innerEventProbe probes the rawEvent field of data, reporting false unless it
+// is an object with a recognized event type.
+func innerEventProbe(data []byte, config *decodeConfig) (*EventProbe, bool) {
+	var outer struct {
+		RawEvent json.RawMessage `json:"rawEvent"`
+	}
+	if err := json.Unmarshal(data, &outer); err != nil || len(outer.RawEvent) == 0 {
+		return nil, false
+	}
+	var probe EventProbe
+	if err := json.Unmarshal(outer.RawEvent, &probe); err != nil {
+		return nil, false
+	}
+	eventType := probe.Type
+	if config.caseInsensitiveTypes {
+		eventType = EventType(strings.ToUpper(string(eventType)))
+	}
+	if !eventType.IsValid() {
+		return nil, false
+	}
+	probe.RawData = outer.RawEvent
+	return &probe, true
+}
+
+// renameJSONField returns data with the top-level key from renamed to to.
+// Data without the key is returned unchanged.
+func renameJSONField(data []byte, from, to string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	value, ok := fields[from]
+	if !ok {
+		return data, nil
+	}
+	delete(fields, from)
+	fields[to] = value
+	return json.Marshal(fields)
+}
+
+// setJSONField returns data with the top-level key set to value.
+func setJSONField(data []byte, key string, value interface{}) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[key] = encoded
+	return json.Marshal(fields)
 }
 
-// decodeEventFromProbe decodes an event based on the probed type.
-func decodeEventFromProbe(probe *EventProbe) (Event, error) {
+// decodeEventByType decodes an event based on the probed type.
+func decodeEventByType(probe *EventProbe, config *decodeConfig) (Event, error) {
 	var data []byte
 	if probe.RawData != nil {
 		data = probe.RawData
@@ -181,119 +692,160 @@ func decodeEventFromProbe(probe *EventProbe) (Event, error) {
 	switch probe.Type {
 	case EventTypeRunStarted:
 		var event RunStartedEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: RunStartedEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeRunFinished:
 		var event RunFinishedEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: RunFinishedEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeRunError:
 		var event RunErrorEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: RunErrorEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeStepStarted:
 		var event StepStartedEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: StepStartedEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeStepFinished:
 		var event StepFinishedEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: StepFinishedEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
+	case EventTypeStepProgress:
+		var event StepProgressEvent
+		if err := config.unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("%w: StepProgressEvent: %v", ErrUnmarshalFailed, err)
+		}
+		return &event, event.Validate()
+
 	case EventTypeTextMessageStart:
 		var event TextMessageStartEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: TextMessageStartEvent: %v", ErrUnmarshalFailed, err)
 		}
+		if config.lenientTextRoles {
+			return &event, event.ValidateWith(LenientTextRoles())
+		}
 		return &event, event.Validate()
 
 	case EventTypeTextMessageContent:
 		var event TextMessageContentEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: TextMessageContentEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeTextMessageEnd:
 		var event TextMessageEndEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: TextMessageEndEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeToolCallStart:
 		var event ToolCallStartEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: ToolCallStartEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeToolCallArgs:
 		var event ToolCallArgsEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: ToolCallArgsEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeToolCallEnd:
 		var event ToolCallEndEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: ToolCallEndEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeToolCallResult:
 		var event ToolCallResultEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: ToolCallResultEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
+	case EventTypeToolCallResultStart:
+		var event ToolCallResultStartEvent
+		if err := config.unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("%w: ToolCallResultStartEvent: %v", ErrUnmarshalFailed, err)
+		}
+		return &event, event.Validate()
+
+	case EventTypeToolCallResultChunk:
+		var event ToolCallResultChunkEvent
+		if err := config.unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("%w: ToolCallResultChunkEvent: %v", ErrUnmarshalFailed, err)
+		}
+		return &event, event.Validate()
+
+	case EventTypeToolCallResultEnd:
+		var event ToolCallResultEndEvent
+		if err := config.unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("%w: ToolCallResultEndEvent: %v", ErrUnmarshalFailed, err)
+		}
+		return &event, event.Validate()
+
 	case EventTypeStateSnapshot:
 		var event StateSnapshotEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: StateSnapshotEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeStateDelta:
 		var event StateDeltaEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if config.rawDelta {
+			type alias StateDeltaEvent
+			raw := struct {
+				*alias
+				Delta []json.RawMessage `json:"delta"`
+			}{alias: (*alias)(&event)}
+			if err := config.unmarshal(data, &raw); err != nil {
+				return nil, fmt.Errorf("%w: StateDeltaEvent: %v", ErrUnmarshalFailed, err)
+			}
+			event.RawDelta = raw.Delta
+		} else if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: StateDeltaEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeMessagesSnapshot:
 		var event MessagesSnapshotEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: MessagesSnapshotEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeRaw:
 		var event RawEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: RawEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
 
 	case EventTypeCustom:
 		var event CustomEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := config.unmarshal(data, &event); err != nil {
 			return nil, fmt.Errorf("%w: CustomEvent: %v", ErrUnmarshalFailed, err)
 		}
 		return &event, event.Validate()
@@ -303,8 +855,31 @@ func decodeEventFromProbe(probe *EventProbe) (Event, error) {
 	}
 }
 
-// decodeMessageFromProbe decodes a message based on the probed role.
-func decodeMessageFromProbe(probe *MessageProbe) (Message, error) {
+// decodeMessageFromProbe decodes a message based on the probed role and applies
+// the checks enabled in config.
+func decodeMessageFromProbe(probe *MessageProbe, config *decodeConfig) (Message, error) {
+	if config.caseInsensitiveRoles {
+		if canonical := Role(strings.ToLower(string(probe.Role))); canonical != probe.Role {
+			probe.Role = canonical
+			if probe.RawData != nil {
+				data, err := setJSONField(probe.RawData, "role", canonical)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+				}
+				probe.RawData = data
+			}
+		}
+	}
+
+	message, err := decodeMessageByRole(probe, config)
+	if err != nil {
+		return message, err
+	}
+	return message, config.checkMessage(message)
+}
+
+// decodeMessageByRole decodes a message based on the probed role.
+func decodeMessageByRole(probe *MessageProbe, config *decodeConfig) (Message, error) {
 	var data []byte
 	if probe.RawData != nil {
 		data = probe.RawData
@@ -320,35 +895,35 @@ func decodeMessageFromProbe(probe *MessageProbe) (Message, error) {
 	switch probe.Role {
 	case RoleDeveloper:
 		var message DeveloperMessage
-		if err := json.Unmarshal(data, &message); err != nil {
+		if err := config.unmarshal(data, &message); err != nil {
 			return nil, fmt.Errorf("%w: DeveloperMessage: %v", ErrUnmarshalFailed, err)
 		}
 		return &message, message.Validate()
 
 	case RoleSystem:
 		var message SystemMessage
-		if err := json.Unmarshal(data, &message); err != nil {
+		if err := config.unmarshal(data, &message); err != nil {
 			return nil, fmt.Errorf("%w: SystemMessage: %v", ErrUnmarshalFailed, err)
 		}
 		return &message, message.Validate()
 
 	case RoleAssistant:
 		var message AssistantMessage
-		if err := json.Unmarshal(data, &message); err != nil {
+		if err := config.unmarshal(data, &message); err != nil {
 			return nil, fmt.Errorf("%w: AssistantMessage: %v", ErrUnmarshalFailed, err)
 		}
 		return &message, message.Validate()
 
 	case RoleUser:
 		var message UserMessage
-		if err := json.Unmarshal(data, &message); err != nil {
+		if err := config.unmarshal(data, &message); err != nil {
 			return nil, fmt.Errorf("%w: UserMessage: %v", ErrUnmarshalFailed, err)
 		}
 		return &message, message.Validate()
 
 	case RoleTool:
 		var message ToolMessage
-		if err := json.Unmarshal(data, &message); err != nil {
+		if err := config.unmarshal(data, &message); err != nil {
 			return nil, fmt.Errorf("%w: ToolMessage: %v", ErrUnmarshalFailed, err)
 		}
 		return &message, message.Validate()
@@ -361,12 +936,40 @@ func decodeMessageFromProbe(probe *MessageProbe) (Message, error) {
 // StreamDecoder provides functionality for decoding streaming AG-UI events.
 // This is particularly useful for the event-driven architecture of AG-UI.
 type StreamDecoder struct {
-	decoder *json.Decoder
+	decoder *Decoder
+	tee     *streamTee // set by NewTeeStreamDecoder
 }
 
 // NewStreamDecoder creates a new StreamDecoder that reads from the provided io.Reader.
-func NewStreamDecoder(r io.Reader) *StreamDecoder {
-	return &StreamDecoder{decoder: json.NewDecoder(r)}
+// Unless WithDelimiter is used, values may be separated by any amount of JSON
+// whitespace, including none, so newline-delimited, space-separated and plainly
+// concatenated streams all decode the same way.
+func NewStreamDecoder(r io.Reader, opts ...DecoderOption) *StreamDecoder {
+	return &StreamDecoder{decoder: NewDecoder(r, opts...)}
+}
+
+// InputOffset returns the offset in the input just past the last value read, as
+// reported by Decoder.InputOffset. Decoding errors sent by the stream methods
+// already include the offset of the failing value; InputOffset must not be
+// called while a stream method is still decoding.
+func (s *StreamDecoder) InputOffset() int64 {
+	return s.decoder.InputOffset()
+}
+
+// notifyEvent passes event to the WithOnEvent callback, if any.
+func (s *StreamDecoder) notifyEvent(event Event) {
+	if s.decoder.config.onEvent != nil {
+		s.decoder.config.onEvent(event)
+	}
+}
+
+// sendError passes err to the WithOnError callback, if any, and sends it on
+// errorChan.
+func (s *StreamDecoder) sendError(errorChan chan<- error, err error) {
+	if s.decoder.config.onError != nil {
+		s.decoder.config.onError(err)
+	}
+	errorChan <- err
 }
 
 // DecodeEvents continuously decodes events from the stream until EOF or error.
@@ -379,30 +982,44 @@ func (s *StreamDecoder) DecodeEvents() (<-chan Event, <-chan error) {
 		defer close(eventChan)
 		defer close(errorChan)
 
+		delivered := 0
 		for {
-			var rawData json.RawMessage
-			if err := s.decoder.Decode(&rawData); err != nil {
+			start := s.decoder.InputOffset()
+			rawData, err := s.decoder.readRaw()
+			if err != nil {
 				if err == io.EOF {
+					if err := s.tee.flush(); err != nil {
+						s.sendError(errorChan, err)
+					}
 					return // Normal end of stream
 				}
-				errorChan <- fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+				s.sendError(errorChan, atOffset(err, start))
 				return
 			}
 
 			var probe EventProbe
 			if err := json.Unmarshal(rawData, &probe); err != nil {
-				errorChan <- fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+				s.sendError(errorChan, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start))
 				return
 			}
 			probe.RawData = rawData
 
-			event, err := decodeEventFromProbe(&probe)
+			event, err := decodeEventFromProbe(&probe, s.decoder.config)
 			if err != nil {
-				errorChan <- err
+				s.sendError(errorChan, atOffset(err, start))
 				return
 			}
 
+			if err := s.tee.forward(s.decoder.InputOffset()); err != nil {
+				s.sendError(errorChan, err)
+				return
+			}
+			s.notifyEvent(event)
 			eventChan <- event
+			if delivered++; s.decoder.config.maxEvents > 0 && delivered >= s.decoder.config.maxEvents {
+				s.sendError(errorChan, ErrMaxEventsReached)
+				return
+			}
 		}
 	}()
 
@@ -419,32 +1036,122 @@ func (s *StreamDecoder) DecodeMessages() (<-chan Message, <-chan error) {
 		defer close(messageChan)
 		defer close(errorChan)
 
+		delivered := 0
 		for {
-			var rawData json.RawMessage
-			if err := s.decoder.Decode(&rawData); err != nil {
+			start := s.decoder.InputOffset()
+			rawData, err := s.decoder.readRaw()
+			if err != nil {
 				if err == io.EOF {
+					if err := s.tee.flush(); err != nil {
+						s.sendError(errorChan, err)
+					}
 					return // Normal end of stream
 				}
-				errorChan <- fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+				s.sendError(errorChan, atOffset(err, start))
 				return
 			}
 
 			var probe MessageProbe
 			if err := json.Unmarshal(rawData, &probe); err != nil {
-				errorChan <- fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+				s.sendError(errorChan, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start))
 				return
 			}
 			probe.RawData = rawData
 
-			message, err := decodeMessageFromProbe(&probe)
+			message, err := decodeMessageFromProbe(&probe, s.decoder.config)
 			if err != nil {
-				errorChan <- err
+				s.sendError(errorChan, atOffset(err, start))
 				return
 			}
 
+			if err := s.tee.forward(s.decoder.InputOffset()); err != nil {
+				s.sendError(errorChan, err)
+				return
+			}
 			messageChan <- message
+			if delivered++; s.decoder.config.maxEvents > 0 && delivered >= s.decoder.config.maxEvents {
+				s.sendError(errorChan, ErrMaxEventsReached)
+				return
+			}
 		}
 	}()
 
 	return messageChan, errorChan
 }
+
+// DecodeAny continuously decodes a stream that interleaves events and bare
+// messages until EOF or error. A JSON value with a "type" field is decoded as
+// an Event, otherwise one with a "role" field is decoded as a Message; values
+// with neither are reported as errors. It returns a channel carrying Event and
+// Message values and a channel of errors.
+func (s *StreamDecoder) DecodeAny() (<-chan interface{}, <-chan error) {
+	valueChan := make(chan interface{}, 10)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(valueChan)
+		defer close(errorChan)
+
+		delivered := 0
+		for {
+			start := s.decoder.InputOffset()
+			rawData, err := s.decoder.readRaw()
+			if err != nil {
+				if err == io.EOF {
+					if err := s.tee.flush(); err != nil {
+						s.sendError(errorChan, err)
+					}
+					return // Normal end of stream
+				}
+				s.sendError(errorChan, atOffset(err, start))
+				return
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(rawData, &fields); err != nil {
+				s.sendError(errorChan, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start))
+				return
+			}
+
+			var value interface{}
+			if _, ok := fields["type"]; ok {
+				var probe EventProbe
+				if err := json.Unmarshal(rawData, &probe); err != nil {
+					s.sendError(errorChan, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start))
+					return
+				}
+				probe.RawData = rawData
+				value, err = decodeEventFromProbe(&probe, s.decoder.config)
+			} else if _, ok := fields["role"]; ok {
+				var probe MessageProbe
+				if err := json.Unmarshal(rawData, &probe); err != nil {
+					s.sendError(errorChan, atOffset(fmt.Errorf("%w: %v", ErrUnmarshalFailed, err), start))
+					return
+				}
+				probe.RawData = rawData
+				value, err = decodeMessageFromProbe(&probe, s.decoder.config)
+			} else {
+				err = fmt.Errorf("%w: value has neither a type nor a role field", ErrInvalidStructure)
+			}
+			if err != nil {
+				s.sendError(errorChan, atOffset(err, start))
+				return
+			}
+
+			if err := s.tee.forward(s.decoder.InputOffset()); err != nil {
+				s.sendError(errorChan, err)
+				return
+			}
+			if event, ok := value.(Event); ok {
+				s.notifyEvent(event)
+			}
+			valueChan <- value
+			if delivered++; s.decoder.config.maxEvents > 0 && delivered >= s.decoder.config.maxEvents {
+				s.sendError(errorChan, ErrMaxEventsReached)
+				return
+			}
+		}
+	}()
+
+	return valueChan, errorChan
+}
@@ -2,6 +2,12 @@ package agui
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -343,3 +349,824 @@ func TestRunAgentInputValidation(t *testing.T) {
 		t.Error("Invalid input should produce error")
 	}
 }
+
+func TestEncoderDelimiter(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello\r\nworld"),
+		NewTextMessageEndEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	for _, sep := range []string{"\r\n", "\x1e"} {
+		t.Run(fmt.Sprintf("%q", sep), func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := NewEncoder(&buf, WithDelimiter([]byte(sep)))
+			for _, event := range events {
+				if err := encoder.Encode(event); err != nil {
+					t.Fatalf("Failed to encode event: %v", err)
+				}
+			}
+
+			if got := strings.Count(buf.String(), sep); got != len(events) {
+				t.Errorf("Expected %d delimiters, got %d", len(events), got)
+			}
+			if !strings.HasSuffix(buf.String(), sep) {
+				t.Error("Expected stream to end with the delimiter")
+			}
+
+			decoder := NewDecoder(&buf, WithDecodeDelimiter([]byte(sep)))
+			var decoded []Event
+			for {
+				event, err := decoder.DecodeEvent()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Failed to decode event: %v", err)
+				}
+				decoded = append(decoded, event)
+			}
+
+			if len(decoded) != len(events) {
+				t.Fatalf("Expected %d events, got %d", len(events), len(decoded))
+			}
+			for i, event := range decoded {
+				if event.GetType() != events[i].GetType() {
+					t.Errorf("Event %d type mismatch: expected %s, got %s", i, events[i].GetType(), event.GetType())
+				}
+			}
+			if content := decoded[2].(*TextMessageContentEvent); content.Delta != "Hello\r\nworld" {
+				t.Errorf("Delta mismatch: got %q", content.Delta)
+			}
+		})
+	}
+}
+
+func TestEncoderDefaultNoDelimiter(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	if err := encoder.Encode(NewTextMessageEndEvent("msg_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "}") {
+		t.Errorf("Expected no trailing delimiter, got %q", buf.String())
+	}
+}
+
+func TestStrictMessageDecoding(t *testing.T) {
+	data := []byte(`{"id":"msg_1","role":"user","content":"Hi","toolCalls":[{"id":"tool_call_1","type":"function","function":{"name":"search","arguments":"{}"}}]}`)
+
+	// Default decoding is lenient and drops the unknown field
+	decoded, err := DecodeMessageFromBytes(data)
+	if err != nil {
+		t.Fatalf("Lenient decoding should succeed: %v", err)
+	}
+	if decoded.MessageType() != "UserMessage" {
+		t.Errorf("Expected UserMessage, got %s", decoded.MessageType())
+	}
+
+	// Strict decoding rejects fields that don't belong to the role's type
+	if _, err := DecodeMessageFromBytes(data, WithStrictDecoding()); err == nil {
+		t.Error("Expected strict decoding to reject toolCalls on a user message")
+	}
+
+	decoder := NewDecoder(bytes.NewReader(data), WithStrictDecoding())
+	if _, err := decoder.DecodeMessage(); err == nil {
+		t.Error("Expected strict Decoder to reject toolCalls on a user message")
+	}
+
+	// Fields that belong to the concrete type are still accepted
+	valid := []byte(`{"id":"msg_2","role":"assistant","content":"Hi","toolCalls":[{"id":"tool_call_1","type":"function","function":{"name":"search","arguments":"{}"}}]}`)
+	if _, err := DecodeMessageFromBytes(valid, WithStrictDecoding()); err != nil {
+		t.Errorf("Strict decoding should accept a valid assistant message: %v", err)
+	}
+}
+
+func TestDecodeEventAt(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageEndEvent("msg_1"),
+	}
+
+	// Build an archive and remember where each event starts
+	var buf bytes.Buffer
+	var offsets []int64
+	for _, event := range events {
+		data, err := EncodeEvent(event)
+		if err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+		offsets = append(offsets, int64(buf.Len()))
+		buf.Write(data)
+		buf.WriteString("\n")
+	}
+	archive := bytes.NewReader(buf.Bytes())
+
+	event, end, err := DecodeEventAt(archive, offsets[2])
+	if err != nil {
+		t.Fatalf("Failed to decode event at offset: %v", err)
+	}
+	content, ok := event.(*TextMessageContentEvent)
+	if !ok {
+		t.Fatalf("Expected *TextMessageContentEvent, got %T", event)
+	}
+	if content.Delta != "Hello" {
+		t.Errorf("Expected delta Hello, got %s", content.Delta)
+	}
+	if end != offsets[3]-1 {
+		t.Errorf("Expected end offset %d, got %d", offsets[3]-1, end)
+	}
+
+	// The end offset can be used to continue reading the next event
+	event, _, err = DecodeEventAt(archive, end)
+	if err != nil {
+		t.Fatalf("Failed to decode event at end offset: %v", err)
+	}
+	if event.GetType() != EventTypeTextMessageEnd {
+		t.Errorf("Expected %s, got %s", EventTypeTextMessageEnd, event.GetType())
+	}
+
+	if _, _, err := DecodeEventAt(archive, int64(buf.Len())); err != io.EOF {
+		t.Errorf("Expected io.EOF at end of archive, got %v", err)
+	}
+}
+
+func TestRunAgentInputRequireMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		strict   bool
+		wantErr  bool
+	}{
+		{name: "NilDefault", messages: nil, strict: false, wantErr: false},
+		{name: "EmptyDefault", messages: []Message{}, strict: false, wantErr: false},
+		{name: "NonEmptyDefault", messages: []Message{NewUserMessage("msg_1", "Hello", "")}, strict: false, wantErr: false},
+		{name: "NilStrict", messages: nil, strict: true, wantErr: true},
+		{name: "EmptyStrict", messages: []Message{}, strict: true, wantErr: true},
+		{name: "NonEmptyStrict", messages: []Message{NewUserMessage("msg_1", "Hello", "")}, strict: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &RunAgentInput{ThreadID: "thread_1", RunID: "run_1", Messages: tt.messages}
+
+			var err error
+			if tt.strict {
+				err = input.ValidateWith(RequireMessages())
+			} else {
+				err = input.Validate()
+			}
+
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunAgentInputContextRules(t *testing.T) {
+	duplicate := []Context{
+		{Description: "user locale", Value: "en-US"},
+		{Description: "timezone", Value: "UTC"},
+		{Description: "user locale", Value: "fr-FR"},
+	}
+
+	tests := []struct {
+		name    string
+		context []Context
+		opts    []ValidateOption
+		wantErr string
+	}{
+		{name: "DuplicateDefault", context: duplicate},
+		{name: "EmptyDefault", context: nil},
+		{name: "DuplicateStrict", context: duplicate, opts: []ValidateOption{UniqueContextDescriptions()}, wantErr: "index 2"},
+		{name: "UniqueStrict", context: duplicate[:2], opts: []ValidateOption{UniqueContextDescriptions(), RequireContext()}},
+		{name: "EmptyStrict", context: []Context{}, opts: []ValidateOption{RequireContext()}, wantErr: "at least one context"},
+		{name: "EmptyUniqueOnly", context: nil, opts: []ValidateOption{UniqueContextDescriptions()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &RunAgentInput{ThreadID: "thread_1", RunID: "run_1", Context: tt.context}
+			err := input.ValidateWith(tt.opts...)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunAgentInputRoundTrip(t *testing.T) {
+	input := &RunAgentInput{
+		ThreadID: GenerateThreadID(),
+		RunID:    GenerateRunID(),
+		State:    map[string]interface{}{"conversation_count": float64(1)},
+		Messages: []Message{
+			NewSystemMessage("msg_1", "You are a helpful assistant.", ""),
+			NewUserMessage("msg_2", "What's the capital of France?", "user_123"),
+			NewAssistantMessage("msg_3", "", "", []ToolCall{searchCall("tool_call_1")}),
+			NewToolMessage("msg_4", "Paris", "tool_call_1", "", ""),
+		},
+		Tools: []Tool{
+			{
+				Name:        "search",
+				Description: "Search for information",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "The search query",
+						},
+					},
+					"required": []interface{}{"query"},
+				},
+			},
+		},
+		Context: []Context{
+			{
+				Description: "User preferences",
+				Value:       "The user prefers concise answers",
+			},
+		},
+		ForwardedProps: map[string]interface{}{"client_version": "1.0.0"},
+	}
+
+	data, err := input.Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode input: %v", err)
+	}
+
+	decoded, err := DecodeRunAgentInput(data)
+	if err != nil {
+		t.Fatalf("Failed to decode input: %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("Round trip mismatch:\nexpected %+v\ngot      %+v", input, decoded)
+	}
+
+	if _, err := DecodeRunAgentInput([]byte(`{"threadId":"t","runId":"r","messages":[{"id":"m","role":"robot"}]}`)); err == nil {
+		t.Error("Expected error for message with unknown role")
+	}
+}
+
+func TestEncodeEventsToChan(t *testing.T) {
+	events := make(chan Event)
+	frames, errs := EncodeEventsToChan(context.Background(), events)
+
+	input := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+	go func() {
+		for _, event := range input {
+			events <- event
+		}
+		close(events)
+	}()
+
+	var decoded []Event
+	for frame := range frames {
+		event, err := DecodeEventFromBytes(frame)
+		if err != nil {
+			t.Fatalf("Failed to decode frame: %v", err)
+		}
+		decoded = append(decoded, event)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(decoded) != len(input) {
+		t.Fatalf("Expected %d frames, got %d", len(input), len(decoded))
+	}
+	for i, event := range decoded {
+		if event.GetType() != input[i].GetType() {
+			t.Errorf("Frame %d type mismatch: expected %s, got %s", i, input[i].GetType(), event.GetType())
+		}
+	}
+}
+
+func TestEncodeEventsToChanErrors(t *testing.T) {
+	// Validation errors are propagated
+	events := make(chan Event, 1)
+	events <- NewTextMessageContentEvent("msg_1", "")
+	frames, errs := EncodeEventsToChan(context.Background(), events)
+	for range frames {
+		t.Error("Expected no frames for an invalid event")
+	}
+	if err := <-errs; !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Expected ErrValidationFailed, got: %v", err)
+	}
+
+	// Cancellation stops encoding
+	ctx, cancel := context.WithCancel(context.Background())
+	frames, errs = EncodeEventsToChan(ctx, make(chan Event))
+	cancel()
+	for range frames {
+		t.Error("Expected no frames after cancellation")
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestTimestampFieldDecoding(t *testing.T) {
+	stream := `{"type":"RUN_STARTED","emittedAt":1700000000123,"threadId":"thread_1","runId":"run_1"}
+{"type":"RUN_FINISHED","emittedAt":1700000000456,"threadId":"thread_1","runId":"run_1"}
+`
+	decoder := NewDecoder(strings.NewReader(stream), WithTimestampField("emittedAt"), WithStrictDecoding())
+	for _, expected := range []int64{1700000000123, 1700000000456} {
+		event, err := decoder.DecodeEvent()
+		if err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if ts := event.GetTimestamp(); ts == nil || *ts != expected {
+			t.Errorf("Expected timestamp %d, got %v", expected, ts)
+		}
+	}
+
+	// Without the option the renamed field is not recognized
+	event, err := DecodeEventFromBytes([]byte(`{"type":"RUN_STARTED","emittedAt":1,"threadId":"t","runId":"r"}`))
+	if err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if event.GetTimestamp() != nil {
+		t.Errorf("Expected no timestamp by default, got %d", *event.GetTimestamp())
+	}
+}
+
+func TestStreamDecodeAny(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`,
+		`{"id":"msg_1","role":"user","content":"Hello"}`,
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_2","role":"assistant"}`,
+		`{"id":"msg_3","role":"tool","content":"Sunny","toolCallId":"tool_call_1"}`,
+		`{"type":"RUN_FINISHED","threadId":"thread_1","runId":"run_1"}`,
+	}, "\n")
+
+	values, errs := NewStreamDecoder(strings.NewReader(stream)).DecodeAny()
+
+	var kinds []string
+	for value := range values {
+		switch v := value.(type) {
+		case Event:
+			kinds = append(kinds, v.EventTypeName())
+		case Message:
+			kinds = append(kinds, v.MessageType())
+		default:
+			t.Errorf("Unexpected value type %T", value)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "RunStartedEvent,UserMessage,TextMessageStartEvent,ToolMessage,RunFinishedEvent"
+	if got := strings.Join(kinds, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	values, errs = NewStreamDecoder(strings.NewReader(`{"id":"x"}`)).DecodeAny()
+	for range values {
+		t.Error("Expected no values for an unknown value")
+	}
+	if err := <-errs; !errors.Is(err, ErrInvalidStructure) {
+		t.Errorf("Expected ErrInvalidStructure, got: %v", err)
+	}
+}
+
+func TestCaseInsensitiveTypes(t *testing.T) {
+	data := []byte(`{"type":"run_started","threadId":"thread_1","runId":"run_1"}`)
+
+	// Strict by default
+	if _, err := DecodeEventFromBytes(data); !errors.Is(err, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType by default, got %v", err)
+	}
+
+	event, err := DecodeEventFromBytes(data, WithCaseInsensitiveTypes())
+	if err != nil {
+		t.Fatalf("Failed to decode lowercase event: %v", err)
+	}
+	started, ok := event.(*RunStartedEvent)
+	if !ok {
+		t.Fatalf("Expected *RunStartedEvent, got %T", event)
+	}
+	if started.Type != EventTypeRunStarted || started.RunID != "run_1" {
+		t.Errorf("Unexpected event: %+v", started)
+	}
+
+	decoder := NewDecoder(strings.NewReader(`{"type":"Text_Message_End","messageId":"msg_1"}`), WithCaseInsensitiveTypes())
+	event, err = decoder.DecodeEvent()
+	if err != nil {
+		t.Fatalf("Failed to decode mixed-case event: %v", err)
+	}
+	if event.GetType() != EventTypeTextMessageEnd {
+		t.Errorf("Expected %s, got %s", EventTypeTextMessageEnd, event.GetType())
+	}
+}
+
+func TestCaseInsensitiveRoles(t *testing.T) {
+	data := []byte(`{"id":"msg_1","role":"User","content":"Hello"}`)
+
+	// Strict by default
+	if _, err := DecodeMessageFromBytes(data); !errors.Is(err, ErrInvalidMessageType) {
+		t.Errorf("Expected ErrInvalidMessageType by default, got %v", err)
+	}
+
+	stream := `{"id":"msg_1","role":"User","content":"Hello"}
+{"id":"msg_2","role":"ASSISTANT","content":"Hi there"}
+{"id":"msg_3","role":"Tool","content":"Sunny","toolCallId":"tool_call_1"}
+`
+	decoder := NewDecoder(strings.NewReader(stream), WithCaseInsensitiveRoles())
+	for _, expected := range []Role{RoleUser, RoleAssistant, RoleTool} {
+		message, err := decoder.DecodeMessage()
+		if err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		if message.GetRole() != expected {
+			t.Errorf("Expected role %s, got %s", expected, message.GetRole())
+		}
+	}
+}
+
+func TestDecodeWithLeadingBOM(t *testing.T) {
+	data := append([]byte("\xEF\xBB\xBF\r\n  "), `{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`...)
+	event, err := DecodeEventFromBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to decode event with BOM: %v", err)
+	}
+	if event.GetType() != EventTypeRunStarted {
+		t.Errorf("Expected %s, got %s", EventTypeRunStarted, event.GetType())
+	}
+
+	message, err := DecodeMessageFromBytes([]byte("\xEF\xBB\xBF" + `{"id":"msg_1","role":"user","content":"Hello"}`))
+	if err != nil {
+		t.Fatalf("Failed to decode message with BOM: %v", err)
+	}
+	if message.GetID() != "msg_1" {
+		t.Errorf("Expected msg_1, got %s", message.GetID())
+	}
+}
+
+func TestDecoderInputOffset(t *testing.T) {
+	lines := []string{
+		`{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`,
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"assistant"}`,
+		`{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}`,
+	}
+	stream := strings.Join(lines, "\n") + "\n"
+
+	for _, tt := range []struct {
+		name string
+		opts []DecoderOption
+		ends []int64
+	}{
+		{name: "JSON", ends: []int64{
+			int64(len(lines[0])),
+			int64(len(lines[0]) + 1 + len(lines[1])),
+			int64(len(lines[0]) + 1 + len(lines[1]) + 1 + len(lines[2])),
+		}},
+		{name: "Delimited", opts: []DecoderOption{WithDecodeDelimiter([]byte("\n"))}, ends: []int64{
+			int64(len(lines[0]) + 1),
+			int64(len(lines[0]) + 1 + len(lines[1]) + 1),
+			int64(len(stream)),
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewDecoder(strings.NewReader(stream), tt.opts...)
+			if decoder.InputOffset() != 0 {
+				t.Errorf("Expected offset 0 before decoding, got %d", decoder.InputOffset())
+			}
+			for i, end := range tt.ends {
+				if _, err := decoder.DecodeEvent(); err != nil {
+					t.Fatalf("Failed to decode event %d: %v", i, err)
+				}
+				if decoder.InputOffset() != end {
+					t.Errorf("After event %d: expected offset %d, got %d", i, end, decoder.InputOffset())
+				}
+			}
+		})
+	}
+
+	// Errors name the offset of the failing value
+	bad := lines[0] + "\n" + `{"type":"TEXT_MESSAGE_END"}` + "\n"
+	stream2 := NewStreamDecoder(strings.NewReader(bad))
+	events, errs := stream2.DecodeEvents()
+	for range events {
+	}
+	err := <-errs
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("at input offset %d", len(lines[0]))) {
+		t.Errorf("Expected error at input offset %d, got %v", len(lines[0]), err)
+	}
+	if !strings.Contains(err.Error(), "message ID is required") {
+		t.Errorf("Expected the underlying error to be preserved, got %v", err)
+	}
+}
+
+func TestPreferRawEvent(t *testing.T) {
+	inner := `{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"Hello"}`
+	wrapped := `{"type":"CUSTOM","name":"proxied","value":true,"rawEvent":{"type":"RAW","event":{},"rawEvent":` + inner + `}}`
+
+	// By default the outer event is returned
+	event, err := DecodeEventFromBytes([]byte(wrapped))
+	if err != nil {
+		t.Fatalf("Failed to decode wrapped event: %v", err)
+	}
+	if event.GetType() != EventTypeCustom {
+		t.Errorf("Expected the outer CUSTOM event by default, got %s", event.GetType())
+	}
+
+	event, err = DecodeEventFromBytes([]byte(wrapped), WithPreferRawEvent())
+	if err != nil {
+		t.Fatalf("Failed to decode wrapped event: %v", err)
+	}
+	content, ok := event.(*TextMessageContentEvent)
+	if !ok {
+		t.Fatalf("Expected the innermost *TextMessageContentEvent, got %T", event)
+	}
+	if content.MessageID != "msg_1" || content.Delta != "Hello" {
+		t.Errorf("Unexpected inner event: %+v", content)
+	}
+
+	// A rawEvent without a recognized type leaves the outer event in place
+	opaque := `{"type":"TEXT_MESSAGE_END","messageId":"msg_1","rawEvent":{"kind":"provider-specific"}}`
+	event, err = DecodeEventFromBytes([]byte(opaque), WithPreferRawEvent())
+	if err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if event.GetType() != EventTypeTextMessageEnd {
+		t.Errorf("Expected the outer event, got %s", event.GetType())
+	}
+}
+
+func TestRequireTimestamp(t *testing.T) {
+	withTimestamp := []byte(`{"type":"RUN_STARTED","timestamp":1700000000000,"threadId":"thread_1","runId":"run_1"}`)
+	withoutTimestamp := []byte(`{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		opts    []DecoderOption
+		wantErr bool
+	}{
+		{name: "WithTimestampDefault", data: withTimestamp},
+		{name: "WithoutTimestampDefault", data: withoutTimestamp},
+		{name: "WithTimestampRequired", data: withTimestamp, opts: []DecoderOption{WithRequireTimestamp()}},
+		{name: "WithoutTimestampRequired", data: withoutTimestamp, opts: []DecoderOption{WithRequireTimestamp()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeEventFromBytes(tt.data, tt.opts...)
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "timestamp is required")) {
+				t.Errorf("Expected timestamp error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	timed := NewTextMessageStartEvent("msg_1")
+	untimed := &TextMessageStartEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageStart}, MessageID: "msg_1", Role: RoleAssistant}
+	if err := untimed.Validate(); err != nil {
+		t.Errorf("Unexpected error from Validate without timestamp: %v", err)
+	}
+	if err := ValidateStrict(timed); err != nil {
+		t.Errorf("Unexpected error from ValidateStrict with timestamp: %v", err)
+	}
+	if err := ValidateStrict(untimed); err == nil {
+		t.Error("Expected ValidateStrict to reject an event without timestamp")
+	}
+	if err := ValidateStrict(&TextMessageStartEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageStart}}); err == nil || strings.Contains(err.Error(), "timestamp") {
+		t.Errorf("Expected ValidateStrict to report the regular validation error first, got %v", err)
+	}
+}
+
+func TestStreamDecoderWithMaxEvents(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		data, err := EncodeEvent(NewTextMessageContentEvent("msg_1", "chunk"))
+		if err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+		buf.Write(data)
+		buf.WriteString("\n")
+	}
+
+	eventChan, errorChan := NewStreamDecoder(&buf, WithMaxEvents(3)).DecodeEvents()
+
+	var count int
+	var streamErr error
+	for eventChan != nil || errorChan != nil {
+		select {
+		case _, ok := <-eventChan:
+			if !ok {
+				eventChan = nil
+				continue
+			}
+			count++
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+				continue
+			}
+			streamErr = err
+		}
+	}
+
+	if count != 3 {
+		t.Errorf("Expected 3 events, got %d", count)
+	}
+	if !errors.Is(streamErr, ErrMaxEventsReached) {
+		t.Errorf("Expected ErrMaxEventsReached, got %v", streamErr)
+	}
+}
+
+func TestStreamDecoderCallbacks(t *testing.T) {
+	stream := `{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}
+{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"assistant"}
+{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}
+{"type":"UNKNOWN"}
+`
+	var seen []EventType
+	var seenErrs []error
+	eventChan, errorChan := NewStreamDecoder(strings.NewReader(stream),
+		WithOnEvent(func(event Event) { seen = append(seen, event.GetType()) }),
+		WithOnError(func(err error) { seenErrs = append(seenErrs, err) }),
+	).DecodeEvents()
+
+	var received int
+	var streamErr error
+	for eventChan != nil || errorChan != nil {
+		select {
+		case _, ok := <-eventChan:
+			if !ok {
+				eventChan = nil
+				continue
+			}
+			received++
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil
+				continue
+			}
+			streamErr = err
+		}
+	}
+
+	want := []EventType{EventTypeRunStarted, EventTypeTextMessageStart, EventTypeTextMessageEnd}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("OnEvent saw %v, want %v", seen, want)
+	}
+	if received != len(want) {
+		t.Errorf("Expected %d events on the channel, got %d", len(want), received)
+	}
+	if len(seenErrs) != 1 || seenErrs[0] != streamErr {
+		t.Errorf("Expected OnError to see the stream error %v, got %v", streamErr, seenErrs)
+	}
+	if !errors.Is(streamErr, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType, got %v", streamErr)
+	}
+}
+
+func TestStreamDecoderMixedSeparators(t *testing.T) {
+	stream := "\r\n  " +
+		`{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}` + "\n" +
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"assistant"}` + " " +
+		`{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"Hi"}` +
+		`{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}` + "\t\r\n" +
+		`{"type":"RUN_FINISHED","threadId":"thread_1","runId":"run_1"}`
+
+	eventChan, errorChan := NewStreamDecoder(strings.NewReader(stream)).DecodeEvents()
+
+	var got []EventType
+	for event := range eventChan {
+		got = append(got, event.GetType())
+	}
+	if err := <-errorChan; err != nil {
+		t.Fatalf("Unexpected stream error: %v", err)
+	}
+
+	want := []EventType{
+		EventTypeRunStarted,
+		EventTypeTextMessageStart,
+		EventTypeTextMessageContent,
+		EventTypeTextMessageEnd,
+		EventTypeRunFinished,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decoded %v, want %v", got, want)
+	}
+}
+
+func TestDecoderDecodeAll(t *testing.T) {
+	stream := `{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}
+{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"assistant"}
+{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"Hi"}
+{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}
+{"type":"RUN_FINISHED","threadId":"thread_1","runId":"run_1"}
+`
+	events, err := NewDecoder(strings.NewReader(stream)).DecodeAll()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events, got %d", len(events))
+	}
+	if content, ok := events[2].(*TextMessageContentEvent); !ok || content.Delta != "Hi" {
+		t.Errorf("Unexpected third event: %#v", events[2])
+	}
+
+	events, err = NewDecoder(strings.NewReader("")).DecodeAll()
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected no events and no error for empty input, got %d events and %v", len(events), err)
+	}
+
+	broken := `{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}
+{"type":"UNKNOWN"}
+{"type":"RUN_FINISHED","threadId":"thread_1","runId":"run_1"}
+`
+	events, err = NewDecoder(strings.NewReader(broken)).DecodeAll()
+	if !errors.Is(err, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType, got %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("Expected the event before the error to be returned, got %d events", len(events))
+	}
+}
+
+func TestRunAgentInputValidateIDs(t *testing.T) {
+	messages := func(toolCallID string) []Message {
+		return []Message{
+			NewUserMessage("msg_1", "What's the weather?", ""),
+			NewAssistantMessage("msg_2", "", "", []ToolCall{{ID: "tc_1", Type: ToolCallTypeFunction, Function: FunctionCall{Name: "search", Arguments: "{}"}}}),
+			NewToolMessage("msg_3", "Sunny", toolCallID, "", ""),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		input   RunAgentInput
+		opts    []ValidateOption
+		wantErr string
+	}{
+		{
+			name:  "Clean",
+			input: RunAgentInput{ThreadID: "thread_1", RunID: "run_1", Messages: messages("tc_1")},
+		},
+		{
+			name:    "TooLongRunID",
+			input:   RunAgentInput{ThreadID: "thread_1", RunID: strings.Repeat("r", DefaultMaxIDLength+1)},
+			wantErr: "runId is 257 bytes long, exceeding the maximum of 256",
+		},
+		{
+			name:    "CustomMaxLength",
+			input:   RunAgentInput{ThreadID: "thread_1", RunID: "run_1", Messages: messages("tc_1")},
+			opts:    []ValidateOption{MaxIDLength(4)},
+			wantErr: "threadId is 8 bytes long, exceeding the maximum of 4",
+		},
+		{
+			name:    "WhitespaceToolCallID",
+			input:   RunAgentInput{ThreadID: "thread_1", RunID: "run_1", Messages: messages(" \t")},
+			wantErr: "messages[2].toolCallId must not be empty or whitespace",
+		},
+		{
+			name:    "InvalidUTF8",
+			input:   RunAgentInput{ThreadID: "thread_\xff", RunID: "run_1"},
+			wantErr: "threadId must be valid UTF-8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.ValidateIDs(tt.opts...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	whitespace := RunAgentInput{ThreadID: "thread_1", RunID: "run_1", Messages: messages(" \t")}
+	if err := whitespace.Validate(); err != nil {
+		t.Errorf("Expected Validate to stay lenient, got %v", err)
+	}
+}
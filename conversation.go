@@ -0,0 +1,272 @@
+package agui
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ValidateConversation checks that the snapshot forms a coherent conversation,
+// beyond the per-message checks done by Validate. It reports an error when:
+//
+//   - the first message is a tool message
+//   - a tool message does not directly follow (possibly after other tool messages)
+//     the assistant message that made the matching tool call
+//   - a tool call is answered more than once
+//   - a non-tool message appears while tool calls of the preceding assistant
+//     message are still unanswered
+//   - roles do not alternate: a system or developer message follows a user,
+//     assistant or tool message, a user message directly follows another user
+//     message, or an assistant message directly follows another assistant
+//     message with no tool messages in between
+//
+// Tool calls left unanswered by the last assistant message are allowed, since
+// a snapshot may be taken while tools are still running.
+func (m *MessagesSnapshotEvent) ValidateConversation() error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	var lastAssistant *AssistantMessage
+	var previous Role
+	pending := make(map[string]bool)
+	answered := make(map[string]bool)
+
+	for i, msg := range m.Messages {
+		role := msg.GetRole()
+		if err := checkRoleOrder(previous, role); err != nil {
+			return fmt.Errorf("message at index %d: %v", i, err)
+		}
+		previous = role
+
+		if tool, ok := msg.(*ToolMessage); ok {
+			if i == 0 {
+				return fmt.Errorf("message at index %d: conversation must not start with a tool message", i)
+			}
+			if lastAssistant == nil {
+				return fmt.Errorf("message at index %d: tool message %s does not follow an assistant message", i, tool.ID)
+			}
+			if answered[tool.ToolCallID] {
+				return fmt.Errorf("message at index %d: tool call %s is answered more than once", i, tool.ToolCallID)
+			}
+			if !pending[tool.ToolCallID] {
+				return fmt.Errorf("message at index %d: tool message %s responds to unknown tool call %s of assistant message %s", i, tool.ID, tool.ToolCallID, lastAssistant.ID)
+			}
+			delete(pending, tool.ToolCallID)
+			answered[tool.ToolCallID] = true
+			continue
+		}
+
+		if len(pending) > 0 {
+			return fmt.Errorf("message at index %d: %s message appears before %d tool call(s) of assistant message %s are answered", i, msg.GetRole(), len(pending), lastAssistant.ID)
+		}
+
+		lastAssistant = nil
+		if assistant, ok := msg.(*AssistantMessage); ok {
+			lastAssistant = assistant
+			for _, tc := range assistant.ToolCalls {
+				pending[tc.ID] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRoleOrder reports whether a message with role may follow one with
+// previous in a conversation, as described for ValidateConversation. previous
+// is empty for the first message.
+func checkRoleOrder(previous, role Role) error {
+	switch role {
+	case RoleSystem, RoleDeveloper:
+		if previous != "" && previous != RoleSystem && previous != RoleDeveloper {
+			return fmt.Errorf("%s message must come before the rest of the conversation", role)
+		}
+	case RoleUser:
+		if previous == RoleUser {
+			return fmt.Errorf("user message directly follows another user message")
+		}
+	case RoleAssistant:
+		if previous == RoleAssistant {
+			return fmt.Errorf("assistant message directly follows another assistant message")
+		}
+	}
+	return nil
+}
+
+// DiffSnapshots compares two message snapshots by message ID. It returns the
+// messages only present in new (added), those only present in old (removed),
+// and the new version of messages whose content differs (changed). Added and
+// changed messages follow the order of new, removed messages the order of old.
+// A nil snapshot is treated as empty.
+func DiffSnapshots(old, new *MessagesSnapshotEvent) (added, removed, changed []Message) {
+	oldByID := make(map[string]Message)
+	if old != nil {
+		for _, msg := range old.Messages {
+			oldByID[msg.GetID()] = msg
+		}
+	}
+	newIDs := make(map[string]bool)
+
+	if new != nil {
+		for _, msg := range new.Messages {
+			newIDs[msg.GetID()] = true
+			previous, ok := oldByID[msg.GetID()]
+			if !ok {
+				added = append(added, msg)
+			} else if !reflect.DeepEqual(previous, msg) {
+				changed = append(changed, msg)
+			}
+		}
+	}
+
+	if old != nil {
+		for _, msg := range old.Messages {
+			if !newIDs[msg.GetID()] {
+				removed = append(removed, msg)
+			}
+		}
+	}
+
+	return added, removed, changed
+}
+
+// BuildConversation reconstructs the conversation described by events as a list
+// of messages. A MESSAGES_SNAPSHOT replaces the conversation built so far, and
+// streamed text, tool call and tool call result lifecycles are added as they
+// complete. A streamed tool call is merged into the assistant message named by
+// its parent message ID when that message is already present. Messages taken
+// from snapshots are copied, so events are not modified. Streamed text messages
+// are reassembled with opts, so WithLenientRoles keeps their declared roles.
+func BuildConversation(events []Event, opts ...TextMessageAssemblerOption) ([]Message, error) {
+	builder := newConversationBuilder(opts...)
+	for i, event := range events {
+		if err := builder.add(event); err != nil {
+			return nil, fmt.Errorf("%w: event %d: %v", ErrInvalidSequence, i, err)
+		}
+	}
+	return builder.conversation, nil
+}
+
+// conversationBuilder incrementally reconstructs a conversation from events,
+// as described for BuildConversation.
+type conversationBuilder struct {
+	conversation []Message
+	index        map[string]int // assistant message ID to its position
+	assembler    *messageAssembler
+}
+
+// newConversationBuilder creates an empty conversationBuilder whose text
+// messages are reassembled with opts.
+func newConversationBuilder(opts ...TextMessageAssemblerOption) *conversationBuilder {
+	return &conversationBuilder{
+		index:     make(map[string]int),
+		assembler: newMessageAssembler(opts...),
+	}
+}
+
+// add updates the conversation with event.
+func (b *conversationBuilder) add(event Event) error {
+	if snapshot, ok := event.(*MessagesSnapshotEvent); ok {
+		b.conversation = nil
+		b.index = make(map[string]int)
+		for _, message := range snapshot.Messages {
+			if message.GetRole() == RoleAssistant {
+				b.index[message.GetID()] = len(b.conversation)
+			}
+			b.conversation = append(b.conversation, CloneMessage(message))
+		}
+		return nil
+	}
+
+	message, err := b.assembler.add(event)
+	if message == nil || err != nil {
+		return err
+	}
+
+	if assistant, ok := message.(*AssistantMessage); ok {
+		if i, ok := b.index[assistant.ID]; ok {
+			if existing, ok := b.conversation[i].(*AssistantMessage); ok {
+				if existing.Content == "" {
+					existing.Content = assistant.Content
+				}
+				existing.ToolCalls = append(existing.ToolCalls, assistant.ToolCalls...)
+				return nil
+			}
+		}
+		b.index[assistant.ID] = len(b.conversation)
+	}
+	b.conversation = append(b.conversation, message)
+	return nil
+}
+
+// ValidateOrdered checks that the snapshot's messages are sorted according to
+// less, reporting the index of the first message that sorts before its
+// predecessor.
+func (m *MessagesSnapshotEvent) ValidateOrdered(less func(a, b Message) bool) error {
+	for i := 1; i < len(m.Messages); i++ {
+		if less(m.Messages[i], m.Messages[i-1]) {
+			return fmt.Errorf("message at index %d: %s is out of order after %s", i, m.Messages[i].GetID(), m.Messages[i-1].GetID())
+		}
+	}
+	return nil
+}
+
+// RepairSnapshot returns a copy of snap without tool messages whose ToolCallID
+// does not match a tool call of any assistant message in the snapshot, along
+// with one error per dropped message. Indexes in the errors refer to snap. The
+// remaining messages are shared with snap, not copied. Validation of snapshots
+// is unaffected; repair only happens when RepairSnapshot is called explicitly.
+func RepairSnapshot(snap *MessagesSnapshotEvent) (*MessagesSnapshotEvent, []error) {
+	if snap == nil {
+		return nil, nil
+	}
+
+	calls := make(map[string]bool)
+	for _, msg := range snap.Messages {
+		if assistant, ok := msg.(*AssistantMessage); ok {
+			for _, tc := range assistant.ToolCalls {
+				calls[tc.ID] = true
+			}
+		}
+	}
+
+	repaired := *snap
+	repaired.Messages = make([]Message, 0, len(snap.Messages))
+	var errs []error
+	for i, msg := range snap.Messages {
+		if tool, ok := msg.(*ToolMessage); ok && !calls[tool.ToolCallID] {
+			errs = append(errs, fmt.Errorf("message at index %d: tool message %s responds to unknown tool call %s", i, tool.ID, tool.ToolCallID))
+			continue
+		}
+		repaired.Messages = append(repaired.Messages, msg)
+	}
+	return &repaired, errs
+}
+
+// PageSnapshot splits snap into snapshots of at most pageSize messages each,
+// preserving message order, so that clients can render large conversations
+// progressively. Every page carries the BaseEvent fields of snap and passes
+// Validate on its own, but tool messages may land on a different page than the
+// assistant message whose tool call they answer. A pageSize below one yields a
+// single page. The messages are shared with snap, not copied.
+func PageSnapshot(snap *MessagesSnapshotEvent, pageSize int) []*MessagesSnapshotEvent {
+	if snap == nil {
+		return nil
+	}
+	if pageSize < 1 || len(snap.Messages) <= pageSize {
+		page := *snap
+		return []*MessagesSnapshotEvent{&page}
+	}
+
+	pages := make([]*MessagesSnapshotEvent, 0, (len(snap.Messages)+pageSize-1)/pageSize)
+	for start := 0; start < len(snap.Messages); start += pageSize {
+		end := start + pageSize
+		if end > len(snap.Messages) {
+			end = len(snap.Messages)
+		}
+		page := *snap
+		page.Messages = snap.Messages[start:end:end]
+		pages = append(pages, &page)
+	}
+	return pages
+}
@@ -0,0 +1,323 @@
+package agui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func searchCall(id string) ToolCall {
+	return ToolCall{
+		ID:       id,
+		Type:     ToolCallTypeFunction,
+		Function: FunctionCall{Name: "search", Arguments: `{"query":"weather"}`},
+	}
+}
+
+func TestValidateConversation(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		wantErr  string
+	}{
+		{
+			name: "Coherent",
+			messages: []Message{
+				NewSystemMessage("msg_1", "You are helpful.", ""),
+				NewUserMessage("msg_2", "What's the weather?", ""),
+				NewAssistantMessage("msg_3", "", "", []ToolCall{searchCall("tc_1"), searchCall("tc_2")}),
+				NewToolMessage("msg_4", "Sunny", "tc_1", "", ""),
+				NewToolMessage("msg_5", "Warm", "tc_2", "", ""),
+				NewAssistantMessage("msg_6", "It's sunny and warm.", "", nil),
+				NewUserMessage("msg_7", "Thanks!", ""),
+			},
+		},
+		{
+			name: "PendingToolCallsAtEnd",
+			messages: []Message{
+				NewUserMessage("msg_1", "What's the weather?", ""),
+				NewAssistantMessage("msg_2", "", "", []ToolCall{searchCall("tc_1")}),
+			},
+		},
+		{
+			name: "StartsWithToolMessage",
+			messages: []Message{
+				NewToolMessage("msg_1", "Sunny", "tc_1", "", ""),
+			},
+			wantErr: "must not start with a tool message",
+		},
+		{
+			name: "ToolMessageAfterUser",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewToolMessage("msg_2", "Sunny", "tc_1", "", ""),
+			},
+			wantErr: "does not follow an assistant message",
+		},
+		{
+			name: "UnknownToolCall",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewAssistantMessage("msg_2", "", "", []ToolCall{searchCall("tc_1")}),
+				NewToolMessage("msg_3", "Sunny", "tc_other", "", ""),
+			},
+			wantErr: "unknown tool call tc_other",
+		},
+		{
+			name: "DuplicateAnswer",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewAssistantMessage("msg_2", "", "", []ToolCall{searchCall("tc_1")}),
+				NewToolMessage("msg_3", "Sunny", "tc_1", "", ""),
+				NewToolMessage("msg_4", "Sunny again", "tc_1", "", ""),
+			},
+			wantErr: "answered more than once",
+		},
+		{
+			name: "UserInterruptsToolCalls",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewAssistantMessage("msg_2", "", "", []ToolCall{searchCall("tc_1")}),
+				NewUserMessage("msg_3", "Hello?", ""),
+			},
+			wantErr: "are answered",
+		},
+		{
+			name: "ConsecutiveUserMessages",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewUserMessage("msg_2", "Hello?", ""),
+			},
+			wantErr: "follows another user message",
+		},
+		{
+			name: "ConsecutiveAssistantMessages",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewAssistantMessage("msg_2", "Hello.", "", nil),
+				NewAssistantMessage("msg_3", "How can I help?", "", nil),
+			},
+			wantErr: "follows another assistant message",
+		},
+		{
+			name: "SystemMessageMidConversation",
+			messages: []Message{
+				NewUserMessage("msg_1", "Hi", ""),
+				NewSystemMessage("msg_2", "Be brief.", ""),
+				NewAssistantMessage("msg_3", "Hello.", "", nil),
+			},
+			wantErr: "system message must come before",
+		},
+		{
+			name: "SystemThenDeveloper",
+			messages: []Message{
+				NewSystemMessage("msg_1", "You are helpful.", ""),
+				NewDeveloperMessage("msg_2", "Answer in English.", ""),
+				NewUserMessage("msg_3", "Hi", ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewMessagesSnapshotEvent(tt.messages).ValidateConversation()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	old := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_1", "Hi", ""),
+		NewAssistantMessage("msg_2", "Hello", "", nil),
+		NewUserMessage("msg_3", "Draft", ""),
+	})
+	new := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_1", "Hi", ""),
+		NewAssistantMessage("msg_2", "Hello! How can I help?", "", nil),
+		NewUserMessage("msg_4", "What's the weather?", ""),
+	})
+
+	added, removed, changed := DiffSnapshots(old, new)
+
+	if len(added) != 1 || added[0].GetID() != "msg_4" {
+		t.Errorf("Expected msg_4 to be added, got %v", added)
+	}
+	if len(removed) != 1 || removed[0].GetID() != "msg_3" {
+		t.Errorf("Expected msg_3 to be removed, got %v", removed)
+	}
+	if len(changed) != 1 || changed[0].GetID() != "msg_2" {
+		t.Fatalf("Expected msg_2 to be changed, got %v", changed)
+	}
+	if changed[0].(*AssistantMessage).Content != "Hello! How can I help?" {
+		t.Errorf("Expected the new version of the changed message, got %+v", changed[0])
+	}
+
+	added, removed, changed = DiffSnapshots(nil, old)
+	if len(added) != 3 || len(removed) != 0 || len(changed) != 0 {
+		t.Errorf("Expected all messages added from a nil snapshot, got %d/%d/%d", len(added), len(removed), len(changed))
+	}
+}
+
+func TestBuildConversation(t *testing.T) {
+	snapshot := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_1", "What's the weather?", ""),
+	})
+	events := []Event{
+		snapshot,
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_2", "Let me check."),
+		NewTextMessageEndEvent("msg_2"),
+		NewToolCallStartEvent("tc_1", "search", "msg_2"),
+		NewToolCallArgsEvent("tc_1", `{"query":"weather"}`),
+		NewToolCallEndEvent("tc_1"),
+		NewToolCallResultEvent("msg_3", "tc_1", "Sunny"),
+		NewTextMessageStartEvent("msg_4"),
+		NewTextMessageContentEvent("msg_4", "It's sunny."),
+		NewTextMessageEndEvent("msg_4"),
+	}
+
+	conversation, err := BuildConversation(events)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(conversation) != 4 {
+		t.Fatalf("Expected 4 messages, got %d", len(conversation))
+	}
+	assistant, ok := conversation[1].(*AssistantMessage)
+	if !ok || assistant.Content != "Let me check." || len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Function.Name != "search" {
+		t.Errorf("Expected tool call merged into msg_2, got %+v", conversation[1])
+	}
+	if tool, ok := conversation[2].(*ToolMessage); !ok || tool.ToolCallID != "tc_1" {
+		t.Errorf("Expected tool result message, got %+v", conversation[2])
+	}
+	if err := NewMessagesSnapshotEvent(conversation).ValidateConversation(); err != nil {
+		t.Errorf("Built conversation is incoherent: %v", err)
+	}
+
+	// A later snapshot replaces everything before it
+	replaced, err := BuildConversation(append(events, NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_9", "Start over", ""),
+	})))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(replaced) != 1 || replaced[0].GetID() != "msg_9" {
+		t.Errorf("Expected the snapshot to replace the conversation, got %d messages", len(replaced))
+	}
+
+	if _, err := BuildConversation([]Event{NewToolCallEndEvent("tc_9")}); err == nil {
+		t.Error("Expected error for a broken tool call lifecycle")
+	}
+}
+
+func TestValidateOrdered(t *testing.T) {
+	byID := func(a, b Message) bool { return a.GetID() < b.GetID() }
+
+	ordered := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_001", "Hi", ""),
+		NewAssistantMessage("msg_002", "Hello", "", nil),
+		NewUserMessage("msg_002", "Same ID sorts equal", ""),
+		NewUserMessage("msg_010", "Bye", ""),
+	})
+	if err := ordered.ValidateOrdered(byID); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	unordered := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_001", "Hi", ""),
+		NewUserMessage("msg_003", "Later", ""),
+		NewAssistantMessage("msg_002", "Earlier", "", nil),
+	})
+	err := unordered.ValidateOrdered(byID)
+	if err == nil || !strings.Contains(err.Error(), "index 2") {
+		t.Errorf("Expected error at index 2, got %v", err)
+	}
+}
+
+func TestRepairSnapshot(t *testing.T) {
+	snap := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_1", "What's the weather?", ""),
+		NewAssistantMessage("msg_2", "", "", []ToolCall{searchCall("tc_1")}),
+		NewToolMessage("msg_3", "Sunny", "tc_1", "", ""),
+		NewToolMessage("msg_4", "Stale", "tc_missing", "", ""),
+		NewAssistantMessage("msg_5", "It's sunny.", "", nil),
+	})
+	if err := snap.ValidateConversation(); err == nil {
+		t.Fatal("Expected the dangling tool message to fail validation")
+	}
+
+	repaired, errs := RepairSnapshot(snap)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "index 3") || !strings.Contains(errs[0].Error(), "tc_missing") {
+		t.Errorf("Expected one error for the tool message at index 3, got %v", errs)
+	}
+	if len(repaired.Messages) != 4 {
+		t.Fatalf("Expected 4 messages after repair, got %d", len(repaired.Messages))
+	}
+	for _, msg := range repaired.Messages {
+		if msg.GetID() == "msg_4" {
+			t.Error("Expected dangling tool message to be dropped")
+		}
+	}
+	if err := repaired.ValidateConversation(); err != nil {
+		t.Errorf("Unexpected error validating repaired snapshot: %v", err)
+	}
+	if len(snap.Messages) != 5 {
+		t.Errorf("Expected original snapshot to be unchanged, got %d messages", len(snap.Messages))
+	}
+
+	clean, errs := RepairSnapshot(repaired)
+	if len(errs) != 0 || len(clean.Messages) != 4 {
+		t.Errorf("Expected clean snapshot to be left alone, got %d messages and %v", len(clean.Messages), errs)
+	}
+}
+
+func TestPageSnapshot(t *testing.T) {
+	var messages []Message
+	for i := 0; i < 10; i++ {
+		messages = append(messages, NewUserMessage(fmt.Sprintf("msg_%d", i), "Hi", ""))
+	}
+	snap := NewMessagesSnapshotEvent(messages)
+
+	pages := PageSnapshot(snap, 4)
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(pages))
+	}
+	wantSizes := []int{4, 4, 2}
+	next := 0
+	for i, page := range pages {
+		if len(page.Messages) != wantSizes[i] {
+			t.Errorf("Page %d has %d messages, want %d", i, len(page.Messages), wantSizes[i])
+		}
+		if err := page.Validate(); err != nil {
+			t.Errorf("Page %d is invalid: %v", i, err)
+		}
+		if page.Timestamp != snap.Timestamp {
+			t.Errorf("Page %d does not carry the snapshot timestamp", i)
+		}
+		for _, msg := range page.Messages {
+			if want := fmt.Sprintf("msg_%d", next); msg.GetID() != want {
+				t.Errorf("Page %d: got %s, want %s", i, msg.GetID(), want)
+			}
+			next++
+		}
+	}
+
+	pages[0].Messages = append(pages[0].Messages, NewUserMessage("extra", "Hi", ""))
+	if pages[1].Messages[0].GetID() != "msg_4" {
+		t.Error("Expected appending to a page not to affect the next page")
+	}
+
+	if single := PageSnapshot(snap, 0); len(single) != 1 || len(single[0].Messages) != 10 {
+		t.Errorf("Expected a single page for a page size of 0, got %d pages", len(single))
+	}
+	if PageSnapshot(nil, 4) != nil {
+		t.Error("Expected no pages for a nil snapshot")
+	}
+}
@@ -0,0 +1,140 @@
+package agui
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// customSchemas maps CustomEvent names to the JSON Schema their Value must match.
+var customSchemas = struct {
+	sync.RWMutex
+	schemas map[string]map[string]interface{}
+}{schemas: make(map[string]map[string]interface{})}
+
+// RegisterCustomSchema registers a JSON Schema that the Value of CustomEvents
+// named name must match; CustomEvent.Validate, and therefore decoding, then
+// reports values that do not. The schema may be any value that marshals to a
+// JSON object. The supported keywords are type, enum, properties, required,
+// additionalProperties (as a boolean) and items. Registering a nil schema
+// removes the registration.
+func RegisterCustomSchema(name string, schema interface{}) error {
+	customSchemas.Lock()
+	defer customSchemas.Unlock()
+
+	if schema == nil {
+		delete(customSchemas.schemas, name)
+		return nil
+	}
+	normalized, err := normalizeState(schema)
+	if err != nil {
+		return fmt.Errorf("invalid schema for custom event %s: %w", name, err)
+	}
+	object, ok := normalized.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid schema for custom event %s: schema must be a JSON object", name)
+	}
+	customSchemas.schemas[name] = object
+	return nil
+}
+
+// validateCustomValue checks value against the schema registered for name, if any.
+func validateCustomValue(name string, value interface{}) error {
+	customSchemas.RLock()
+	schema, ok := customSchemas.schemas[name]
+	customSchemas.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	normalized, err := normalizeState(value)
+	if err != nil {
+		return fmt.Errorf("value does not match schema for custom event %s: %w", name, err)
+	}
+	if err := matchSchema(schema, normalized, "value"); err != nil {
+		return fmt.Errorf("value does not match schema for custom event %s: %w", name, err)
+	}
+	return nil
+}
+
+// matchSchema checks a generic JSON value against a JSON Schema subset.
+// path names the value in error messages.
+func matchSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if want, ok := schema["type"].(string); ok && !hasJSONType(value, want) {
+		return fmt.Errorf("%s must be of type %s", path, want)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s is not one of the allowed values", path)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, key := range required {
+				if name, ok := key.(string); ok {
+					if _, present := v[name]; !present {
+						return fmt.Errorf("%s is missing required property %q", path, name)
+					}
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s has unexpected property %q", path, key)
+				}
+				continue
+			}
+			if err := matchSchema(property, v[key], path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := matchSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasJSONType reports whether a generic JSON value has the given JSON Schema type.
+func hasJSONType(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case nil:
+		return want == "null"
+	case bool:
+		return want == "boolean"
+	case string:
+		return want == "string"
+	case float64:
+		return want == "number" || (want == "integer" && v == math.Trunc(v))
+	case []interface{}:
+		return want == "array"
+	case map[string]interface{}:
+		return want == "object"
+	}
+	return false
+}
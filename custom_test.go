@@ -0,0 +1,69 @@
+package agui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterCustomSchema(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"level", "message"},
+		"properties": map[string]interface{}{
+			"level":   map[string]interface{}{"type": "string", "enum": []string{"info", "warn"}},
+			"message": map[string]interface{}{"type": "string"},
+			"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"count":   map[string]interface{}{"type": "integer"},
+		},
+	}
+	if err := RegisterCustomSchema("log", schema); err != nil {
+		t.Fatalf("Failed to register schema: %v", err)
+	}
+	defer RegisterCustomSchema("log", nil)
+
+	conforming := NewCustomEvent("log", map[string]interface{}{
+		"level":   "info",
+		"message": "started",
+		"tags":    []string{"a", "b"},
+		"count":   3,
+	})
+	if err := conforming.Validate(); err != nil {
+		t.Errorf("Unexpected error for conforming event: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr string
+	}{
+		{name: "MissingRequired", value: map[string]interface{}{"level": "info"}, wantErr: `"message"`},
+		{name: "WrongType", value: map[string]interface{}{"level": "info", "message": 42}, wantErr: "value.message must be of type string"},
+		{name: "NotInEnum", value: map[string]interface{}{"level": "debug", "message": "x"}, wantErr: "value.level"},
+		{name: "BadItem", value: map[string]interface{}{"level": "warn", "message": "x", "tags": []interface{}{"a", 1}}, wantErr: "value.tags[1]"},
+		{name: "NotInteger", value: map[string]interface{}{"level": "warn", "message": "x", "count": 1.5}, wantErr: "integer"},
+		{name: "NotObject", value: "started", wantErr: "type object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewCustomEvent("log", tt.value).Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Decoding validates against the schema too
+	_, err := DecodeEventFromBytes([]byte(`{"type":"CUSTOM","name":"log","value":{"level":"info"}}`))
+	if err == nil || !strings.Contains(err.Error(), "does not match schema") {
+		t.Errorf("Expected schema error when decoding, got %v", err)
+	}
+
+	// Unregistered names skip the check
+	if err := NewCustomEvent("other", "anything").Validate(); err != nil {
+		t.Errorf("Unexpected error for unregistered name: %v", err)
+	}
+
+	if err := RegisterCustomSchema("bad", []string{"not", "an", "object"}); err == nil {
+		t.Error("Expected error registering a non-object schema")
+	}
+}
@@ -0,0 +1,61 @@
+package agui
+
+// DeltaBuilder assembles the JSON Patch operations of a StateDeltaEvent.
+// Paths are JSON Pointers; use JSONPointer to build them from keys that may
+// contain "~" or "/".
+//
+//	delta := NewDeltaBuilder().
+//		Replace(JSONPointer("user", "name"), "Ada").
+//		Remove("/draft").
+//		Build()
+type DeltaBuilder struct {
+	ops []interface{}
+}
+
+// NewDeltaBuilder creates an empty DeltaBuilder.
+func NewDeltaBuilder() *DeltaBuilder {
+	return &DeltaBuilder{}
+}
+
+// Add appends an add operation setting path to value.
+func (b *DeltaBuilder) Add(path string, value interface{}) *DeltaBuilder {
+	return b.append(map[string]interface{}{"op": PatchOpAdd, "path": path, "value": value})
+}
+
+// Remove appends a remove operation deleting path.
+func (b *DeltaBuilder) Remove(path string) *DeltaBuilder {
+	return b.append(map[string]interface{}{"op": PatchOpRemove, "path": path})
+}
+
+// Replace appends a replace operation setting the existing path to value.
+func (b *DeltaBuilder) Replace(path string, value interface{}) *DeltaBuilder {
+	return b.append(map[string]interface{}{"op": PatchOpReplace, "path": path, "value": value})
+}
+
+// Move appends a move operation relocating the value at from to to.
+func (b *DeltaBuilder) Move(from, to string) *DeltaBuilder {
+	return b.append(map[string]interface{}{"op": PatchOpMove, "from": from, "path": to})
+}
+
+// Copy appends a copy operation duplicating the value at from to to.
+func (b *DeltaBuilder) Copy(from, to string) *DeltaBuilder {
+	return b.append(map[string]interface{}{"op": PatchOpCopy, "from": from, "path": to})
+}
+
+// Test appends a test operation asserting that path holds value.
+func (b *DeltaBuilder) Test(path string, value interface{}) *DeltaBuilder {
+	return b.append(map[string]interface{}{"op": PatchOpTest, "path": path, "value": value})
+}
+
+// Build returns a StateDeltaEvent carrying the operations added so far. The
+// builder can keep being used; later operations do not affect built events.
+func (b *DeltaBuilder) Build() *StateDeltaEvent {
+	ops := make([]interface{}, len(b.ops))
+	copy(ops, b.ops)
+	return NewStateDeltaEvent(ops)
+}
+
+func (b *DeltaBuilder) append(op map[string]interface{}) *DeltaBuilder {
+	b.ops = append(b.ops, op)
+	return b
+}
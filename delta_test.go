@@ -0,0 +1,85 @@
+package agui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{tokens: nil, want: ""},
+		{tokens: []string{"user", "name"}, want: "/user/name"},
+		{tokens: []string{"a/b", "c~d", ""}, want: "/a~1b/c~0d/"},
+	}
+	for _, tt := range tests {
+		got := JSONPointer(tt.tokens...)
+		if got != tt.want {
+			t.Errorf("JSONPointer(%q) = %q, want %q", tt.tokens, got, tt.want)
+		}
+		parsed, err := parseJSONPointer(got)
+		if err != nil {
+			t.Errorf("parseJSONPointer(%q) failed: %v", got, err)
+		}
+		if len(tt.tokens) > 0 && !reflect.DeepEqual(parsed, tt.tokens) {
+			t.Errorf("parseJSONPointer(%q) = %q, want %q", got, parsed, tt.tokens)
+		}
+	}
+}
+
+func TestDeltaBuilder(t *testing.T) {
+	base := map[string]interface{}{
+		"count": 1,
+		"user": map[string]interface{}{
+			"name": "Ada",
+			"tags": []interface{}{"a", "b"},
+		},
+		"a/b": true,
+	}
+
+	builder := NewDeltaBuilder().
+		Test(JSONPointer("user", "name"), "Ada").
+		Replace("/count", 2).
+		Add(JSONPointer("user", "tags", "-"), "c").
+		Remove(JSONPointer("a/b")).
+		Copy("/count", "/total").
+		Move(JSONPointer("user", "name"), "/name")
+	delta := builder.Build()
+
+	if err := delta.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+	if err := delta.ValidateAgainst(base); err != nil {
+		t.Fatalf("Unexpected error validating against base: %v", err)
+	}
+	if len(delta.Delta) != 6 {
+		t.Fatalf("Expected 6 operations, got %d", len(delta.Delta))
+	}
+
+	store := NewStateStore()
+	if err := store.Apply(NewStateSnapshotEvent(base)); err != nil {
+		t.Fatalf("Failed to apply snapshot: %v", err)
+	}
+	if err := store.Apply(delta); err != nil {
+		t.Fatalf("Failed to apply delta: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"count": float64(2),
+		"total": float64(2),
+		"name":  "Ada",
+		"user": map[string]interface{}{
+			"tags": []interface{}{"a", "b", "c"},
+		},
+	}
+	if got := store.State(); !reflect.DeepEqual(got, want) {
+		t.Errorf("State = %v, want %v", got, want)
+	}
+
+	builder.Remove("/count")
+	if len(delta.Delta) != 6 {
+		t.Errorf("Expected built delta to be unaffected by later operations, got %d", len(delta.Delta))
+	}
+}
@@ -40,6 +40,7 @@
 //   - RunErrorEvent: Signals an error during an agent run
 //   - StepStartedEvent: Signals the start of a step within an agent run
 //   - StepFinishedEvent: Signals completion of a step within an agent run
+//   - StepProgressEvent: Reports the progress of a long-running step
 //
 // ## Text Message Events
 //
@@ -53,6 +54,8 @@
 //   - ToolCallArgsEvent: Represents a chunk of argument data for a tool call
 //   - ToolCallEndEvent: Signals the end of a tool call
 //   - ToolCallResultEvent: Provides the result of a tool call execution
+//   - ToolCallResultStartEvent, ToolCallResultChunkEvent, ToolCallResultEndEvent: Stream a
+//     large tool call result in chunks; ToolCallResultAssembler reassembles them
 //
 // ## State Management Events
 //
@@ -91,6 +94,9 @@
 // based on the AG-UI schema specifications. Validation is automatically performed
 // during encoding operations.
 //
+// SequenceValidator checks the ordering of a stream of events, such as text message
+// content arriving only between the matching start and end events.
+//
 // # Factory Functions
 //
 // The package provides convenient factory functions for creating properly initialized
@@ -0,0 +1,59 @@
+package agui
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Envelope wraps an Event for transport between services, adding a format
+// version and the time it was published. It is encoded as
+// {"v":1,"ts":1234567890123,"payload":{...}}.
+type Envelope struct {
+	Version     int   // Envelope format version
+	PublishedAt int64 // Publish time in epoch milliseconds
+	Event       Event // The wrapped event
+}
+
+// envelopeJSON is the wire form of an Envelope.
+type envelopeJSON struct {
+	Version     int             `json:"v"`
+	PublishedAt int64           `json:"ts"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	if e.Event == nil {
+		return nil, fmt.Errorf("%w: envelope event is required", ErrMarshalFailed)
+	}
+	payload, err := EncodeEvent(e.Event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelopeJSON{
+		Version:     e.Version,
+		PublishedAt: e.PublishedAt,
+		Payload:     payload,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, restoring the payload to its concrete event type.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var raw envelopeJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: Envelope: %v", ErrUnmarshalFailed, err)
+	}
+	if len(raw.Payload) == 0 || string(raw.Payload) == "null" {
+		return fmt.Errorf("%w: envelope payload is required", ErrInvalidStructure)
+	}
+
+	event, err := DecodeEventFromBytes(raw.Payload)
+	if err != nil {
+		return err
+	}
+
+	e.Version = raw.Version
+	e.PublishedAt = raw.PublishedAt
+	e.Event = event
+	return nil
+}
@@ -0,0 +1,43 @@
+package agui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	envelope := Envelope{
+		Version:     1,
+		PublishedAt: 1700000000123,
+		Event:       NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Failed to marshal envelope: %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"v":1,"ts":1700000000123,"payload":{"type":"TOOL_CALL_START"`) {
+		t.Errorf("Unexpected envelope JSON: %s", data)
+	}
+
+	var decoded Envelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+
+	if decoded.Version != 1 || decoded.PublishedAt != 1700000000123 {
+		t.Errorf("Envelope metadata mismatch: %+v", decoded)
+	}
+	start, ok := decoded.Event.(*ToolCallStartEvent)
+	if !ok {
+		t.Fatalf("Expected *ToolCallStartEvent, got %T", decoded.Event)
+	}
+	if start.ToolCallID != "tool_call_1" || start.ToolCallName != "search" || start.ParentMessageID != "msg_1" {
+		t.Errorf("Event content mismatch: %+v", start)
+	}
+
+	if err := json.Unmarshal([]byte(`{"v":1,"ts":1}`), &decoded); err == nil {
+		t.Error("Expected error for envelope without payload")
+	}
+}
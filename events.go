@@ -1,7 +1,11 @@
 package agui
 
 import (
+	"encoding/json"
 	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,16 +15,22 @@ type Event interface {
 	GetType() EventType
 	GetTimestamp() *int64
 	GetRawEvent() interface{}
+	// Time returns the event timestamp as a time.Time and whether it is set
+	Time() (time.Time, bool)
 	Validate() error
 	// EventType returns the concrete type name for type switching
 	EventTypeName() string
+	// Accept calls the method of v for the event's concrete type
+	Accept(v EventVisitor)
 }
 
 // BaseEvent contains common properties shared by all event types.
 type BaseEvent struct {
-	Type      EventType   `json:"type"`                // The type of event
-	Timestamp *int64      `json:"timestamp,omitempty"` // Timestamp when the event was created
-	RawEvent  interface{} `json:"rawEvent,omitempty"`  // Original event data if this event was transformed
+	Type        EventType   `json:"type"`                  // The type of event
+	Timestamp   *int64      `json:"timestamp,omitempty"`   // Timestamp when the event was created
+	RawEvent    interface{} `json:"rawEvent,omitempty"`    // Original event data if this event was transformed
+	Seq         *int64      `json:"seq,omitempty"`         // Per-stream sequence number set by SequencingEncoder
+	TraceParent string      `json:"traceparent,omitempty"` // W3C traceparent stamped by an Encoder using WithTraceContext
 }
 
 // GetType returns the event type.
@@ -38,27 +48,121 @@ func (b *BaseEvent) GetRawEvent() interface{} {
 	return b.RawEvent
 }
 
+// base returns the BaseEvent itself, giving package code access to the common
+// fields of any concrete event type.
+func (b *BaseEvent) base() *BaseEvent {
+	return b
+}
+
+// GetSeq returns the event sequence number, or nil if it has none.
+func (b *BaseEvent) GetSeq() *int64 {
+	return b.Seq
+}
+
+// SetSeq sets the event sequence number.
+func (b *BaseEvent) SetSeq(seq int64) {
+	b.Seq = &seq
+}
+
+// GetTraceParent returns the event's W3C traceparent, or "" if it has none.
+func (b *BaseEvent) GetTraceParent() string {
+	return b.TraceParent
+}
+
+// SetTraceParent sets the event's W3C traceparent.
+func (b *BaseEvent) SetTraceParent(traceParent string) {
+	b.TraceParent = traceParent
+}
+
 // Validate checks if the BaseEvent is valid.
 func (b *BaseEvent) Validate() error {
 	if !b.Type.IsValid() {
 		return fmt.Errorf("invalid event type: %s", b.Type)
 	}
-	return nil
+	return validateFinite("rawEvent", b.RawEvent)
 }
 
-// SetTimestamp sets the timestamp to the current time.
-func (b *BaseEvent) SetTimestamp() {
-	now := time.Now().UnixMilli()
+// TimestampPrecision is the unit used for event timestamps.
+type TimestampPrecision int32
+
+// Timestamp precisions. Milliseconds is the default and what the AG-UI
+// specification uses; nanoseconds avoids collisions between events created
+// within the same millisecond. Producers and consumers must agree on the
+// precision in use, since the timestamp field does not record it.
+const (
+	TimestampMillisecond TimestampPrecision = iota
+	TimestampNanosecond
+)
+
+// timestampPrecision holds the package-wide default TimestampPrecision.
+var timestampPrecision atomic.Int32
+
+// SetTimestampPrecision sets the precision used by SetTimestamp, SetTime, Time
+// and the event factory functions. It affects the whole package.
+func SetTimestampPrecision(p TimestampPrecision) {
+	timestampPrecision.Store(int32(p))
+}
+
+// GetTimestampPrecision returns the precision set by SetTimestampPrecision.
+func GetTimestampPrecision() TimestampPrecision {
+	return TimestampPrecision(timestampPrecision.Load())
+}
+
+// TimestampOption overrides the timestamp precision for a single SetTimestamp call.
+type TimestampOption func(*TimestampPrecision)
+
+// WithNanosecondTimestamps makes SetTimestamp use nanosecond precision.
+func WithNanosecondTimestamps() TimestampOption {
+	return func(p *TimestampPrecision) {
+		*p = TimestampNanosecond
+	}
+}
+
+// timestampOf converts t to a timestamp with precision p.
+func timestampOf(t time.Time, p TimestampPrecision) int64 {
+	if p == TimestampNanosecond {
+		return t.UnixNano()
+	}
+	return t.UnixMilli()
+}
+
+// SetTimestamp sets the timestamp to the current time, using the package
+// precision unless overridden by opts.
+func (b *BaseEvent) SetTimestamp(opts ...TimestampOption) {
+	precision := GetTimestampPrecision()
+	for _, opt := range opts {
+		opt(&precision)
+	}
+	now := timestampOf(time.Now(), precision)
 	b.Timestamp = &now
 }
 
+// Time returns the event timestamp as a time.Time, interpreted with the package precision.
+// The boolean is false if the event has no timestamp.
+func (b *BaseEvent) Time() (time.Time, bool) {
+	if b.Timestamp == nil {
+		return time.Time{}, false
+	}
+	if GetTimestampPrecision() == TimestampNanosecond {
+		return time.Unix(0, *b.Timestamp), true
+	}
+	return time.UnixMilli(*b.Timestamp), true
+}
+
+// SetTime sets the timestamp to t, truncated to the package precision.
+func (b *BaseEvent) SetTime(t time.Time) {
+	ts := timestampOf(t, GetTimestampPrecision())
+	b.Timestamp = &ts
+}
+
 // Lifecycle Events
 
 // RunStartedEvent signals the start of an agent run.
 type RunStartedEvent struct {
 	BaseEvent
-	ThreadID string `json:"threadId"` // ID of the conversation thread
-	RunID    string `json:"runId"`    // ID of the agent run
+	ThreadID    string `json:"threadId"`              // ID of the conversation thread
+	RunID       string `json:"runId"`                 // ID of the agent run
+	ParentRunID string `json:"parentRunId,omitempty"` // ID of the run that spawned this run, if any
 }
 
 // EventTypeName returns the concrete type name.
@@ -98,6 +202,14 @@ func (r *RunFinishedEvent) EventTypeName() string {
 
 // Validate checks if the RunFinishedEvent is valid.
 func (r *RunFinishedEvent) Validate() error {
+	return r.ValidateWith()
+}
+
+// ValidateWith checks if the RunFinishedEvent is valid like Validate, additionally
+// enforcing the rules enabled by opts.
+func (r *RunFinishedEvent) ValidateWith(opts ...ValidateOption) error {
+	config := newValidateConfig(opts)
+
 	if err := r.BaseEvent.Validate(); err != nil {
 		return err
 	}
@@ -110,7 +222,10 @@ func (r *RunFinishedEvent) Validate() error {
 	if r.RunID == "" {
 		return fmt.Errorf("run ID is required")
 	}
-	return nil
+	if config.requireResult && r.Result == nil {
+		return fmt.Errorf("run result is required")
+	}
+	return validateFinite("result", r.Result)
 }
 
 // RunErrorEvent signals an error during an agent run.
@@ -139,6 +254,33 @@ func (r *RunErrorEvent) Validate() error {
 	return nil
 }
 
+// AsError converts the event into a Go error whose message is the event's
+// message. The returned *RunFailedError matches ErrRunFailed with errors.Is.
+func (r *RunErrorEvent) AsError() error {
+	return &RunFailedError{message: r.Message, code: r.Code}
+}
+
+// RunFailedError is the error form of a RunErrorEvent.
+type RunFailedError struct {
+	message string
+	code    string
+}
+
+// Error returns the run error message.
+func (e *RunFailedError) Error() string {
+	return e.message
+}
+
+// Code returns the run error code, which may be empty.
+func (e *RunFailedError) Code() string {
+	return e.code
+}
+
+// Is reports whether target is ErrRunFailed.
+func (e *RunFailedError) Is(target error) bool {
+	return target == ErrRunFailed
+}
+
 // StepStartedEvent signals the start of a step within an agent run.
 type StepStartedEvent struct {
 	BaseEvent
@@ -189,6 +331,37 @@ func (s *StepFinishedEvent) Validate() error {
 	return nil
 }
 
+// StepProgressEvent reports the progress of a long-running step within an agent run.
+// It is emitted between the StepStartedEvent and StepFinishedEvent of the same step.
+type StepProgressEvent struct {
+	BaseEvent
+	StepName string  `json:"stepName"`          // Name of the step
+	Progress float64 `json:"progress"`          // Completed fraction of the step, from 0 to 1
+	Message  string  `json:"message,omitempty"` // Optional human-readable progress message
+}
+
+// EventTypeName returns the concrete type name.
+func (s *StepProgressEvent) EventTypeName() string {
+	return "StepProgressEvent"
+}
+
+// Validate checks if the StepProgressEvent is valid.
+func (s *StepProgressEvent) Validate() error {
+	if err := s.BaseEvent.Validate(); err != nil {
+		return err
+	}
+	if s.Type != EventTypeStepProgress {
+		return fmt.Errorf("step progress event must have STEP_PROGRESS type, got: %s", s.Type)
+	}
+	if s.StepName == "" {
+		return fmt.Errorf("step name is required")
+	}
+	if !(s.Progress >= 0 && s.Progress <= 1) {
+		return fmt.Errorf("progress must be between 0 and 1, got: %v", s.Progress)
+	}
+	return nil
+}
+
 // Text Message Events
 
 // TextMessageStartEvent signals the start of a text message.
@@ -205,6 +378,14 @@ func (t *TextMessageStartEvent) EventTypeName() string {
 
 // Validate checks if the TextMessageStartEvent is valid.
 func (t *TextMessageStartEvent) Validate() error {
+	return t.ValidateWith()
+}
+
+// ValidateWith checks if the TextMessageStartEvent is valid like Validate,
+// additionally applying the rules enabled by opts.
+func (t *TextMessageStartEvent) ValidateWith(opts ...ValidateOption) error {
+	config := newValidateConfig(opts)
+
 	if err := t.BaseEvent.Validate(); err != nil {
 		return err
 	}
@@ -214,6 +395,13 @@ func (t *TextMessageStartEvent) Validate() error {
 	if t.MessageID == "" {
 		return fmt.Errorf("message ID is required")
 	}
+	if config.lenientTextRoles {
+		switch t.Role {
+		case "", RoleAssistant, RoleUser, RoleSystem, RoleDeveloper:
+			return nil
+		}
+		return fmt.Errorf("text message role must be assistant, user, system or developer, got: %s", t.Role)
+	}
 	if t.Role != RoleAssistant {
 		return fmt.Errorf("text message role must be assistant, got: %s", t.Role)
 	}
@@ -389,12 +577,110 @@ func (t *ToolCallResultEvent) Validate() error {
 	if t.Content == "" {
 		return fmt.Errorf("content is required")
 	}
+	// An empty role defaults to tool; only tool and assistant can carry a result
+	switch t.Role {
+	case "", RoleTool, RoleAssistant:
+	default:
+		return fmt.Errorf("tool call result role must be tool or assistant, got: %s", t.Role)
+	}
+	return nil
+}
+
+// ToolCallResultStartEvent signals the start of a tool call result streamed in chunks.
+type ToolCallResultStartEvent struct {
+	BaseEvent
+	MessageID  string `json:"messageId"`      // ID of the conversation message this result belongs to
+	ToolCallID string `json:"toolCallId"`     // Matches the ID from the corresponding ToolCallStartEvent
+	Role       Role   `json:"role,omitempty"` // Role identifier, typically "tool" for tool results
+}
+
+// EventTypeName returns the concrete type name.
+func (t *ToolCallResultStartEvent) EventTypeName() string {
+	return "ToolCallResultStartEvent"
+}
+
+// Validate checks if the ToolCallResultStartEvent is valid.
+func (t *ToolCallResultStartEvent) Validate() error {
+	if err := t.BaseEvent.Validate(); err != nil {
+		return err
+	}
+	if t.Type != EventTypeToolCallResultStart {
+		return fmt.Errorf("tool call result start event must have TOOL_CALL_RESULT_START type, got: %s", t.Type)
+	}
+	if t.MessageID == "" {
+		return fmt.Errorf("message ID is required")
+	}
+	if t.ToolCallID == "" {
+		return fmt.Errorf("tool call ID is required")
+	}
 	if t.Role != "" && !t.Role.IsValid() {
 		return fmt.Errorf("invalid role: %s", t.Role)
 	}
 	return nil
 }
 
+// ToolCallResultChunkEvent represents a fragment of a streamed tool call result.
+type ToolCallResultChunkEvent struct {
+	BaseEvent
+	MessageID  string `json:"messageId"`  // Matches the ID from ToolCallResultStartEvent
+	ToolCallID string `json:"toolCallId"` // Matches the ID from ToolCallResultStartEvent
+	Delta      string `json:"delta"`      // Result content chunk (non-empty)
+}
+
+// EventTypeName returns the concrete type name.
+func (t *ToolCallResultChunkEvent) EventTypeName() string {
+	return "ToolCallResultChunkEvent"
+}
+
+// Validate checks if the ToolCallResultChunkEvent is valid.
+func (t *ToolCallResultChunkEvent) Validate() error {
+	if err := t.BaseEvent.Validate(); err != nil {
+		return err
+	}
+	if t.Type != EventTypeToolCallResultChunk {
+		return fmt.Errorf("tool call result chunk event must have TOOL_CALL_RESULT_CHUNK type, got: %s", t.Type)
+	}
+	if t.MessageID == "" {
+		return fmt.Errorf("message ID is required")
+	}
+	if t.ToolCallID == "" {
+		return fmt.Errorf("tool call ID is required")
+	}
+	if t.Delta == "" {
+		return fmt.Errorf("delta must not be empty")
+	}
+	return nil
+}
+
+// ToolCallResultEndEvent signals the end of a streamed tool call result.
+type ToolCallResultEndEvent struct {
+	BaseEvent
+	MessageID  string `json:"messageId"`  // Matches the ID from ToolCallResultStartEvent
+	ToolCallID string `json:"toolCallId"` // Matches the ID from ToolCallResultStartEvent
+}
+
+// EventTypeName returns the concrete type name.
+func (t *ToolCallResultEndEvent) EventTypeName() string {
+	return "ToolCallResultEndEvent"
+}
+
+// Validate checks if the ToolCallResultEndEvent is valid.
+func (t *ToolCallResultEndEvent) Validate() error {
+	if err := t.BaseEvent.Validate(); err != nil {
+		return err
+	}
+	if t.Type != EventTypeToolCallResultEnd {
+		return fmt.Errorf("tool call result end event must have TOOL_CALL_RESULT_END type, got: %s", t.Type)
+	}
+	if t.MessageID == "" {
+		return fmt.Errorf("message ID is required")
+	}
+	if t.ToolCallID == "" {
+		return fmt.Errorf("tool call ID is required")
+	}
+	return nil
+}
+
 // State Management Events
 
 // StateSnapshotEvent provides a complete snapshot of an agent's state.
@@ -419,13 +705,18 @@ func (s *StateSnapshotEvent) Validate() error {
 	if s.Snapshot == nil {
 		return fmt.Errorf("snapshot is required")
 	}
-	return nil
+	return validateFinite("snapshot", s.Snapshot)
 }
 
 // StateDeltaEvent provides a partial update to an agent's state using JSON Patch.
 type StateDeltaEvent struct {
 	BaseEvent
 	Delta []interface{} `json:"delta"` // Array of JSON Patch operations (RFC 6902)
+
+	// RawDelta holds the operations byte-for-byte as received when decoded with
+	// WithRawDelta, in which case Delta is nil. When set, it is encoded in place
+	// of Delta. Use Operations to read the operations in either form.
+	RawDelta []json.RawMessage `json:"-"`
 }
 
 // EventTypeName returns the concrete type name.
@@ -441,16 +732,16 @@ func (s *StateDeltaEvent) Validate() error {
 	if s.Type != EventTypeStateDelta {
 		return fmt.Errorf("state delta event must have STATE_DELTA type, got: %s", s.Type)
 	}
-	if s.Delta == nil {
+	if s.Delta == nil && s.RawDelta == nil {
 		return fmt.Errorf("delta is required")
 	}
-	return nil
+	return validateFinite("delta", s.Delta)
 }
 
 // MessagesSnapshotEvent provides a snapshot of all messages in a conversation.
 type MessagesSnapshotEvent struct {
 	BaseEvent
-	Messages []Message `json:"messages"` // Array of message objects
+	Messages Messages `json:"messages"` // Array of message objects
 }
 
 // EventTypeName returns the concrete type name.
@@ -480,6 +771,66 @@ func (m *MessagesSnapshotEvent) Validate() error {
 	return nil
 }
 
+// ValidateParallel checks the MessagesSnapshotEvent like Validate, but validates
+// the messages concurrently using up to workers goroutines. A non-positive workers
+// value uses runtime.GOMAXPROCS(0). The reported error is always the one for the
+// lowest invalid index, so the result is identical to Validate.
+func (m *MessagesSnapshotEvent) ValidateParallel(workers int) error {
+	if err := m.BaseEvent.Validate(); err != nil {
+		return err
+	}
+	if m.Type != EventTypeMessagesSnapshot {
+		return fmt.Errorf("messages snapshot event must have MESSAGES_SNAPSHOT type, got: %s", m.Type)
+	}
+	if m.Messages == nil {
+		return fmt.Errorf("messages are required")
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(m.Messages) {
+		workers = len(m.Messages)
+	}
+	if workers <= 1 {
+		return m.Validate()
+	}
+
+	// Split the messages into contiguous chunks; each worker stops at the first
+	// failure in its chunk, so the first failing chunk holds the lowest index.
+	chunk := (len(m.Messages) + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * chunk
+		end := start + chunk
+		if end > len(m.Messages) {
+			end = len(m.Messages)
+		}
+		if start >= end {
+			break
+		}
+		wg.Add(1)
+		go func(w, start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				if err := m.Messages[i].Validate(); err != nil {
+					errs[w] = fmt.Errorf("invalid message at index %d: %w", i, err)
+					return
+				}
+			}
+		}(w, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Special Events
 
 // RawEvent is used to pass through events from external systems.
@@ -505,7 +856,7 @@ func (r *RawEvent) Validate() error {
 	if r.Event == nil {
 		return fmt.Errorf("event is required")
 	}
-	return nil
+	return validateFinite("event", r.Event)
 }
 
 // CustomEvent is used for application-specific custom events.
@@ -534,5 +885,8 @@ func (c *CustomEvent) Validate() error {
 	if c.Value == nil {
 		return fmt.Errorf("value is required")
 	}
-	return nil
+	if err := validateFinite("value", c.Value); err != nil {
+		return err
+	}
+	return validateCustomValue(c.Name, c.Value)
 }
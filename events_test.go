@@ -0,0 +1,383 @@
+package agui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLargeMessagesSnapshot(n int) *MessagesSnapshotEvent {
+	messages := make([]Message, n)
+	for i := range messages {
+		messages[i] = NewUserMessage(fmt.Sprintf("msg_%d", i), "Hello", "")
+	}
+	return NewMessagesSnapshotEvent(messages)
+}
+
+func TestMessagesSnapshotValidateParallel(t *testing.T) {
+	event := newLargeMessagesSnapshot(1000)
+	if err := event.ValidateParallel(4); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	// Invalidate two messages in the middle; the lowest index must be reported.
+	event.Messages[517] = NewUserMessage("msg_517", "", "")
+	event.Messages[731] = NewUserMessage("", "Hello", "")
+
+	for _, workers := range []int{0, 1, 3, 8, 2000} {
+		err := event.ValidateParallel(workers)
+		if err == nil {
+			t.Fatalf("workers=%d: expected validation error, got none", workers)
+		}
+		if !strings.Contains(err.Error(), "index 517") {
+			t.Errorf("workers=%d: expected error for index 517, got: %v", workers, err)
+		}
+		if err.Error() != event.Validate().Error() {
+			t.Errorf("workers=%d: parallel error %q differs from serial error %q", workers, err, event.Validate())
+		}
+	}
+}
+
+func BenchmarkMessagesSnapshotValidate(b *testing.B) {
+	event := newLargeMessagesSnapshot(50000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := event.Validate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMessagesSnapshotValidateParallel(b *testing.B) {
+	event := newLargeMessagesSnapshot(50000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := event.ValidateParallel(0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStepProgressEvent(t *testing.T) {
+	event := NewStepProgressEvent("download", 0.42, "Downloading files")
+
+	data, err := EncodeEvent(event)
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+
+	decoded, err := DecodeEventFromBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+
+	progress, ok := decoded.(*StepProgressEvent)
+	if !ok {
+		t.Fatalf("Expected *StepProgressEvent, got %T", decoded)
+	}
+	if progress.StepName != "download" || progress.Progress != 0.42 || progress.Message != "Downloading files" {
+		t.Errorf("Decoded event mismatch: %+v", progress)
+	}
+}
+
+func TestStepProgressEventValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		progress    float64
+		shouldError bool
+	}{
+		{name: "Zero", progress: 0, shouldError: false},
+		{name: "Half", progress: 0.5, shouldError: false},
+		{name: "One", progress: 1, shouldError: false},
+		{name: "Negative", progress: -0.1, shouldError: true},
+		{name: "AboveOne", progress: 1.5, shouldError: true},
+		{name: "NaN", progress: math.NaN(), shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewStepProgressEvent("step", tt.progress, "").Validate()
+			if tt.shouldError && err == nil {
+				t.Error("Expected validation error, but got none")
+			}
+			if !tt.shouldError && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	event := &TextMessageEndEvent{
+		BaseEvent: BaseEvent{Type: EventTypeTextMessageEnd},
+		MessageID: "msg_1",
+	}
+
+	if tm, ok := event.Time(); ok || !tm.IsZero() {
+		t.Errorf("Expected absent timestamp, got %v (present=%v)", tm, ok)
+	}
+
+	when := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	event.SetTime(when)
+
+	if event.Timestamp == nil || *event.Timestamp != when.UnixMilli() {
+		t.Fatalf("Expected timestamp %d, got %v", when.UnixMilli(), event.Timestamp)
+	}
+
+	var e Event = event
+	tm, ok := e.Time()
+	if !ok {
+		t.Fatal("Expected timestamp to be present")
+	}
+	if !tm.Equal(when.Truncate(time.Millisecond)) {
+		t.Errorf("Expected time %v, got %v", when.Truncate(time.Millisecond), tm)
+	}
+}
+
+func TestEventMessageID(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		id    string
+		hasID bool
+	}{
+		{name: "TextMessageStart", event: NewTextMessageStartEvent("msg_1"), id: "msg_1", hasID: true},
+		{name: "TextMessageContent", event: NewTextMessageContentEvent("msg_2", "Hi"), id: "msg_2", hasID: true},
+		{name: "TextMessageEnd", event: NewTextMessageEndEvent("msg_3"), id: "msg_3", hasID: true},
+		{name: "ToolCallStartWithParent", event: NewToolCallStartEvent("tool_call_1", "search", "msg_4"), id: "msg_4", hasID: true},
+		{name: "ToolCallStartWithoutParent", event: NewToolCallStartEvent("tool_call_1", "search", ""), hasID: false},
+		{name: "ToolCallResult", event: NewToolCallResultEvent("msg_5", "tool_call_1", "done"), id: "msg_5", hasID: true},
+		{name: "ToolCallResultChunk", event: NewToolCallResultChunkEvent("msg_6", "tool_call_1", "part"), id: "msg_6", hasID: true},
+		{name: "ToolCallArgs", event: NewToolCallArgsEvent("tool_call_1", "{}"), hasID: false},
+		{name: "RunStarted", event: NewRunStartedEvent("thread_1", "run_1"), hasID: false},
+		{name: "StateSnapshot", event: NewStateSnapshotEvent(map[string]interface{}{}), hasID: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := EventMessageID(tt.event)
+			if id != tt.id || ok != tt.hasID {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.id, tt.hasID, id, ok)
+			}
+		})
+	}
+}
+
+func TestNanosecondTimestamps(t *testing.T) {
+	SetTimestampPrecision(TimestampNanosecond)
+	defer SetTimestampPrecision(TimestampMillisecond)
+
+	first := NewTextMessageContentEvent("msg_1", "a")
+	second := NewTextMessageContentEvent("msg_1", "b")
+	if *first.Timestamp == *second.Timestamp {
+		t.Errorf("Expected distinct nanosecond timestamps, both were %d", *first.Timestamp)
+	}
+
+	tm, ok := first.Time()
+	if !ok || tm.UnixNano() != *first.Timestamp {
+		t.Errorf("Expected Time to interpret nanoseconds, got %v", tm)
+	}
+}
+
+func TestSetTimestampOption(t *testing.T) {
+	var event BaseEvent
+	before := time.Now()
+	event.SetTimestamp(WithNanosecondTimestamps())
+	if *event.Timestamp < before.UnixNano() {
+		t.Errorf("Expected a nanosecond timestamp, got %d", *event.Timestamp)
+	}
+
+	event.SetTimestamp()
+	if *event.Timestamp > time.Now().UnixMilli() {
+		t.Errorf("Expected the default to stay milliseconds, got %d", *event.Timestamp)
+	}
+}
+
+func TestToolCallResultEventRoleValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        Role
+		shouldError bool
+	}{
+		{name: "Tool", role: RoleTool, shouldError: false},
+		{name: "Assistant", role: RoleAssistant, shouldError: false},
+		{name: "Empty", role: "", shouldError: false},
+		{name: "User", role: RoleUser, shouldError: true},
+		{name: "System", role: RoleSystem, shouldError: true},
+		{name: "Unknown", role: Role("robot"), shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := NewToolCallResultEvent("msg_1", "tool_call_1", "done")
+			event.Role = tt.role
+			err := event.Validate()
+			if tt.shouldError && err == nil {
+				t.Error("Expected validation error, but got none")
+			}
+			if !tt.shouldError && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunErrorAsError(t *testing.T) {
+	err := NewRunErrorEvent("model overloaded", "RATE_LIMIT").AsError()
+	if err.Error() != "model overloaded" {
+		t.Errorf("Expected message %q, got %q", "model overloaded", err.Error())
+	}
+
+	wrapped := fmt.Errorf("agent call: %w", err)
+	if !errors.Is(wrapped, ErrRunFailed) {
+		t.Error("Expected errors.Is to match ErrRunFailed")
+	}
+	if errors.Is(wrapped, ErrRunEnded) {
+		t.Error("Expected errors.Is not to match ErrRunEnded")
+	}
+
+	var runErr *RunFailedError
+	if !errors.As(wrapped, &runErr) {
+		t.Fatal("Expected errors.As to find *RunFailedError")
+	}
+	if runErr.Code() != "RATE_LIMIT" {
+		t.Errorf("Expected code RATE_LIMIT, got %q", runErr.Code())
+	}
+}
+
+func TestRunFinishedRequireResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  interface{}
+		strict  bool
+		wantErr bool
+	}{
+		{name: "PresentDefault", result: map[string]interface{}{"ok": true}, strict: false, wantErr: false},
+		{name: "AbsentDefault", result: nil, strict: false, wantErr: false},
+		{name: "PresentStrict", result: map[string]interface{}{"ok": true}, strict: true, wantErr: false},
+		{name: "FalsyStrict", result: false, strict: true, wantErr: false},
+		{name: "AbsentStrict", result: nil, strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := NewRunFinishedEvent("thread_1", "run_1", tt.result)
+
+			var err error
+			if tt.strict {
+				err = event.ValidateWith(RequireResult())
+			} else {
+				err = event.Validate()
+			}
+
+			if tt.wantErr && err == nil {
+				t.Error("Expected validation error, but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEventField(t *testing.T) {
+	started := NewRunStartedEvent("thread_1", "run_1")
+	*started.Timestamp = 1700000000000
+
+	tests := []struct {
+		name  string
+		event Event
+		field string
+		want  interface{}
+		found bool
+	}{
+		{name: "ThreadID", event: started, field: "threadId", want: "thread_1", found: true},
+		{name: "EmbeddedType", event: started, field: "type", want: EventTypeRunStarted, found: true},
+		{name: "Timestamp", event: started, field: "timestamp", want: int64(1700000000000), found: true},
+		{name: "MessageID", event: NewTextMessageContentEvent("msg_1", "Hi"), field: "messageId", want: "msg_1", found: true},
+		{name: "Delta", event: NewToolCallArgsEvent("tool_call_1", `{"q":1}`), field: "delta", want: `{"q":1}`, found: true},
+		{name: "Progress", event: NewStepProgressEvent("plan", 0.5, ""), field: "progress", want: 0.5, found: true},
+		{name: "Absent", event: NewTextMessageEndEvent("msg_1"), field: "delta", found: false},
+		{name: "UnsetPointer", event: &RunStartedEvent{BaseEvent: BaseEvent{Type: EventTypeRunStarted}}, field: "seq", found: false},
+		{name: "GoFieldName", event: started, field: "ThreadID", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := EventField(tt.event, tt.field)
+			if ok != tt.found {
+				t.Fatalf("Expected found=%v, got %v", tt.found, ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("Expected %v (%T), got %v (%T)", tt.want, tt.want, got, got)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsNonFiniteNumbers(t *testing.T) {
+	type score struct {
+		Value float64 `json:"value"`
+	}
+
+	tests := []struct {
+		name    string
+		event   Event
+		wantErr string
+	}{
+		{
+			name: "CleanSnapshot",
+			event: NewStateSnapshotEvent(map[string]interface{}{
+				"user": map[string]interface{}{"score": 1.5, "tags": []interface{}{"a"}},
+			}),
+		},
+		{
+			name: "NaNInSnapshot",
+			event: NewStateSnapshotEvent(map[string]interface{}{
+				"user": map[string]interface{}{"scores": []interface{}{1.0, math.NaN()}},
+			}),
+			wantErr: "snapshot contains a non-finite number at /user/scores/1: NaN",
+		},
+		{
+			name:    "InfInTypedState",
+			event:   NewStateSnapshotEvent(map[string]score{"a/b": {Value: math.Inf(1)}}),
+			wantErr: "snapshot contains a non-finite number at /a~1b/value: +Inf",
+		},
+		{
+			name:    "NaNResult",
+			event:   NewRunFinishedEvent("thread_1", "run_1", math.NaN()),
+			wantErr: "result is not a finite number: NaN",
+		},
+		{
+			name:    "InfCustomValue",
+			event:   NewCustomEvent("progress", map[string]interface{}{"ratio": math.Inf(-1)}),
+			wantErr: "value contains a non-finite number at /ratio: -Inf",
+		},
+		{
+			name:    "NaNDelta",
+			event:   NewStateDeltaEvent([]interface{}{map[string]interface{}{"op": "replace", "path": "/x", "value": math.NaN()}}),
+			wantErr: "delta contains a non-finite number at /0/value: NaN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+			if _, err := EncodeEvent(tt.event); !errors.Is(err, ErrValidationFailed) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected EncodeEvent to fail validation with %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package agui
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// framePrefixSize is the size of the big-endian uint32 length prefix written
+// before each framed event.
+const framePrefixSize = 4
+
+// FramedEncoder writes AG-UI events to an io.Writer as length-prefixed frames:
+// a 4-byte big-endian payload length followed by the event's JSON encoding.
+// This suits binary transports such as raw TCP sockets.
+type FramedEncoder struct {
+	writer io.Writer
+}
+
+// NewFramedEncoder creates a new FramedEncoder that writes to the provided io.Writer.
+func NewFramedEncoder(w io.Writer) *FramedEncoder {
+	return &FramedEncoder{writer: w}
+}
+
+// Encode validates event and writes it as a single length-prefixed frame.
+func (f *FramedEncoder) Encode(event Event) error {
+	data, err := EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxDelimitedValueSize {
+		return fmt.Errorf("%w: event of %d bytes exceeds frame limit of %d", ErrMarshalFailed, len(data), maxDelimitedValueSize)
+	}
+
+	frame := make([]byte, framePrefixSize+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[framePrefixSize:], data)
+	if _, err := f.writer.Write(frame); err != nil {
+		return fmt.Errorf("agui: failed to write event frame: %w", err)
+	}
+	return nil
+}
+
+// FramedDecoder reads AG-UI events written by a FramedEncoder.
+type FramedDecoder struct {
+	reader io.Reader
+	config *decodeConfig
+}
+
+// NewFramedDecoder creates a new FramedDecoder that reads from the provided io.Reader.
+// Frames larger than 64 MiB are rejected without reading their payload.
+func NewFramedDecoder(r io.Reader, opts ...DecoderOption) *FramedDecoder {
+	return &FramedDecoder{reader: r, config: newDecodeConfig(opts)}
+}
+
+// DecodeEvent reads and decodes the next framed event. It returns io.EOF when
+// the input ends cleanly between frames; input ending within a frame is an error.
+func (f *FramedDecoder) DecodeEvent() (Event, error) {
+	var prefix [framePrefixSize]byte
+	if _, err := io.ReadFull(f.reader, prefix[:]); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: reading frame length: %v", ErrUnmarshalFailed, err)
+	}
+
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size > maxDelimitedValueSize {
+		return nil, fmt.Errorf("%w: frame of %d bytes exceeds limit of %d", ErrUnmarshalFailed, size, maxDelimitedValueSize)
+	}
+	rawData := make([]byte, size)
+	if _, err := io.ReadFull(f.reader, rawData); err != nil {
+		return nil, fmt.Errorf("%w: reading frame of %d bytes: %v", ErrUnmarshalFailed, size, err)
+	}
+
+	var probe EventProbe
+	if err := json.Unmarshal(rawData, &probe); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+	}
+	probe.RawData = rawData
+	return decodeEventFromProbe(&probe, f.config)
+}
+
+// DecodeEvents continuously decodes framed events until EOF or error.
+// It returns a channel of events and a channel of errors.
+func (f *FramedDecoder) DecodeEvents() (<-chan Event, <-chan error) {
+	eventChan := make(chan Event, 10)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(eventChan)
+		defer close(errorChan)
+
+		for {
+			event, err := f.DecodeEvent()
+			if err != nil {
+				if err == io.EOF {
+					return // Normal end of stream
+				}
+				errorChan <- err
+				return
+			}
+			eventChan <- event
+		}
+	}()
+
+	return eventChan, errorChan
+}
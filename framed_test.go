@@ -0,0 +1,72 @@
+package agui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFramedRoundTrip(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello\nworld"),
+		NewTextMessageEndEvent("msg_1"),
+		NewStateSnapshotEvent(map[string]interface{}{"count": float64(1)}),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	var buf bytes.Buffer
+	encoder := NewFramedEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+
+	first, _ := EncodeEvent(events[0])
+	if size := binary.BigEndian.Uint32(buf.Bytes()); int(size) != len(first) {
+		t.Errorf("Expected first frame length %d, got %d", len(first), size)
+	}
+
+	eventChan, errorChan := NewFramedDecoder(&buf).DecodeEvents()
+	var decoded []Event
+	for event := range eventChan {
+		decoded = append(decoded, event)
+	}
+	if err := <-errorChan; err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+
+	if len(decoded) != len(events) {
+		t.Fatalf("Expected %d events, got %d", len(events), len(decoded))
+	}
+	for i := range events {
+		if !reflect.DeepEqual(decoded[i], events[i]) {
+			t.Errorf("Event %d: got %#v, want %#v", i, decoded[i], events[i])
+		}
+	}
+}
+
+func TestFramedDecoderErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewFramedEncoder(&buf).Encode(NewRunStartedEvent("thread_1", "run_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-3]
+	if _, err := NewFramedDecoder(bytes.NewReader(truncated)).DecodeEvent(); !errors.Is(err, ErrUnmarshalFailed) {
+		t.Errorf("Expected ErrUnmarshalFailed for a truncated frame, got %v", err)
+	}
+
+	oversized := []byte{0xff, 0xff, 0xff, 0xff}
+	if _, err := NewFramedDecoder(bytes.NewReader(oversized)).DecodeEvent(); !errors.Is(err, ErrUnmarshalFailed) {
+		t.Errorf("Expected ErrUnmarshalFailed for an oversized frame, got %v", err)
+	}
+
+	unknown := append([]byte{0, 0, 0, 18}, `{"type":"UNKNOWN"}`...)
+	if _, err := NewFramedDecoder(bytes.NewReader(unknown)).DecodeEvent(); !errors.Is(err, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package agui
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -72,6 +74,20 @@ func NewStepFinishedEvent(stepName string) *StepFinishedEvent {
 	return event
 }
 
+// NewStepProgressEvent creates a new StepProgressEvent with the current timestamp.
+func NewStepProgressEvent(stepName string, progress float64, message string) *StepProgressEvent {
+	event := &StepProgressEvent{
+		BaseEvent: BaseEvent{
+			Type: EventTypeStepProgress,
+		},
+		StepName: stepName,
+		Progress: progress,
+		Message:  message,
+	}
+	event.SetTimestamp()
+	return event
+}
+
 // NewTextMessageStartEvent creates a new TextMessageStartEvent with the current timestamp.
 func NewTextMessageStartEvent(messageID string) *TextMessageStartEvent {
 	event := &TextMessageStartEvent{
@@ -164,6 +180,47 @@ func NewToolCallResultEvent(messageID, toolCallID, content string) *ToolCallResu
 	return event
 }
 
+// NewToolCallResultStartEvent creates a new ToolCallResultStartEvent with the current timestamp.
+func NewToolCallResultStartEvent(messageID, toolCallID string) *ToolCallResultStartEvent {
+	event := &ToolCallResultStartEvent{
+		BaseEvent: BaseEvent{
+			Type: EventTypeToolCallResultStart,
+		},
+		MessageID:  messageID,
+		ToolCallID: toolCallID,
+		Role:       RoleTool,
+	}
+	event.SetTimestamp()
+	return event
+}
+
+// NewToolCallResultChunkEvent creates a new ToolCallResultChunkEvent with the current timestamp.
+func NewToolCallResultChunkEvent(messageID, toolCallID, delta string) *ToolCallResultChunkEvent {
+	event := &ToolCallResultChunkEvent{
+		BaseEvent: BaseEvent{
+			Type: EventTypeToolCallResultChunk,
+		},
+		MessageID:  messageID,
+		ToolCallID: toolCallID,
+		Delta:      delta,
+	}
+	event.SetTimestamp()
+	return event
+}
+
+// NewToolCallResultEndEvent creates a new ToolCallResultEndEvent with the current timestamp.
+func NewToolCallResultEndEvent(messageID, toolCallID string) *ToolCallResultEndEvent {
+	event := &ToolCallResultEndEvent{
+		BaseEvent: BaseEvent{
+			Type: EventTypeToolCallResultEnd,
+		},
+		MessageID:  messageID,
+		ToolCallID: toolCallID,
+	}
+	event.SetTimestamp()
+	return event
+}
+
 // NewStateSnapshotEvent creates a new StateSnapshotEvent with the current timestamp.
 func NewStateSnapshotEvent(snapshot State) *StateSnapshotEvent {
 	event := &StateSnapshotEvent{
@@ -294,6 +351,77 @@ func NewToolMessage(id, content, toolCallID, errorMsg, name string) *ToolMessage
 
 // Utility Functions
 
+// EventMessageID returns the ID of the message that event pertains to, and false
+// if the event does not refer to a message. Text message and tool call result
+// events report their MessageID; a ToolCallStartEvent reports its ParentMessageID
+// when set. It is a function rather than an Event method because several event
+// types already have a MessageID field.
+func EventMessageID(event Event) (string, bool) {
+	var id string
+	switch e := event.(type) {
+	case *TextMessageStartEvent:
+		id = e.MessageID
+	case *TextMessageContentEvent:
+		id = e.MessageID
+	case *TextMessageEndEvent:
+		id = e.MessageID
+	case *ToolCallStartEvent:
+		id = e.ParentMessageID
+	case *ToolCallResultEvent:
+		id = e.MessageID
+	case *ToolCallResultStartEvent:
+		id = e.MessageID
+	case *ToolCallResultChunkEvent:
+		id = e.MessageID
+	case *ToolCallResultEndEvent:
+		id = e.MessageID
+	}
+	return id, id != ""
+}
+
+// EventField returns the value of the field of event whose JSON name is name,
+// such as "messageId" or "delta", and false if the event type has no such field
+// or it is an unset pointer. Pointer fields are dereferenced. It is a function
+// rather than an Event method so that it works for every event type through
+// reflection over the JSON tags.
+func EventField(event Event, name string) (interface{}, bool) {
+	v := reflect.ValueOf(event)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, false
+	}
+	return structField(v.Elem(), name)
+}
+
+// structField looks up the field tagged name in v, descending into embedded structs.
+func structField(v reflect.Value, name string) (interface{}, bool) {
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			if value, ok := structField(v.Field(i), name); ok {
+				return value, true
+			}
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag != name || tag == "" || tag == "-" {
+			continue
+		}
+		value := v.Field(i)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return nil, false
+			}
+			value = value.Elem()
+		}
+		return value.Interface(), true
+	}
+	return nil, false
+}
+
 // GenerateMessageID generates a unique message ID based on the current timestamp.
 func GenerateMessageID() string {
 	return fmt.Sprintf("msg_%d", time.Now().UnixNano())
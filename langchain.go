@@ -0,0 +1,109 @@
+package agui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LangChainSource is the RawEvent source used for LangChain chunks that have
+// no AG-UI counterpart.
+const LangChainSource = "langchain"
+
+// langChainChunk is the shape of an event emitted by LangChain's astream_events.
+type langChainChunk struct {
+	Event string `json:"event"`
+	Name  string `json:"name"`
+	RunID string `json:"run_id"`
+	Data  struct {
+		Chunk *struct {
+			ID             string          `json:"id"`
+			Content        json.RawMessage `json:"content"`
+			ToolCallChunks []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Args string `json:"args"`
+			} `json:"tool_call_chunks"`
+		} `json:"chunk"`
+	} `json:"data"`
+}
+
+// FromLangChainChunk converts a LangChain astream_events chunk into an AG-UI
+// event:
+//
+//   - on_chat_model_stream with text content becomes a TextMessageContentEvent
+//     whose message ID is the chunk ID, or the run ID if the chunk has none
+//   - on_chat_model_stream with a tool call chunk carrying an ID and arguments
+//     becomes a ToolCallArgsEvent
+//   - on_tool_start becomes a ToolCallStartEvent and on_tool_end a
+//     ToolCallEndEvent, both using the run ID as the tool call ID
+//
+// Any other chunk, including model chunks with neither text nor identifiable
+// tool call arguments, is wrapped in a RawEvent with source LangChainSource.
+func FromLangChainChunk(raw json.RawMessage) (Event, error) {
+	var chunk langChainChunk
+	if err := json.Unmarshal(raw, &chunk); err != nil {
+		return nil, fmt.Errorf("%w: LangChain chunk: %v", ErrUnmarshalFailed, err)
+	}
+
+	var event Event
+	switch chunk.Event {
+	case "on_chat_model_stream":
+		if chunk.Data.Chunk == nil {
+			break
+		}
+		if text := langChainText(chunk.Data.Chunk.Content); text != "" {
+			messageID := chunk.Data.Chunk.ID
+			if messageID == "" {
+				messageID = chunk.RunID
+			}
+			event = NewTextMessageContentEvent(messageID, text)
+			break
+		}
+		for _, call := range chunk.Data.Chunk.ToolCallChunks {
+			if call.ID != "" && call.Args != "" {
+				event = NewToolCallArgsEvent(call.ID, call.Args)
+				break
+			}
+		}
+	case "on_tool_start":
+		event = NewToolCallStartEvent(chunk.RunID, chunk.Name, "")
+	case "on_tool_end":
+		event = NewToolCallEndEvent(chunk.RunID)
+	}
+
+	if event == nil {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("%w: LangChain chunk: %v", ErrUnmarshalFailed, err)
+		}
+		event = NewRawEvent(value, LangChainSource)
+	}
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: LangChain %s chunk: %v", ErrValidationFailed, chunk.Event, err)
+	}
+	return event, nil
+}
+
+// langChainText returns the text of a message chunk's content, which is either
+// a string or a list of content blocks of which only text blocks are kept.
+func langChainText(content json.RawMessage) string {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return text
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(content, &blocks) != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, block := range blocks {
+		if block.Type == "text" {
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package agui
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFromLangChainChunk(t *testing.T) {
+	t.Run("TextChunk", func(t *testing.T) {
+		raw := json.RawMessage(`{"event":"on_chat_model_stream","name":"ChatOpenAI","run_id":"run_1","data":{"chunk":{"id":"msg_1","content":"Hello"}}}`)
+		event, err := FromLangChainChunk(raw)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, ok := event.(*TextMessageContentEvent)
+		if !ok {
+			t.Fatalf("Expected *TextMessageContentEvent, got %T", event)
+		}
+		if content.MessageID != "msg_1" || content.Delta != "Hello" {
+			t.Errorf("Unexpected content event: %+v", content)
+		}
+	})
+
+	t.Run("ContentBlocks", func(t *testing.T) {
+		raw := json.RawMessage(`{"event":"on_chat_model_stream","run_id":"run_1","data":{"chunk":{"content":[{"type":"text","text":"Hi "},{"type":"image_url"},{"type":"text","text":"there"}]}}}`)
+		event, err := FromLangChainChunk(raw)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		content, ok := event.(*TextMessageContentEvent)
+		if !ok || content.MessageID != "run_1" || content.Delta != "Hi there" {
+			t.Errorf("Unexpected event: %#v", event)
+		}
+	})
+
+	t.Run("ToolChunks", func(t *testing.T) {
+		start, err := FromLangChainChunk(json.RawMessage(`{"event":"on_tool_start","name":"search","run_id":"tool_run_1","data":{"input":{"query":"weather"}}}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if s, ok := start.(*ToolCallStartEvent); !ok || s.ToolCallID != "tool_run_1" || s.ToolCallName != "search" {
+			t.Errorf("Unexpected start event: %#v", start)
+		}
+
+		args, err := FromLangChainChunk(json.RawMessage(`{"event":"on_chat_model_stream","run_id":"run_1","data":{"chunk":{"content":"","tool_call_chunks":[{"id":"call_1","name":"search","args":"{\"query\":","index":0}]}}}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if a, ok := args.(*ToolCallArgsEvent); !ok || a.ToolCallID != "call_1" || a.Delta != `{"query":` {
+			t.Errorf("Unexpected args event: %#v", args)
+		}
+
+		end, err := FromLangChainChunk(json.RawMessage(`{"event":"on_tool_end","name":"search","run_id":"tool_run_1","data":{"output":"Sunny"}}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if e, ok := end.(*ToolCallEndEvent); !ok || e.ToolCallID != "tool_run_1" {
+			t.Errorf("Unexpected end event: %#v", end)
+		}
+	})
+
+	t.Run("UnknownChunk", func(t *testing.T) {
+		event, err := FromLangChainChunk(json.RawMessage(`{"event":"on_chain_start","name":"agent","run_id":"run_1"}`))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		raw, ok := event.(*RawEvent)
+		if !ok || raw.Source != LangChainSource {
+			t.Fatalf("Expected RawEvent from %s, got %#v", LangChainSource, event)
+		}
+		if fields, ok := raw.Event.(map[string]interface{}); !ok || fields["event"] != "on_chain_start" {
+			t.Errorf("Expected the original chunk to be preserved, got %#v", raw.Event)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if _, err := FromLangChainChunk(json.RawMessage(`{"event":`)); !errors.Is(err, ErrUnmarshalFailed) {
+			t.Errorf("Expected ErrUnmarshalFailed, got %v", err)
+		}
+		if _, err := FromLangChainChunk(json.RawMessage(`{"event":"on_tool_start","name":"search"}`)); !errors.Is(err, ErrValidationFailed) {
+			t.Errorf("Expected ErrValidationFailed for a tool start without run ID, got %v", err)
+		}
+	})
+}
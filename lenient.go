@@ -0,0 +1,117 @@
+package agui
+
+import (
+	"bytes"
+	"io"
+)
+
+// stripJSONExtensions rewrites hand-edited JSON into strict JSON by removing
+// "//" line comments, "/* */" block comments and trailing commas before a
+// closing '}' or ']'. String literals are left untouched.
+func stripJSONExtensions(data []byte) []byte {
+	return stripTrailingCommas(stripJSONComments(data))
+}
+
+// stripJSONComments removes comments outside of string literals.
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			// Skip to the end of the line, keeping the newline itself
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+			// Keep tokens on either side of the comment separated
+			out = append(out, ' ')
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// stripTrailingCommas removes commas that are followed only by whitespace
+// and a closing '}' or ']', outside of string literals.
+func stripTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		} else if c == ',' {
+			j := i + 1
+			for j < len(data) && isJSONWhitespace(data[j]) {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// isJSONWhitespace reports whether c is insignificant whitespace in JSON.
+func isJSONWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// lenientReader buffers the whole underlying reader on first use and serves
+// it with JSON comments and trailing commas removed.
+type lenientReader struct {
+	source io.Reader
+	reader *bytes.Reader
+	err    error
+}
+
+// Read implements io.Reader.
+func (l *lenientReader) Read(p []byte) (int, error) {
+	if l.reader == nil && l.err == nil {
+		data, err := io.ReadAll(l.source)
+		if err != nil {
+			l.err = err
+		}
+		l.reader = bytes.NewReader(stripJSONExtensions(data))
+	}
+	if l.reader.Len() == 0 && l.err != nil {
+		return 0, l.err
+	}
+	return l.reader.Read(p)
+}
@@ -0,0 +1,82 @@
+package agui
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const lenientFixture = `// Fixture: a tool call start event
+{
+	"type": "TOOL_CALL_START", /* inline comment */
+	"toolCallId": "tool_call_1",
+	"toolCallName": "search", // trailing comment
+	"parentMessageId": "http://example.com/a,//b", // URL-like string with comment markers
+	"rawEvent": {"tags": ["a", "b",],},
+}
+`
+
+func TestLenientJSONDecoding(t *testing.T) {
+	if _, err := DecodeEventFromBytes([]byte(lenientFixture)); err == nil {
+		t.Fatal("Expected default decoding to reject comments and trailing commas")
+	}
+
+	decoded, err := DecodeEventFromBytes([]byte(lenientFixture), WithLenientJSON())
+	if err != nil {
+		t.Fatalf("Failed to decode lenient JSON: %v", err)
+	}
+
+	start, ok := decoded.(*ToolCallStartEvent)
+	if !ok {
+		t.Fatalf("Expected *ToolCallStartEvent, got %T", decoded)
+	}
+	if start.ToolCallName != "search" {
+		t.Errorf("Expected tool call name search, got %s", start.ToolCallName)
+	}
+	if start.ParentMessageID != "http://example.com/a,//b" {
+		t.Errorf("String content was altered: %s", start.ParentMessageID)
+	}
+}
+
+func TestLenientJSONStreamDecoding(t *testing.T) {
+	stream := lenientFixture + `
+// Second event
+{"type": "TOOL_CALL_END", "toolCallId": "tool_call_1",}
+`
+
+	decoder := NewDecoder(strings.NewReader(stream), WithLenientJSON())
+	var names []string
+	for {
+		event, err := decoder.DecodeEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		names = append(names, event.EventTypeName())
+	}
+
+	if strings.Join(names, ",") != "ToolCallStartEvent,ToolCallEndEvent" {
+		t.Errorf("Unexpected events: %v", names)
+	}
+}
+
+func TestStripJSONExtensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `{"a":1,}`, expected: `{"a":1}`},
+		{input: `[1, 2 , ]`, expected: `[1, 2  ]`},
+		{input: `{"s":"a,}"}`, expected: `{"s":"a,}"}`},
+		{input: `{"s":"\"//"} // c`, expected: `{"s":"\"//"} `},
+		{input: `{"a":/* x */1}`, expected: `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		if got := string(stripJSONExtensions([]byte(tt.input))); got != tt.expected {
+			t.Errorf("stripJSONExtensions(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package agui
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WithMaxToolArgsSize makes decoding reject tool call arguments larger than n
+// bytes with ErrToolArgsTooLarge. It applies to FunctionCall.Arguments of decoded
+// assistant messages and to the arguments accumulated from ToolCallArgsEvents;
+// a streaming Decoder sums the deltas of each tool call until its ToolCallEndEvent.
+// By default argument size is unlimited.
+func WithMaxToolArgsSize(n int) DecoderOption {
+	return func(c *decodeConfig) {
+		c.maxToolArgsSize = n
+	}
+}
+
+// WithMaxStateSize makes decoding reject StateSnapshotEvents and StateDeltaEvents
+// whose serialized snapshot or delta exceeds n bytes with ErrStateTooLarge.
+// By default state size is unlimited.
+func WithMaxStateSize(n int) DecoderOption {
+	return func(c *decodeConfig) {
+		c.maxStateSize = n
+	}
+}
+
+// checkEvent applies the size limits enabled in the configuration to a decoded event.
+func (c *decodeConfig) checkEvent(event Event) error {
+	if err := c.checkStateSize(event); err != nil {
+		return err
+	}
+	if c.maxToolArgsSize <= 0 {
+		return nil
+	}
+
+	switch e := event.(type) {
+	case *ToolCallArgsEvent:
+		if c.toolArgsSize == nil {
+			c.toolArgsSize = make(map[string]int)
+		}
+		size := c.toolArgsSize[e.ToolCallID] + len(e.Delta)
+		if size > c.maxToolArgsSize {
+			delete(c.toolArgsSize, e.ToolCallID)
+			return fmt.Errorf("%w: tool call %s arguments reached %d bytes, limit is %d", ErrToolArgsTooLarge, e.ToolCallID, size, c.maxToolArgsSize)
+		}
+		c.toolArgsSize[e.ToolCallID] = size
+	case *ToolCallEndEvent:
+		delete(c.toolArgsSize, e.ToolCallID)
+	case *MessagesSnapshotEvent:
+		for i, msg := range e.Messages {
+			if err := c.checkMessage(msg); err != nil {
+				return fmt.Errorf("invalid message at index %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkStateSize rejects state events whose payload exceeds the configured maximum.
+func (c *decodeConfig) checkStateSize(event Event) error {
+	if c.maxStateSize <= 0 {
+		return nil
+	}
+
+	var payload interface{}
+	switch e := event.(type) {
+	case *StateSnapshotEvent:
+		payload = e.Snapshot
+	case *StateDeltaEvent:
+		payload = e.Delta
+		if e.RawDelta != nil {
+			payload = e.RawDelta
+		}
+	default:
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+	if len(data) > c.maxStateSize {
+		return fmt.Errorf("%w: %s payload is %d bytes, limit is %d", ErrStateTooLarge, event.GetType(), len(data), c.maxStateSize)
+	}
+	return nil
+}
+
+// checkMessage applies the size limits enabled in the configuration to a decoded message.
+func (c *decodeConfig) checkMessage(message Message) error {
+	assistant, ok := message.(*AssistantMessage)
+	if !ok || c.maxToolArgsSize <= 0 {
+		return nil
+	}
+	for _, tc := range assistant.ToolCalls {
+		if size := len(tc.Function.Arguments); size > c.maxToolArgsSize {
+			return fmt.Errorf("%w: tool call %s arguments are %d bytes, limit is %d", ErrToolArgsTooLarge, tc.ID, size, c.maxToolArgsSize)
+		}
+	}
+	return nil
+}
+
+// ValidateLimits checks that the input has at most maxMessages messages,
+// maxTools tools and maxContext context entries, guarding servers against
+// oversized requests. A limit of zero or less disables the corresponding check.
+// It is independent of Validate, which applies no limits.
+func (r *RunAgentInput) ValidateLimits(maxMessages, maxTools, maxContext int) error {
+	if maxMessages > 0 && len(r.Messages) > maxMessages {
+		return fmt.Errorf("too many messages: %d exceeds the limit of %d", len(r.Messages), maxMessages)
+	}
+	if maxTools > 0 && len(r.Tools) > maxTools {
+		return fmt.Errorf("too many tools: %d exceeds the limit of %d", len(r.Tools), maxTools)
+	}
+	if maxContext > 0 && len(r.Context) > maxContext {
+		return fmt.Errorf("too many context entries: %d exceeds the limit of %d", len(r.Context), maxContext)
+	}
+	return nil
+}
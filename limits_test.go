@@ -0,0 +1,162 @@
+package agui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxToolArgsSizeStream(t *testing.T) {
+	events := []Event{
+		NewToolCallStartEvent("tool_call_1", "search", ""),
+		NewToolCallArgsEvent("tool_call_1", `{"query":`),
+		NewToolCallArgsEvent("tool_call_1", `"weather"}`),
+		NewToolCallEndEvent("tool_call_1"),
+		NewToolCallStartEvent("tool_call_2", "upload", ""),
+		NewToolCallArgsEvent("tool_call_2", `{"data":"`+strings.Repeat("x", 20)),
+		NewToolCallArgsEvent("tool_call_2", strings.Repeat("x", 20)+`"}`),
+	}
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+
+	decoder := NewDecoder(&buf, WithMaxToolArgsSize(32))
+	for i := range events {
+		_, err := decoder.DecodeEvent()
+		if i < len(events)-1 && err != nil {
+			t.Fatalf("Unexpected error at event %d: %v", i, err)
+		}
+		if i == len(events)-1 && !errors.Is(err, ErrToolArgsTooLarge) {
+			t.Errorf("Expected ErrToolArgsTooLarge for accumulated arguments, got: %v", err)
+		}
+	}
+}
+
+func TestMaxToolArgsSizeMessage(t *testing.T) {
+	normal := NewAssistantMessage("msg_1", "", "", []ToolCall{searchCall("tool_call_1")})
+	oversized := NewAssistantMessage("msg_2", "", "", []ToolCall{{
+		ID:       "tool_call_2",
+		Type:     ToolCallTypeFunction,
+		Function: FunctionCall{Name: "upload", Arguments: `{"data":"` + strings.Repeat("x", 100) + `"}`},
+	}})
+
+	for _, tt := range []struct {
+		message Message
+		wantErr bool
+	}{
+		{message: normal, wantErr: false},
+		{message: oversized, wantErr: true},
+	} {
+		data, err := EncodeMessage(tt.message)
+		if err != nil {
+			t.Fatalf("Failed to encode message: %v", err)
+		}
+
+		_, err = DecodeMessageFromBytes(data, WithMaxToolArgsSize(64))
+		if tt.wantErr && !errors.Is(err, ErrToolArgsTooLarge) {
+			t.Errorf("%s: expected ErrToolArgsTooLarge, got: %v", tt.message.GetID(), err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.message.GetID(), err)
+		}
+
+		// Default is unlimited
+		if _, err := DecodeMessageFromBytes(data); err != nil {
+			t.Errorf("%s: unexpected error without limit: %v", tt.message.GetID(), err)
+		}
+	}
+}
+
+func TestMaxStateSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   Event
+		wantErr bool
+	}{
+		{
+			name:    "NormalSnapshot",
+			event:   NewStateSnapshotEvent(map[string]interface{}{"count": 1}),
+			wantErr: false,
+		},
+		{
+			name:    "OversizedSnapshot",
+			event:   NewStateSnapshotEvent(map[string]interface{}{"blob": strings.Repeat("x", 200)}),
+			wantErr: true,
+		},
+		{
+			name: "OversizedDelta",
+			event: NewStateDeltaEvent([]interface{}{
+				map[string]interface{}{"op": "add", "path": "/blob", "value": strings.Repeat("x", 200)},
+			}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := EncodeEvent(tt.event)
+			if err != nil {
+				t.Fatalf("Failed to encode event: %v", err)
+			}
+
+			_, err = DecodeEventFromBytes(data, WithMaxStateSize(100))
+			if tt.wantErr && !errors.Is(err, ErrStateTooLarge) {
+				t.Errorf("Expected ErrStateTooLarge, got: %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if _, err := DecodeEventFromBytes(data); err != nil {
+				t.Errorf("Unexpected error without limit: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunAgentInputValidateLimits(t *testing.T) {
+	tool := Tool{Name: "search", Description: "Search the web", Parameters: map[string]interface{}{"type": "object"}}
+	input := &RunAgentInput{
+		ThreadID: "thread_1",
+		RunID:    "run_1",
+		Messages: []Message{NewUserMessage("msg_1", "Hi", ""), NewUserMessage("msg_2", "Still there?", "")},
+		Tools:    []Tool{tool},
+		Context:  []Context{{Description: "locale", Value: "en-US"}, {Description: "timezone", Value: "UTC"}, {Description: "plan", Value: "pro"}},
+	}
+
+	tests := []struct {
+		name                              string
+		maxMessages, maxTools, maxContext int
+		wantErr                           string
+	}{
+		{name: "WithinLimits", maxMessages: 2, maxTools: 1, maxContext: 3},
+		{name: "Unlimited"},
+		{name: "TooManyMessages", maxMessages: 1, wantErr: "too many messages: 2 exceeds the limit of 1"},
+		{name: "TooManyContext", maxMessages: 10, maxContext: 2, wantErr: "too many context entries: 3 exceeds the limit of 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := input.ValidateLimits(tt.maxMessages, tt.maxTools, tt.maxContext)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	input.Tools = append(input.Tools, tool)
+	if err := input.ValidateLimits(0, 1, 0); err == nil || !strings.Contains(err.Error(), "too many tools: 2") {
+		t.Errorf("Expected a tool limit error, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package agui
+
+import (
+	"fmt"
+)
+
+// StreamStart stands for the start of the stream in a transition table, as the
+// predecessor of the first event.
+const StreamStart EventType = ""
+
+// StreamStateMachine validates event streams against a declarative transition
+// table mapping each event type to the event types allowed immediately before
+// it. Event types missing from the table may follow any event.
+type StreamStateMachine struct {
+	predecessors map[EventType]map[EventType]bool
+}
+
+// NewStreamStateMachine creates a StreamStateMachine from transitions, which maps
+// each event type to its allowed predecessors; use StreamStart to allow an event
+// type first. A nil table selects DefaultTransitions.
+func NewStreamStateMachine(transitions map[EventType][]EventType) *StreamStateMachine {
+	if transitions == nil {
+		transitions = DefaultTransitions()
+	}
+	m := &StreamStateMachine{predecessors: make(map[EventType]map[EventType]bool)}
+	for eventType, allowed := range transitions {
+		set := make(map[EventType]bool, len(allowed))
+		for _, predecessor := range allowed {
+			set[predecessor] = true
+		}
+		m.predecessors[eventType] = set
+	}
+	return m
+}
+
+// DefaultTransitions returns the canonical AG-UI transition table. A stream
+// starts with RUN_STARTED, and another run may start once the previous one
+// finished or failed. Text message, tool call and chunked tool call result
+// lifecycles run uninterrupted from start to end. All other events may occur
+// anywhere inside a run, and RAW and CUSTOM events anywhere at all; since those
+// two are transparent to Validate, they do not open a way into or out of a run.
+// The table is a fresh copy that callers may modify to build their own profiles.
+func DefaultTransitions() map[EventType][]EventType {
+	terminal := []EventType{EventTypeRunFinished, EventTypeRunError}
+	var inRun, anywhere []EventType
+	anywhere = append(anywhere, StreamStart)
+	for _, eventType := range []EventType{
+		EventTypeRunStarted, EventTypeRunFinished, EventTypeRunError,
+		EventTypeStepStarted, EventTypeStepFinished, EventTypeStepProgress,
+		EventTypeTextMessageStart, EventTypeTextMessageContent, EventTypeTextMessageEnd,
+		EventTypeToolCallStart, EventTypeToolCallArgs, EventTypeToolCallEnd, EventTypeToolCallResult,
+		EventTypeToolCallResultStart, EventTypeToolCallResultChunk, EventTypeToolCallResultEnd,
+		EventTypeStateSnapshot, EventTypeStateDelta, EventTypeMessagesSnapshot,
+		EventTypeRaw, EventTypeCustom,
+	} {
+		anywhere = append(anywhere, eventType)
+		if eventType != EventTypeRunFinished && eventType != EventTypeRunError {
+			inRun = append(inRun, eventType)
+		}
+	}
+
+	transitions := map[EventType][]EventType{
+		EventTypeRunStarted:          append([]EventType{StreamStart, EventTypeRaw, EventTypeCustom}, terminal...),
+		EventTypeTextMessageContent:  {EventTypeTextMessageStart, EventTypeTextMessageContent},
+		EventTypeTextMessageEnd:      {EventTypeTextMessageStart, EventTypeTextMessageContent},
+		EventTypeToolCallArgs:        {EventTypeToolCallStart, EventTypeToolCallArgs},
+		EventTypeToolCallEnd:         {EventTypeToolCallStart, EventTypeToolCallArgs},
+		EventTypeToolCallResultChunk: {EventTypeToolCallResultStart, EventTypeToolCallResultChunk},
+		EventTypeToolCallResultEnd:   {EventTypeToolCallResultStart, EventTypeToolCallResultChunk},
+		EventTypeRaw:                 anywhere,
+		EventTypeCustom:              append([]EventType(nil), anywhere...),
+	}
+	for _, eventType := range []EventType{
+		EventTypeRunFinished, EventTypeRunError,
+		EventTypeStepStarted, EventTypeStepFinished, EventTypeStepProgress,
+		EventTypeTextMessageStart, EventTypeToolCallStart, EventTypeToolCallResult, EventTypeToolCallResultStart,
+		EventTypeStateSnapshot, EventTypeStateDelta, EventTypeMessagesSnapshot,
+	} {
+		transitions[eventType] = append([]EventType(nil), inRun...)
+	}
+	return transitions
+}
+
+// Validate checks every transition in events against the table, returning an
+// error wrapping ErrInvalidSequence for the first disallowed one. RAW and CUSTOM
+// events are transparent: an event following them must be allowed both after
+// them and after the last event of another type, or at the stream start when
+// there is none.
+func (m *StreamStateMachine) Validate(events []Event) error {
+	previous, substantive := StreamStart, StreamStart
+	for i, event := range events {
+		eventType := event.GetType()
+		if err := m.check(previous, eventType); err != nil {
+			return fmt.Errorf("%w: event %d: %v", ErrInvalidSequence, i, err)
+		}
+		previous = eventType
+		if eventType == EventTypeRaw || eventType == EventTypeCustom {
+			continue
+		}
+		if err := m.check(substantive, eventType); err != nil {
+			return fmt.Errorf("%w: event %d: %v", ErrInvalidSequence, i, err)
+		}
+		substantive = eventType
+	}
+	return nil
+}
+
+// check returns an error if eventType may not follow previous.
+func (m *StreamStateMachine) check(previous, eventType EventType) error {
+	if allowed, ok := m.predecessors[eventType]; ok && !allowed[previous] {
+		if previous == StreamStart {
+			return fmt.Errorf("%s cannot start the stream", eventType)
+		}
+		return fmt.Errorf("%s cannot follow %s", eventType, previous)
+	}
+	return nil
+}
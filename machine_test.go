@@ -0,0 +1,113 @@
+package agui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStreamStateMachineDefault(t *testing.T) {
+	machine := NewStreamStateMachine(nil)
+
+	valid := []Event{
+		NewCustomEvent("hello", true),
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewStepStartedEvent("plan"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hi"),
+		NewTextMessageEndEvent("msg_1"),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+		NewToolCallArgsEvent("tool_call_1", "{}"),
+		NewToolCallEndEvent("tool_call_1"),
+		NewToolCallResultEvent("msg_2", "tool_call_1", "done"),
+		NewStepFinishedEvent("plan"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+		NewRawEvent("upstream", ""),
+		NewRunStartedEvent("thread_1", "run_2"),
+		NewRunErrorEvent("boom", ""),
+	}
+	if err := machine.Validate(valid); err != nil {
+		t.Errorf("Unexpected error for a valid stream: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		events  []Event
+		wantErr string
+	}{
+		{
+			name:    "NoRunStarted",
+			events:  []Event{NewTextMessageStartEvent("msg_1")},
+			wantErr: "event 0: TEXT_MESSAGE_START cannot start the stream",
+		},
+		{
+			name: "InterruptedMessage",
+			events: []Event{
+				NewRunStartedEvent("thread_1", "run_1"),
+				NewTextMessageStartEvent("msg_1"),
+				NewStepStartedEvent("plan"),
+				NewTextMessageContentEvent("msg_1", "Hi"),
+			},
+			wantErr: "event 3: TEXT_MESSAGE_CONTENT cannot follow STEP_STARTED",
+		},
+		{
+			name: "EventAfterRunFinished",
+			events: []Event{
+				NewRunStartedEvent("thread_1", "run_1"),
+				NewRunFinishedEvent("thread_1", "run_1", nil),
+				NewStepStartedEvent("plan"),
+			},
+			wantErr: "event 2: STEP_STARTED cannot follow RUN_FINISHED",
+		},
+		{
+			name: "RawAfterRunFinished",
+			events: []Event{
+				NewRunStartedEvent("thread_1", "run_1"),
+				NewRunFinishedEvent("thread_1", "run_1", nil),
+				NewRawEvent("upstream", ""),
+				NewTextMessageStartEvent("msg_1"),
+			},
+			wantErr: "event 3: TEXT_MESSAGE_START cannot follow RUN_FINISHED",
+		},
+		{
+			name: "CustomBeforeRunStarted",
+			events: []Event{
+				NewCustomEvent("hello", true),
+				NewStepStartedEvent("plan"),
+			},
+			wantErr: "event 1: STEP_STARTED cannot start the stream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := machine.Validate(tt.events)
+			if !errors.Is(err, ErrInvalidSequence) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStreamStateMachineCustom(t *testing.T) {
+	// A profile that allows steps to interrupt text messages
+	transitions := DefaultTransitions()
+	transitions[EventTypeTextMessageContent] = append(transitions[EventTypeTextMessageContent], EventTypeStepStarted)
+
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewStepStartedEvent("plan"),
+		NewTextMessageContentEvent("msg_1", "Hi"),
+	}
+	if err := NewStreamStateMachine(transitions).Validate(events); err != nil {
+		t.Errorf("Unexpected error with a custom profile: %v", err)
+	}
+
+	// Event types missing from the table are unconstrained
+	minimal := NewStreamStateMachine(map[EventType][]EventType{
+		EventTypeRunFinished: {EventTypeRunStarted},
+	})
+	if err := minimal.Validate([]Event{NewStepStartedEvent("plan"), NewRunStartedEvent("thread_1", "run_1"), NewRunFinishedEvent("thread_1", "run_1", nil)}); err != nil {
+		t.Errorf("Unexpected error with a minimal profile: %v", err)
+	}
+}
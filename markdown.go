@@ -0,0 +1,61 @@
+package agui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExportMarkdown renders the conversation described by events as Markdown. Each
+// message gets a heading naming its role, followed by its text; tool calls and
+// tool results are rendered as fenced code blocks. The conversation is
+// reconstructed with BuildConversation.
+func ExportMarkdown(events []Event) (string, error) {
+	conversation, err := BuildConversation(events)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, message := range conversation {
+		switch m := message.(type) {
+		case *DeveloperMessage:
+			writeMarkdownText(&b, "Developer", m.Content)
+		case *SystemMessage:
+			writeMarkdownText(&b, "System", m.Content)
+		case *UserMessage:
+			writeMarkdownText(&b, "User", m.Content)
+		case *AssistantMessage:
+			writeMarkdownText(&b, "Assistant", m.Content)
+			for _, call := range m.ToolCalls {
+				fmt.Fprintf(&b, "**Tool call** `%s` (`%s`)\n\n", call.Function.Name, call.ID)
+				writeMarkdownFence(&b, "json", call.Function.Arguments)
+			}
+		case *ToolMessage:
+			fmt.Fprintf(&b, "## Tool result (`%s`)\n\n", m.ToolCallID)
+			writeMarkdownFence(&b, "", m.Content)
+			if m.Error != "" {
+				fmt.Fprintf(&b, "**Error:** %s\n\n", m.Error)
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// writeMarkdownText writes a role heading followed by content, if any.
+func writeMarkdownText(b *strings.Builder, heading, content string) {
+	fmt.Fprintf(b, "## %s\n\n", heading)
+	if content != "" {
+		b.WriteString(content)
+		b.WriteString("\n\n")
+	}
+}
+
+// writeMarkdownFence writes content as a fenced code block, lengthening the
+// fence when content itself contains backtick fences.
+func writeMarkdownFence(b *strings.Builder, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, content, fence)
+}
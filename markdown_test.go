@@ -0,0 +1,42 @@
+package agui
+
+import (
+	"testing"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	events := []Event{
+		NewMessagesSnapshotEvent([]Message{
+			NewUserMessage("msg_1", "What's the weather in Paris?", ""),
+		}),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_2", "Let me check."),
+		NewTextMessageEndEvent("msg_2"),
+		NewToolCallStartEvent("tc_1", "get_weather", "msg_2"),
+		NewToolCallArgsEvent("tc_1", `{"city":"Paris"}`),
+		NewToolCallEndEvent("tc_1"),
+		NewToolCallResultEvent("msg_3", "tc_1", "Sunny, 22°C"),
+		NewTextMessageStartEvent("msg_4"),
+		NewTextMessageContentEvent("msg_4", "It's sunny in Paris."),
+		NewTextMessageEndEvent("msg_4"),
+	}
+
+	markdown, err := ExportMarkdown(events)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "## User\n\n" +
+		"What's the weather in Paris?\n\n" +
+		"## Assistant\n\n" +
+		"Let me check.\n\n" +
+		"**Tool call** `get_weather` (`tc_1`)\n\n" +
+		"```json\n{\"city\":\"Paris\"}\n```\n\n" +
+		"## Tool result (`tc_1`)\n\n" +
+		"```\nSunny, 22°C\n```\n\n" +
+		"## Assistant\n\n" +
+		"It's sunny in Paris."
+	if markdown != expected {
+		t.Errorf("Unexpected Markdown:\n%s\n\nExpected:\n%s", markdown, expected)
+	}
+}
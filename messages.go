@@ -1,6 +1,7 @@
 package agui
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -101,8 +102,10 @@ func (s *SystemMessage) Validate() error {
 // AssistantMessage represents a message from an assistant.
 type AssistantMessage struct {
 	BaseMessage
-	Content   string     `json:"content,omitempty"`   // Text content of the message
-	ToolCalls []ToolCall `json:"toolCalls,omitempty"` // Tool calls made in this message
+	Content   string      `json:"content,omitempty"`   // Text content of the message
+	ToolCalls []ToolCall  `json:"toolCalls,omitempty"` // Tool calls made in this message
+	Refusal   string      `json:"refusal,omitempty"`   // Refusal text if the assistant declined to answer
+	Audio     interface{} `json:"audio,omitempty"`     // Provider audio payload, preserved as-is and not validated
 }
 
 // MessageType returns the concrete type name.
@@ -110,6 +113,11 @@ func (a *AssistantMessage) MessageType() string {
 	return "AssistantMessage"
 }
 
+// IsRefusal reports whether the assistant message carries a refusal.
+func (a *AssistantMessage) IsRefusal() bool {
+	return a.Refusal != ""
+}
+
 // Validate checks if the AssistantMessage is valid.
 func (a *AssistantMessage) Validate() error {
 	if err := a.BaseMessage.Validate(); err != nil {
@@ -118,6 +126,8 @@ func (a *AssistantMessage) Validate() error {
 	if a.Role != RoleAssistant {
 		return fmt.Errorf("assistant message must have assistant role, got: %s", a.Role)
 	}
+	// Content is optional, so a refusal-only message with no content and no
+	// tool calls is valid.
 
 	// Validate tool calls if present
 	for i, toolCall := range a.ToolCalls {
@@ -193,3 +203,35 @@ type MessageWrapper struct {
 	*UserMessage
 	*ToolMessage
 }
+
+// Messages is a list of messages that can be decoded directly from a JSON array,
+// restoring each element to its concrete message type based on its role.
+type Messages []Message
+
+// MarshalJSON implements json.Marshaler.
+func (m Messages) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]Message(m))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Messages) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*m = nil
+		return nil
+	}
+
+	messages := make(Messages, len(raw))
+	for i, rawMessage := range raw {
+		message, err := DecodeMessageFromBytes(rawMessage)
+		if err != nil {
+			return fmt.Errorf("invalid message at index %d: %w", i, err)
+		}
+		messages[i] = message
+	}
+	*m = messages
+	return nil
+}
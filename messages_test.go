@@ -0,0 +1,176 @@
+package agui
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssistantMessageRefusal(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   *AssistantMessage
+		isRefusal bool
+	}{
+		{
+			name: "RefusalOnly",
+			message: &AssistantMessage{
+				BaseMessage: BaseMessage{ID: "msg_1", Role: RoleAssistant},
+				Refusal:     "I can't help with that.",
+			},
+			isRefusal: true,
+		},
+		{
+			name:      "NormalMessage",
+			message:   NewAssistantMessage("msg_2", "Hello! How can I help?", "", nil),
+			isRefusal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.message.IsRefusal(); got != tt.isRefusal {
+				t.Errorf("IsRefusal mismatch: expected %v, got %v", tt.isRefusal, got)
+			}
+
+			data, err := EncodeMessage(tt.message)
+			if err != nil {
+				t.Fatalf("Failed to encode message: %v", err)
+			}
+
+			decoded, err := DecodeMessageFromBytes(data)
+			if err != nil {
+				t.Fatalf("Failed to decode message: %v", err)
+			}
+
+			assistant, ok := decoded.(*AssistantMessage)
+			if !ok {
+				t.Fatalf("Expected *AssistantMessage, got %T", decoded)
+			}
+			if assistant.Refusal != tt.message.Refusal {
+				t.Errorf("Refusal mismatch: expected %q, got %q", tt.message.Refusal, assistant.Refusal)
+			}
+			if assistant.Content != tt.message.Content {
+				t.Errorf("Content mismatch: expected %q, got %q", tt.message.Content, assistant.Content)
+			}
+			if assistant.IsRefusal() != tt.isRefusal {
+				t.Errorf("Decoded IsRefusal mismatch: expected %v, got %v", tt.isRefusal, assistant.IsRefusal())
+			}
+		})
+	}
+}
+
+func TestMessagesUnmarshalJSON(t *testing.T) {
+	data := []byte(`[
+		{"id":"msg_1","role":"system","content":"You are helpful."},
+		{"id":"msg_2","role":"user","content":"What's the weather?"},
+		{"id":"msg_3","role":"assistant","toolCalls":[{"id":"tool_call_1","type":"function","function":{"name":"search","arguments":"{}"}}]},
+		{"id":"msg_4","role":"tool","content":"Sunny","toolCallId":"tool_call_1"}
+	]`)
+
+	var messages Messages
+	if err := json.Unmarshal(data, &messages); err != nil {
+		t.Fatalf("Failed to unmarshal messages: %v", err)
+	}
+
+	expected := []string{"SystemMessage", "UserMessage", "AssistantMessage", "ToolMessage"}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d", len(expected), len(messages))
+	}
+	for i, message := range messages {
+		if message.MessageType() != expected[i] {
+			t.Errorf("Message %d: expected %s, got %s", i, expected[i], message.MessageType())
+		}
+	}
+	if calls := messages[2].(*AssistantMessage).ToolCalls; len(calls) != 1 || calls[0].ID != "tool_call_1" {
+		t.Errorf("Expected tool calls to be restored, got %+v", calls)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"id":"msg_1","role":"robot"}]`), &messages); err == nil {
+		t.Error("Expected error for a message with an unknown role")
+	}
+}
+
+func TestMessagesSnapshotRoundTrip(t *testing.T) {
+	event := NewMessagesSnapshotEvent([]Message{
+		NewUserMessage("msg_1", "Hello", ""),
+		NewAssistantMessage("msg_2", "Hi there!", "", nil),
+	})
+
+	data, err := EncodeEvent(event)
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	decoded, err := DecodeEventFromBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+
+	snapshot := decoded.(*MessagesSnapshotEvent)
+	if len(snapshot.Messages) != 2 || snapshot.Messages[1].MessageType() != "AssistantMessage" {
+		t.Errorf("Unexpected decoded messages: %+v", snapshot.Messages)
+	}
+}
+
+func TestAssistantMessageMalformedToolCallArguments(t *testing.T) {
+	data := []byte(`{"id":"msg_1","role":"assistant","toolCalls":[` +
+		`{"id":"tool_call_1","type":"function","function":{"name":"search","arguments":"{\"q\":1}"}},` +
+		`{"id":"tool_call_2","type":"function","function":{"name":"search","arguments":"{\"q\":"}}]}`)
+
+	// Decoding validates the message
+	_, err := DecodeMessageFromBytes(data)
+	if err == nil {
+		t.Fatal("Expected error for malformed tool call arguments")
+	}
+	if !strings.Contains(err.Error(), "tool call at index 1") || !strings.Contains(err.Error(), "valid JSON") {
+		t.Errorf("Expected error naming tool call index 1, got: %v", err)
+	}
+
+	// So does Validate on a message decoded without validation
+	var message AssistantMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+	if err := message.Validate(); err == nil || !strings.Contains(err.Error(), "tool call at index 1") {
+		t.Errorf("Expected Validate error naming tool call index 1, got: %v", err)
+	}
+}
+
+func TestAssistantMessageAudioRoundTrip(t *testing.T) {
+	data := []byte(`{"id":"msg_1","role":"assistant","content":"Hello","audio":{"id":"audio_1","expires_at":1700000000,"transcript":"Hello"}}`)
+
+	message, err := DecodeMessageFromBytes(data, WithStrictDecoding())
+	if err != nil {
+		t.Fatalf("Failed to decode message with audio: %v", err)
+	}
+	assistant := message.(*AssistantMessage)
+	audio, ok := assistant.Audio.(map[string]interface{})
+	if !ok || audio["id"] != "audio_1" {
+		t.Fatalf("Expected audio to be preserved, got %v", assistant.Audio)
+	}
+
+	encoded, err := json.Marshal(assistant)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	var got, want map[string]interface{}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Round trip mismatch:\n got: %s\nwant: %s", encoded, data)
+	}
+
+	// Messages without audio don't emit the field
+	encoded, err = json.Marshal(NewAssistantMessage("msg_2", "Hi", "", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), "audio") {
+		t.Errorf("Expected no audio field, got %s", encoded)
+	}
+}
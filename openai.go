@@ -0,0 +1,72 @@
+package agui
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// openAIToolCall is the tool call shape used by OpenAI's chat completions API.
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// ToOpenAI returns the tool call in OpenAI's function calling format,
+// {"id","type","function":{"name","arguments"}}, with the arguments as a JSON
+// string. An empty type is written as "function".
+func (t ToolCall) ToOpenAI() json.RawMessage {
+	callType := t.Type
+	if callType == "" {
+		callType = ToolCallTypeFunction
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":   t.ID,
+		"type": callType,
+		"function": map[string]string{
+			"name":      t.Function.Name,
+			"arguments": t.Function.Arguments,
+		},
+	})
+	return data
+}
+
+// ToolCallFromOpenAI converts a tool call in OpenAI's function calling format
+// into a ToolCall and validates it. A missing type defaults to "function", and
+// arguments given as a JSON object rather than an encoded string are accepted.
+func ToolCallFromOpenAI(raw json.RawMessage) (ToolCall, error) {
+	var call openAIToolCall
+	if err := json.Unmarshal(raw, &call); err != nil {
+		return ToolCall{}, fmt.Errorf("%w: OpenAI tool call: %v", ErrUnmarshalFailed, err)
+	}
+
+	// OpenAI encodes arguments as a string, but some compatible providers send an object
+	var arguments string
+	if err := json.Unmarshal(call.Function.Arguments, &arguments); err != nil {
+		var value interface{}
+		if json.Unmarshal(call.Function.Arguments, &value) != nil {
+			return ToolCall{}, fmt.Errorf("%w: OpenAI tool call arguments: %v", ErrUnmarshalFailed, err)
+		}
+		compact, _ := json.Marshal(value)
+		arguments = string(compact)
+	}
+
+	toolCall := ToolCall{
+		ID:   call.ID,
+		Type: ToolCallType(call.Type),
+		Function: FunctionCall{
+			Name:      call.Function.Name,
+			Arguments: arguments,
+		},
+	}
+	if toolCall.Type == "" {
+		toolCall.Type = ToolCallTypeFunction
+	}
+	if err := toolCall.Validate(); err != nil {
+		return ToolCall{}, fmt.Errorf("%w: %v", ErrValidationFailed, err)
+	}
+	return toolCall, nil
+}
@@ -0,0 +1,59 @@
+package agui
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestToolCallOpenAIRoundTrip(t *testing.T) {
+	call := ToolCall{
+		ID:       "call_abc",
+		Type:     ToolCallTypeFunction,
+		Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+	}
+
+	raw := call.ToOpenAI()
+	var shape map[string]interface{}
+	if err := json.Unmarshal(raw, &shape); err != nil {
+		t.Fatalf("ToOpenAI produced invalid JSON: %v", err)
+	}
+	expected := map[string]interface{}{
+		"id":   "call_abc",
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":      "get_weather",
+			"arguments": `{"city":"Paris"}`,
+		},
+	}
+	if !reflect.DeepEqual(shape, expected) {
+		t.Errorf("Unexpected OpenAI shape: %s", raw)
+	}
+
+	back, err := ToolCallFromOpenAI(raw)
+	if err != nil {
+		t.Fatalf("Failed to convert from OpenAI: %v", err)
+	}
+	if !reflect.DeepEqual(back, call) {
+		t.Errorf("Round trip mismatch: got %+v, want %+v", back, call)
+	}
+}
+
+func TestToolCallFromOpenAINormalizes(t *testing.T) {
+	// Missing type and object arguments, as sent by some compatible providers
+	call, err := ToolCallFromOpenAI(json.RawMessage(`{"id":"call_1","function":{"name":"search","arguments":{"q":"go"}}}`))
+	if err != nil {
+		t.Fatalf("Failed to convert from OpenAI: %v", err)
+	}
+	if call.Type != ToolCallTypeFunction || call.Function.Arguments != `{"q":"go"}` {
+		t.Errorf("Unexpected normalized tool call: %+v", call)
+	}
+
+	if _, err := ToolCallFromOpenAI(json.RawMessage(`{"id":"call_1","type":"function","function":{"name":"search","arguments":"{bad"}}`)); !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Expected ErrValidationFailed for malformed arguments, got %v", err)
+	}
+	if _, err := ToolCallFromOpenAI(json.RawMessage(`[1,2]`)); !errors.Is(err, ErrUnmarshalFailed) {
+		t.Errorf("Expected ErrUnmarshalFailed for a non-object, got %v", err)
+	}
+}
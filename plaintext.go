@@ -0,0 +1,75 @@
+package agui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlainTextOption configures ExportPlainText.
+type PlainTextOption func(*plainTextConfig)
+
+// plainTextConfig holds the settings applied by PlainTextOptions.
+type plainTextConfig struct {
+	toolArguments bool
+}
+
+// WithToolArguments makes ExportPlainText include the arguments of tool calls.
+func WithToolArguments() PlainTextOption {
+	return func(c *plainTextConfig) {
+		c.toolArguments = true
+	}
+}
+
+// ExportPlainText renders the conversation described by events as plain text,
+// one role-prefixed line per message, such as "User: ..." or "Assistant: ...".
+// Tool calls are rendered as "Assistant: [tool call: name]" lines, without their
+// arguments unless WithToolArguments is given, and tool results as
+// "Tool(name): ..." lines. Line breaks within a message are kept. The
+// conversation is reconstructed with BuildConversation.
+func ExportPlainText(events []Event, opts ...PlainTextOption) (string, error) {
+	config := &plainTextConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	conversation, err := BuildConversation(events)
+	if err != nil {
+		return "", err
+	}
+
+	toolNames := make(map[string]string)
+	var lines []string
+	for _, message := range conversation {
+		switch m := message.(type) {
+		case *DeveloperMessage:
+			lines = append(lines, "Developer: "+m.Content)
+		case *SystemMessage:
+			lines = append(lines, "System: "+m.Content)
+		case *UserMessage:
+			lines = append(lines, "User: "+m.Content)
+		case *AssistantMessage:
+			if m.Content != "" {
+				lines = append(lines, "Assistant: "+m.Content)
+			}
+			for _, call := range m.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				if config.toolArguments {
+					lines = append(lines, fmt.Sprintf("Assistant: [tool call: %s %s]", call.Function.Name, call.Function.Arguments))
+				} else {
+					lines = append(lines, fmt.Sprintf("Assistant: [tool call: %s]", call.Function.Name))
+				}
+			}
+		case *ToolMessage:
+			name := toolNames[m.ToolCallID]
+			if name == "" {
+				name = m.ToolCallID
+			}
+			content := m.Content
+			if m.Error != "" {
+				content = "error: " + m.Error
+			}
+			lines = append(lines, fmt.Sprintf("Tool(%s): %s", name, content))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,55 @@
+package agui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportPlainText(t *testing.T) {
+	events := []Event{
+		NewMessagesSnapshotEvent([]Message{
+			NewUserMessage("msg_1", "What's the weather in Paris?", ""),
+		}),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_2", "Let me check."),
+		NewTextMessageEndEvent("msg_2"),
+		NewToolCallStartEvent("tc_1", "get_weather", "msg_2"),
+		NewToolCallArgsEvent("tc_1", `{"city":"Paris"}`),
+		NewToolCallEndEvent("tc_1"),
+		NewToolCallResultEvent("msg_3", "tc_1", "Sunny"),
+		NewTextMessageStartEvent("msg_4"),
+		NewTextMessageContentEvent("msg_4", "It's sunny in Paris."),
+		NewTextMessageEndEvent("msg_4"),
+	}
+
+	text, err := ExportPlainText(events)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "User: What's the weather in Paris?\n" +
+		"Assistant: Let me check.\n" +
+		"Assistant: [tool call: get_weather]\n" +
+		"Tool(get_weather): Sunny\n" +
+		"Assistant: It's sunny in Paris."
+	if text != expected {
+		t.Errorf("Unexpected plain text:\n%s\n\nExpected:\n%s", text, expected)
+	}
+
+	text, err = ExportPlainText(events, WithToolArguments())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := `Assistant: [tool call: get_weather {"city":"Paris"}]`; !containsLine(text, want) {
+		t.Errorf("Expected line %q in:\n%s", want, text)
+	}
+}
+
+// containsLine reports whether text has a line equal to line.
+func containsLine(text, line string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package agui
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Protobuf wire types used by the agui.proto schema.
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+// protoKind is how a JSON event field is carried in its protobuf message.
+type protoKind int
+
+const (
+	protoString       protoKind = iota // string
+	protoInt64                         // optional int64
+	protoDouble                        // double
+	protoJSON                          // bytes holding a JSON value
+	protoRepeatedJSON                  // repeated bytes, one JSON array element each
+)
+
+// protoField maps a JSON field of an event to its protobuf field number.
+type protoField struct {
+	num  uint64
+	name string
+	kind protoKind
+}
+
+// protoEventSchema maps an event type to its oneof field number in the
+// envelope and the fields of its payload message.
+type protoEventSchema struct {
+	eventType EventType
+	num       uint64
+	fields    []protoField
+}
+
+// protoEnvelopeFields are the BaseEvent fields of the agui.v1.Event envelope.
+var protoEnvelopeFields = []protoField{
+	{1, "type", protoString},
+	{2, "timestamp", protoInt64},
+	{3, "rawEvent", protoJSON},
+	{4, "seq", protoInt64},
+	{5, "traceparent", protoString},
+}
+
+// protoEventSchemas mirrors agui.proto.
+var protoEventSchemas = []protoEventSchema{
+	{EventTypeTextMessageStart, 10, []protoField{{1, "messageId", protoString}, {2, "role", protoString}}},
+	{EventTypeTextMessageContent, 11, []protoField{{1, "messageId", protoString}, {2, "delta", protoString}}},
+	{EventTypeTextMessageEnd, 12, []protoField{{1, "messageId", protoString}}},
+	{EventTypeToolCallStart, 13, []protoField{{1, "toolCallId", protoString}, {2, "toolCallName", protoString}, {3, "parentMessageId", protoString}}},
+	{EventTypeToolCallArgs, 14, []protoField{{1, "toolCallId", protoString}, {2, "delta", protoString}}},
+	{EventTypeToolCallEnd, 15, []protoField{{1, "toolCallId", protoString}}},
+	{EventTypeToolCallResult, 16, []protoField{{1, "messageId", protoString}, {2, "toolCallId", protoString}, {3, "content", protoString}, {4, "role", protoString}}},
+	{EventTypeToolCallResultStart, 17, []protoField{{1, "messageId", protoString}, {2, "toolCallId", protoString}, {3, "role", protoString}}},
+	{EventTypeToolCallResultChunk, 18, []protoField{{1, "messageId", protoString}, {2, "toolCallId", protoString}, {3, "delta", protoString}}},
+	{EventTypeToolCallResultEnd, 19, []protoField{{1, "messageId", protoString}, {2, "toolCallId", protoString}}},
+	{EventTypeStateSnapshot, 20, []protoField{{1, "snapshot", protoJSON}}},
+	{EventTypeStateDelta, 21, []protoField{{1, "delta", protoRepeatedJSON}}},
+	{EventTypeMessagesSnapshot, 22, []protoField{{1, "messages", protoRepeatedJSON}}},
+	{EventTypeRaw, 23, []protoField{{1, "event", protoJSON}, {2, "source", protoString}}},
+	{EventTypeCustom, 24, []protoField{{1, "name", protoString}, {2, "value", protoJSON}}},
+	{EventTypeRunStarted, 25, []protoField{{1, "threadId", protoString}, {2, "runId", protoString}, {3, "parentRunId", protoString}}},
+	{EventTypeRunFinished, 26, []protoField{{1, "threadId", protoString}, {2, "runId", protoString}, {3, "result", protoJSON}}},
+	{EventTypeRunError, 27, []protoField{{1, "message", protoString}, {2, "code", protoString}}},
+	{EventTypeStepStarted, 28, []protoField{{1, "stepName", protoString}}},
+	{EventTypeStepFinished, 29, []protoField{{1, "stepName", protoString}}},
+	{EventTypeStepProgress, 30, []protoField{{1, "stepName", protoString}, {2, "progress", protoDouble}, {3, "message", protoString}}},
+}
+
+// EncodeEventProto validates event and encodes it as an agui.v1.Event
+// protobuf message, as described by agui.proto. The payload oneof is chosen
+// from the event's type, and free form JSON values are carried as JSON bytes.
+func EncodeEventProto(event Event) ([]byte, error) {
+	data, err := EncodeEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+
+	schema, ok := protoSchemaForType(event.GetType())
+	if !ok {
+		return nil, fmt.Errorf("%w: no protobuf mapping for event type: %s", ErrInvalidEventType, event.GetType())
+	}
+
+	out, err := appendProtoFields(nil, protoEnvelopeFields, values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMarshalFailed, err)
+	}
+	payload, err := appendProtoFields(nil, schema.fields, values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrMarshalFailed, schema.eventType, err)
+	}
+	// The payload is always written, even when empty, so the oneof is set.
+	return appendProtoBytes(out, schema.num, payload), nil
+}
+
+// DecodeEventProtoFromBytes decodes an agui.v1.Event protobuf message produced
+// by EncodeEventProto. The payload oneof must match the type discriminator, and
+// the resulting event is validated exactly as DecodeEventFromBytes would.
+func DecodeEventProtoFromBytes(data []byte) (Event, error) {
+	values := make(map[string]interface{})
+	var schema *protoEventSchema
+	var payload []byte
+
+	err := readProtoFields(data, func(num uint64, wire int, value []byte, scalar uint64) error {
+		if field, ok := protoFieldByNum(protoEnvelopeFields, num); ok {
+			return decodeProtoField(field, wire, value, scalar, values)
+		}
+		if s, ok := protoSchemaForField(num); ok && wire == protoWireBytes {
+			// As with any oneof, the last payload on the wire wins.
+			schema, payload = s, value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("%w: protobuf event has no payload", ErrInvalidStructure)
+	}
+	if eventType, _ := values["type"].(string); eventType != string(schema.eventType) {
+		return nil, fmt.Errorf("%w: protobuf payload %s does not match event type %q", ErrInvalidStructure, schema.eventType, eventType)
+	}
+
+	// Repeated fields are absent from the wire when empty, but an empty JSON
+	// array is still a value the event requires.
+	for _, field := range schema.fields {
+		if field.kind == protoRepeatedJSON {
+			values[field.name] = []json.RawMessage{}
+		}
+	}
+
+	err = readProtoFields(payload, func(num uint64, wire int, value []byte, scalar uint64) error {
+		if field, ok := protoFieldByNum(schema.fields, num); ok {
+			if err := decodeProtoField(field, wire, value, scalar, values); err != nil {
+				return fmt.Errorf("%s: %v", schema.eventType, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+	}
+	return DecodeEventFromBytes(encoded)
+}
+
+// protoSchemaForType returns the protobuf schema for an event type.
+func protoSchemaForType(eventType EventType) (*protoEventSchema, bool) {
+	for i := range protoEventSchemas {
+		if protoEventSchemas[i].eventType == eventType {
+			return &protoEventSchemas[i], true
+		}
+	}
+	return nil, false
+}
+
+// protoSchemaForField returns the protobuf schema whose oneof field number is num.
+func protoSchemaForField(num uint64) (*protoEventSchema, bool) {
+	for i := range protoEventSchemas {
+		if protoEventSchemas[i].num == num {
+			return &protoEventSchemas[i], true
+		}
+	}
+	return nil, false
+}
+
+// protoFieldByNum returns the field numbered num.
+func protoFieldByNum(fields []protoField, num uint64) (protoField, bool) {
+	for _, field := range fields {
+		if field.num == num {
+			return field, true
+		}
+	}
+	return protoField{}, false
+}
+
+// appendProtoFields appends the JSON values named by fields to b. Absent values
+// are skipped, as are empty strings and zero doubles, following proto3.
+func appendProtoFields(b []byte, fields []protoField, values map[string]json.RawMessage) ([]byte, error) {
+	for _, field := range fields {
+		value, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		var err error
+		switch field.kind {
+		case protoString:
+			var s string
+			if err = json.Unmarshal(value, &s); err == nil && s != "" {
+				b = appendProtoBytes(b, field.num, []byte(s))
+			}
+		case protoInt64:
+			var n int64
+			if err = json.Unmarshal(value, &n); err == nil {
+				b = appendProtoKey(b, field.num, protoWireVarint)
+				b = binary.AppendUvarint(b, uint64(n))
+			}
+		case protoDouble:
+			var f float64
+			if err = json.Unmarshal(value, &f); err == nil && f != 0 {
+				b = appendProtoKey(b, field.num, protoWireFixed64)
+				b = binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+			}
+		case protoJSON:
+			b = appendProtoBytes(b, field.num, value)
+		case protoRepeatedJSON:
+			var items []json.RawMessage
+			if err = json.Unmarshal(value, &items); err == nil {
+				for _, item := range items {
+					b = appendProtoBytes(b, field.num, item)
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", field.name, err)
+		}
+	}
+	return b, nil
+}
+
+// decodeProtoField stores a decoded protobuf field in values under its JSON
+// name. Repeated fields accumulate into a []json.RawMessage.
+func decodeProtoField(field protoField, wire int, value []byte, scalar uint64, values map[string]interface{}) error {
+	want := protoWireBytes
+	switch field.kind {
+	case protoInt64:
+		want = protoWireVarint
+	case protoDouble:
+		want = protoWireFixed64
+	}
+	if wire != want {
+		return fmt.Errorf("%s: unexpected wire type %d", field.name, wire)
+	}
+	if (field.kind == protoJSON || field.kind == protoRepeatedJSON) && !json.Valid(value) {
+		return fmt.Errorf("%s: invalid JSON value", field.name)
+	}
+
+	switch field.kind {
+	case protoString:
+		values[field.name] = string(value)
+	case protoInt64:
+		values[field.name] = int64(scalar)
+	case protoDouble:
+		values[field.name] = math.Float64frombits(scalar)
+	case protoJSON:
+		values[field.name] = json.RawMessage(value)
+	case protoRepeatedJSON:
+		items, _ := values[field.name].([]json.RawMessage)
+		values[field.name] = append(items, json.RawMessage(value))
+	}
+	return nil
+}
+
+// readProtoFields walks the fields of a protobuf message, calling fn with each
+// field's number and wire type. Length-delimited fields are passed as value,
+// and varint and fixed-width fields as scalar; fn skips fields it does not know.
+func readProtoFields(data []byte, fn func(num uint64, wire int, value []byte, scalar uint64) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("%w: protobuf: malformed field key", ErrUnmarshalFailed)
+		}
+		data = data[n:]
+		num, wire := key>>3, int(key&7)
+		if num == 0 {
+			return fmt.Errorf("%w: protobuf: invalid field number 0", ErrUnmarshalFailed)
+		}
+
+		var value []byte
+		var scalar uint64
+		switch wire {
+		case protoWireVarint:
+			scalar, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("%w: protobuf: malformed varint in field %d", ErrUnmarshalFailed, num)
+			}
+			data = data[n:]
+		case protoWireFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("%w: protobuf: truncated field %d", ErrUnmarshalFailed, num)
+			}
+			scalar, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoWireFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("%w: protobuf: truncated field %d", ErrUnmarshalFailed, num)
+			}
+			scalar, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoWireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return fmt.Errorf("%w: protobuf: truncated field %d", ErrUnmarshalFailed, num)
+			}
+			value, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return fmt.Errorf("%w: protobuf: unsupported wire type %d in field %d", ErrUnmarshalFailed, wire, num)
+		}
+
+		if err := fn(num, wire, value, scalar); err != nil {
+			return fmt.Errorf("%w: protobuf: %v", ErrUnmarshalFailed, err)
+		}
+	}
+	return nil
+}
+
+// appendProtoKey appends the key of field num with the given wire type.
+func appendProtoKey(b []byte, num uint64, wire int) []byte {
+	return binary.AppendUvarint(b, num<<3|uint64(wire))
+}
+
+// appendProtoBytes appends a length-delimited field.
+func appendProtoBytes(b []byte, num uint64, value []byte) []byte {
+	b = appendProtoKey(b, num, protoWireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
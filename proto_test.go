@@ -0,0 +1,152 @@
+package agui
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEventProtoRoundTrip(t *testing.T) {
+	withMeta := NewTextMessageContentEvent("msg_1", "Hello\nworld")
+	seq := int64(0)
+	withMeta.Seq = &seq
+	withMeta.RawEvent = map[string]interface{}{"id": "chunk_1"}
+	withMeta.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	events := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		withMeta,
+		NewTextMessageEndEvent("msg_1"),
+		NewToolCallStartEvent("call_1", "search", "msg_1"),
+		NewToolCallArgsEvent("call_1", `{"q":"go"}`),
+		NewToolCallEndEvent("call_1"),
+		NewToolCallResultEvent("msg_2", "call_1", "found"),
+		NewToolCallResultStartEvent("msg_2", "call_1"),
+		NewToolCallResultChunkEvent("msg_2", "call_1", "fou"),
+		NewToolCallResultEndEvent("msg_2", "call_1"),
+		NewStateSnapshotEvent(map[string]interface{}{"count": float64(1), "tags": []interface{}{"a"}}),
+		NewStateDeltaEvent([]interface{}{
+			map[string]interface{}{"op": "replace", "path": "/count", "value": float64(2)},
+			map[string]interface{}{"op": "remove", "path": "/tags/0"},
+		}),
+		NewMessagesSnapshotEvent([]Message{
+			NewUserMessage("msg_0", "Hi", ""),
+			NewAssistantMessage("msg_1", "Hello", "", nil),
+		}),
+		NewStateDeltaEvent([]interface{}{}),
+		NewMessagesSnapshotEvent([]Message{}),
+		NewRawEvent(map[string]interface{}{"kind": "upstream"}, "langchain"),
+		NewCustomEvent("progress", map[string]interface{}{"percent": float64(50)}),
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewRunFinishedEvent("thread_1", "run_1", map[string]interface{}{"ok": true}),
+		NewRunErrorEvent("boom", "E_FAIL"),
+		NewStepStartedEvent("plan"),
+		NewStepFinishedEvent("plan"),
+		NewStepProgressEvent("plan", 0.5, "halfway"),
+	}
+
+	seen := make(map[EventType]bool)
+	for _, event := range events {
+		seen[event.GetType()] = true
+
+		data, err := EncodeEventProto(event)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", event.GetType(), err)
+		}
+		decoded, err := DecodeEventProtoFromBytes(data)
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", event.GetType(), err)
+		}
+		if !reflect.DeepEqual(decoded, event) {
+			t.Errorf("%s: got %#v, want %#v", event.GetType(), decoded, event)
+		}
+	}
+	if len(seen) != len(protoEventSchemas) {
+		t.Errorf("Expected round trips for all %d event types, covered %d", len(protoEventSchemas), len(seen))
+	}
+}
+
+func TestEventProtoWireFormat(t *testing.T) {
+	data, err := EncodeEventProto(NewStepStartedEvent("plan"))
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+
+	// Field 1 (type) is a length-delimited string holding the discriminator.
+	if data[0] != 1<<3|protoWireBytes || string(data[2:2+int(data[1])]) != "STEP_STARTED" {
+		t.Errorf("Expected type field first, got % x", data)
+	}
+
+	// The StepStarted payload is oneof field 28 and ends the message.
+	var payloadNum uint64
+	err = readProtoFields(data, func(num uint64, wire int, value []byte, scalar uint64) error {
+		if num >= 10 {
+			payloadNum = num
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read fields: %v", err)
+	}
+	if payloadNum != 28 {
+		t.Errorf("Expected payload field 28, got %d", payloadNum)
+	}
+}
+
+func TestDecodeEventProtoIgnoresUnknownFields(t *testing.T) {
+	data, err := EncodeEventProto(NewRunStartedEvent("thread_1", "run_1"))
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	data = appendProtoKey(data, 99, protoWireVarint)
+	data = binary.AppendUvarint(data, 7)
+
+	event, err := DecodeEventProtoFromBytes(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if started, ok := event.(*RunStartedEvent); !ok || started.RunID != "run_1" {
+		t.Errorf("Expected RunStartedEvent run_1, got %#v", event)
+	}
+}
+
+func TestDecodeEventProtoErrors(t *testing.T) {
+	valid, err := EncodeEventProto(NewRunStartedEvent("thread_1", "run_1"))
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	mismatched := appendProtoBytes(nil, 1, []byte("RUN_ERROR"))
+	mismatched = appendProtoBytes(mismatched, 25, appendProtoBytes(nil, 1, []byte("thread_1")))
+	badJSON := appendProtoBytes(nil, 1, []byte("STATE_SNAPSHOT"))
+	badJSON = appendProtoBytes(badJSON, 20, appendProtoBytes(nil, 1, []byte("{")))
+	missingField := appendProtoBytes(nil, 1, []byte("RUN_STARTED"))
+	missingField = appendProtoBytes(missingField, 25, appendProtoBytes(nil, 2, []byte("run_1")))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"truncated", valid[:len(valid)-2], ErrUnmarshalFailed},
+		{"no payload", appendProtoBytes(nil, 1, []byte("RUN_STARTED")), ErrInvalidStructure},
+		{"payload mismatch", mismatched, ErrInvalidStructure},
+		{"invalid JSON", badJSON, ErrUnmarshalFailed},
+		{"wrong wire type", binary.AppendUvarint(appendProtoKey(nil, 1, protoWireVarint), 3), ErrUnmarshalFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeEventProtoFromBytes(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Decoded events are validated like DecodeEventFromBytes does.
+	if _, err := DecodeEventProtoFromBytes(missingField); err == nil {
+		t.Error("Expected a validation error for a RunStartedEvent without a thread ID")
+	}
+	if _, err := EncodeEventProto(&RunStartedEvent{BaseEvent: BaseEvent{Type: EventTypeRunStarted}}); !errors.Is(err, ErrValidationFailed) {
+		t.Errorf("Expected ErrValidationFailed encoding an invalid event, got %v", err)
+	}
+}
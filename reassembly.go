@@ -0,0 +1,264 @@
+package agui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pendingToolCall accumulates a streamed tool call until its end event.
+type pendingToolCall struct {
+	name      string
+	parentID  string
+	arguments strings.Builder
+}
+
+// messageAssembler turns text, tool call and tool call result lifecycles into
+// complete messages. Lifecycles with different IDs may be interleaved.
+type messageAssembler struct {
+	text      *TextMessageAssembler
+	results   *ToolResultBuilder
+	toolCalls map[string]*pendingToolCall
+}
+
+// newMessageAssembler creates an empty messageAssembler whose text messages
+// are reassembled with opts.
+func newMessageAssembler(opts ...TextMessageAssemblerOption) *messageAssembler {
+	return &messageAssembler{
+		text:      NewTextMessageAssembler(opts...),
+		results:   NewToolResultBuilder(),
+		toolCalls: make(map[string]*pendingToolCall),
+	}
+}
+
+// add feeds an event to the assembler and returns the message it completes, if any.
+func (a *messageAssembler) add(event Event) (Message, error) {
+	switch e := event.(type) {
+	case *TextMessageStartEvent, *TextMessageContentEvent, *TextMessageEndEvent:
+		return a.text.AddMessage(event)
+
+	case *ToolCallStartEvent:
+		if _, ok := a.toolCalls[e.ToolCallID]; ok {
+			return nil, fmt.Errorf("tool call %s already started", e.ToolCallID)
+		}
+		a.toolCalls[e.ToolCallID] = &pendingToolCall{name: e.ToolCallName, parentID: e.ParentMessageID}
+
+	case *ToolCallArgsEvent:
+		call, ok := a.toolCalls[e.ToolCallID]
+		if !ok {
+			return nil, fmt.Errorf("tool call args for %s without start", e.ToolCallID)
+		}
+		call.arguments.WriteString(e.Delta)
+
+	case *ToolCallEndEvent:
+		call, ok := a.toolCalls[e.ToolCallID]
+		if !ok {
+			return nil, fmt.Errorf("tool call end for %s without start", e.ToolCallID)
+		}
+		delete(a.toolCalls, e.ToolCallID)
+
+		// The call is attached to its parent message ID, or its own ID if it has no parent
+		id := call.parentID
+		if id == "" {
+			id = e.ToolCallID
+		}
+		return NewAssistantMessage(id, "", "", []ToolCall{{
+			ID:   e.ToolCallID,
+			Type: ToolCallTypeFunction,
+			Function: FunctionCall{
+				Name:      call.name,
+				Arguments: call.arguments.String(),
+			},
+		}}), nil
+
+	case *ToolCallResultEvent:
+		return NewToolMessage(e.MessageID, e.Content, e.ToolCallID, "", ""), nil
+
+	case *ToolCallResultStartEvent, *ToolCallResultChunkEvent, *ToolCallResultEndEvent:
+		message, err := a.results.Add(event)
+		if message == nil || err != nil {
+			return nil, err
+		}
+		return message, nil
+	}
+
+	return nil, nil
+}
+
+// contributesTo returns the ID of the assistant message that event adds text or
+// a tool call to, or "" if it adds to none.
+func (a *messageAssembler) contributesTo(event Event) string {
+	switch e := event.(type) {
+	case *TextMessageStartEvent:
+		return e.MessageID
+	case *TextMessageContentEvent:
+		return e.MessageID
+	case *TextMessageEndEvent:
+		return e.MessageID
+	case *ToolCallStartEvent:
+		if e.ParentMessageID != "" {
+			return e.ParentMessageID
+		}
+		return e.ToolCallID
+	case *ToolCallArgsEvent:
+		return a.toolCallMessageID(e.ToolCallID)
+	case *ToolCallEndEvent:
+		return a.toolCallMessageID(e.ToolCallID)
+	}
+	return ""
+}
+
+// toolCallMessageID returns the ID of the assistant message an open tool call
+// is attached to, or "" if the tool call is not open.
+func (a *messageAssembler) toolCallMessageID(toolCallID string) string {
+	call, ok := a.toolCalls[toolCallID]
+	if !ok {
+		return ""
+	}
+	if call.parentID != "" {
+		return call.parentID
+	}
+	return toolCallID
+}
+
+// open reports whether text or a tool call for the assistant message id is
+// still streaming.
+func (a *messageAssembler) open(id string) bool {
+	if _, ok := a.text.pending[id]; ok {
+		return true
+	}
+	for toolCallID := range a.toolCalls {
+		if a.toolCallMessageID(toolCallID) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// messageQueue holds assembled messages so that an assistant message is
+// released once, with its text and all its tool calls merged, when the stream
+// moves past it. Messages are released in the order they were first assembled.
+type messageQueue struct {
+	assembler *messageAssembler
+	queued    []Message
+}
+
+// newMessageQueue creates an empty messageQueue whose text messages are
+// reassembled with opts.
+func newMessageQueue(opts ...TextMessageAssemblerOption) *messageQueue {
+	return &messageQueue{assembler: newMessageAssembler(opts...)}
+}
+
+// add feeds event to the assembler and returns the messages it completes.
+func (q *messageQueue) add(event Event) ([]Message, error) {
+	id := q.assembler.contributesTo(event)
+	ready := q.release(id)
+
+	message, err := q.assembler.add(event)
+	if message == nil || err != nil {
+		return ready, err
+	}
+	q.enqueue(message)
+	return append(ready, q.release(id)...), nil
+}
+
+// enqueue queues message, merging an assistant message into a queued one
+// with the same ID.
+func (q *messageQueue) enqueue(message Message) {
+	if assistant, ok := message.(*AssistantMessage); ok {
+		for _, queued := range q.queued {
+			if existing, ok := queued.(*AssistantMessage); ok && existing.ID == assistant.ID {
+				if existing.Content == "" {
+					existing.Content = assistant.Content
+				}
+				existing.ToolCalls = append(existing.ToolCalls, assistant.ToolCalls...)
+				return
+			}
+		}
+	}
+	q.queued = append(q.queued, message)
+}
+
+// release removes and returns the queued messages up to the first assistant
+// message that may still grow: one named by current, the ID the event being
+// processed contributes to, or one with text or tool calls still streaming.
+func (q *messageQueue) release(current string) []Message {
+	var ready []Message
+	for len(q.queued) > 0 {
+		message := q.queued[0]
+		if message.GetRole() == RoleAssistant && (message.GetID() == current || q.assembler.open(message.GetID())) {
+			break
+		}
+		ready = append(ready, message)
+		q.queued = q.queued[1:]
+	}
+	return ready
+}
+
+// flush removes and returns all queued messages, for the end of the stream.
+func (q *messageQueue) flush() []Message {
+	ready := q.queued
+	q.queued = nil
+	return ready
+}
+
+// MessageStreamDecoder decodes a stream of events and yields complete messages
+// instead of the individual lifecycle events that make them up.
+type MessageStreamDecoder struct {
+	events *StreamDecoder
+}
+
+// NewMessageStreamDecoder creates a new MessageStreamDecoder that reads events from r.
+func NewMessageStreamDecoder(r io.Reader, opts ...DecoderOption) *MessageStreamDecoder {
+	return &MessageStreamDecoder{events: NewStreamDecoder(r, opts...)}
+}
+
+// DecodeMessages continuously decodes events from the stream until EOF or error,
+// emitting a message each time one is fully assembled:
+//   - an AssistantMessage with the streamed content and the tool calls made
+//     under its ID, once its text and tool calls have ended and the stream has
+//     moved on to an event that does not add to it. Tool calls without a parent
+//     message form an AssistantMessage with the tool call ID.
+//   - a ToolMessage on TOOL_CALL_RESULT or TOOL_CALL_RESULT_END
+//
+// Messages are emitted in the order they were first assembled, and each message
+// ID is emitted once. With WithLenientTextRoles, text messages are emitted with
+// the role declared by their start event. Other events are consumed silently. It returns a channel
+// of messages and a channel of errors.
+func (m *MessageStreamDecoder) DecodeMessages() (<-chan Message, <-chan error) {
+	messageChan := make(chan Message, 10)
+	errorChan := make(chan error, 1)
+
+	go func() {
+		defer close(messageChan)
+		defer close(errorChan)
+
+		events, errs := m.events.DecodeEvents()
+		var opts []TextMessageAssemblerOption
+		if m.events.decoder.config.lenientTextRoles {
+			opts = append(opts, WithLenientRoles())
+		}
+		queue := newMessageQueue(opts...)
+		for event := range events {
+			ready, err := queue.add(event)
+			for _, message := range ready {
+				messageChan <- message
+			}
+			if err != nil {
+				errorChan <- fmt.Errorf("%w: %v", ErrInvalidSequence, err)
+				// Drain the event stream so its goroutine can finish
+				for range events {
+				}
+				return
+			}
+		}
+		for _, message := range queue.flush() {
+			messageChan <- message
+		}
+		if err := <-errs; err != nil {
+			errorChan <- err
+		}
+	}()
+
+	return messageChan, errorChan
+}
@@ -0,0 +1,161 @@
+package agui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMessageStreamDecoder(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`,
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"assistant"}`,
+		`{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"Let me "}`,
+		`{"type":"TOOL_CALL_START","toolCallId":"tool_call_1","toolCallName":"get_weather","parentMessageId":"msg_1"}`,
+		`{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"check."}`,
+		`{"type":"TOOL_CALL_ARGS","toolCallId":"tool_call_1","delta":"{\"city\":"}`,
+		`{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}`,
+		`{"type":"TOOL_CALL_ARGS","toolCallId":"tool_call_1","delta":"\"Paris\"}"}`,
+		`{"type":"TOOL_CALL_END","toolCallId":"tool_call_1"}`,
+		`{"type":"TOOL_CALL_RESULT_START","messageId":"msg_2","toolCallId":"tool_call_1","role":"tool"}`,
+		`{"type":"TOOL_CALL_RESULT_CHUNK","messageId":"msg_2","toolCallId":"tool_call_1","delta":"Sunny"}`,
+		`{"type":"TOOL_CALL_RESULT_END","messageId":"msg_2","toolCallId":"tool_call_1"}`,
+		`{"type":"RUN_FINISHED","threadId":"thread_1","runId":"run_1"}`,
+	}, "\n")
+
+	messages, errs := NewMessageStreamDecoder(strings.NewReader(stream)).DecodeMessages()
+	var got []Message
+	for message := range messages {
+		got = append(got, message)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(got))
+	}
+
+	text, ok := got[0].(*AssistantMessage)
+	if !ok || text.ID != "msg_1" || text.Content != "Let me check." || len(text.ToolCalls) != 1 {
+		t.Fatalf("Unexpected assistant message: %+v", got[0])
+	}
+	if fn := text.ToolCalls[0].Function; fn.Name != "get_weather" || fn.Arguments != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call function: %+v", fn)
+	}
+
+	result, ok := got[1].(*ToolMessage)
+	if !ok || result.ID != "msg_2" || result.ToolCallID != "tool_call_1" || result.Content != "Sunny" {
+		t.Errorf("Unexpected tool message: %+v", got[1])
+	}
+
+	for i, message := range got {
+		if err := message.Validate(); err != nil {
+			t.Errorf("Message %d is invalid: %v", i, err)
+		}
+	}
+}
+
+func TestMessageStreamDecoderMergesToolCalls(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`,
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"assistant"}`,
+		`{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"Checking both."}`,
+		`{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}`,
+		`{"type":"TOOL_CALL_START","toolCallId":"tool_call_1","toolCallName":"get_weather","parentMessageId":"msg_1"}`,
+		`{"type":"TOOL_CALL_ARGS","toolCallId":"tool_call_1","delta":"{\"city\":\"Paris\"}"}`,
+		`{"type":"TOOL_CALL_END","toolCallId":"tool_call_1"}`,
+		`{"type":"TOOL_CALL_START","toolCallId":"tool_call_2","toolCallName":"get_time","parentMessageId":"msg_1"}`,
+		`{"type":"TOOL_CALL_END","toolCallId":"tool_call_2"}`,
+		`{"type":"TOOL_CALL_RESULT","messageId":"msg_2","toolCallId":"tool_call_1","content":"Sunny"}`,
+		`{"type":"TOOL_CALL_RESULT","messageId":"msg_3","toolCallId":"tool_call_2","content":"Noon"}`,
+		`{"type":"RUN_FINISHED","threadId":"thread_1","runId":"run_1"}`,
+	}, "\n")
+
+	messages, errs := NewMessageStreamDecoder(strings.NewReader(stream)).DecodeMessages()
+	var got []Message
+	for message := range messages {
+		got = append(got, message)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var assistants []*AssistantMessage
+	for _, message := range got {
+		if assistant, ok := message.(*AssistantMessage); ok {
+			assistants = append(assistants, assistant)
+		}
+	}
+	if len(got) != 3 || len(assistants) != 1 {
+		t.Fatalf("Expected one assistant and two tool messages, got %+v", got)
+	}
+	assistant := assistants[0]
+	if assistant != got[0] || assistant.ID != "msg_1" || assistant.Content != "Checking both." {
+		t.Errorf("Unexpected assistant message: %+v", assistant)
+	}
+	if len(assistant.ToolCalls) != 2 || assistant.ToolCalls[0].ID != "tool_call_1" || assistant.ToolCalls[1].ID != "tool_call_2" {
+		t.Errorf("Expected both tool calls on the assistant message, got %+v", assistant.ToolCalls)
+	}
+}
+
+func TestMessageStreamDecoderBrokenLifecycle(t *testing.T) {
+	stream := `{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"orphan"}
+{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`
+
+	messages, errs := NewMessageStreamDecoder(strings.NewReader(stream)).DecodeMessages()
+	for range messages {
+		t.Error("Expected no messages")
+	}
+	if err := <-errs; !errors.Is(err, ErrInvalidSequence) {
+		t.Errorf("Expected ErrInvalidSequence, got %v", err)
+	}
+}
+
+func TestMessageStreamDecoderLenientTextRoles(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"user"}`,
+		`{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_1","delta":"Hi"}`,
+		`{"type":"TEXT_MESSAGE_END","messageId":"msg_1"}`,
+		`{"type":"TEXT_MESSAGE_START","messageId":"msg_2"}`,
+		`{"type":"TEXT_MESSAGE_CONTENT","messageId":"msg_2","delta":"Hello"}`,
+		`{"type":"TEXT_MESSAGE_END","messageId":"msg_2"}`,
+	}, "\n")
+
+	if _, err := DecodeEventFromBytes([]byte(`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"user"}`)); err == nil {
+		t.Error("Expected a user text message to fail validation by default")
+	}
+	if _, err := DecodeEventFromBytes([]byte(`{"type":"TEXT_MESSAGE_START","messageId":"msg_1","role":"tool"}`), WithLenientTextRoles()); err == nil {
+		t.Error("Expected a tool text message to fail validation with WithLenientTextRoles")
+	}
+
+	messages, errs := NewMessageStreamDecoder(strings.NewReader(stream), WithLenientTextRoles()).DecodeMessages()
+	var got []Message
+	for message := range messages {
+		got = append(got, message)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(got))
+	}
+	if user, ok := got[0].(*UserMessage); !ok || user.ID != "msg_1" || user.Content != "Hi" {
+		t.Errorf("Expected user message msg_1, got %+v", got[0])
+	}
+	if assistant, ok := got[1].(*AssistantMessage); !ok || assistant.ID != "msg_2" || assistant.Content != "Hello" {
+		t.Errorf("Expected assistant message msg_2, got %+v", got[1])
+	}
+
+	events, err := NewDecoder(strings.NewReader(stream), WithLenientTextRoles()).DecodeAll()
+	if err != nil {
+		t.Fatalf("Failed to decode events: %v", err)
+	}
+	conversation, err := BuildConversation(events, WithLenientRoles())
+	if err != nil {
+		t.Fatalf("Failed to build conversation: %v", err)
+	}
+	if len(conversation) != 2 || conversation[0].GetRole() != RoleUser || conversation[1].GetRole() != RoleAssistant {
+		t.Errorf("Expected user then assistant messages, got %+v", conversation)
+	}
+}
@@ -0,0 +1,285 @@
+package agui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// activeStack holds the IDs of runs, or of their threads, that have started
+// but not ended, the most recently started last. Only run lifecycle events
+// carry these IDs, so every other event is attributed to the most recent
+// entry: runs are assumed to nest, not to interleave.
+type activeStack []string
+
+// push records the start of id.
+func (s *activeStack) push(id string) {
+	*s = append(*s, id)
+}
+
+// current returns the most recently started ID that is still active, or "".
+func (s activeStack) current() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
+
+// end removes the most recent entry for id, if there is one.
+func (s *activeStack) end(id string) {
+	for i := len(*s) - 1; i >= 0; i-- {
+		if (*s)[i] == id {
+			*s = append((*s)[:i], (*s)[i+1:]...)
+			return
+		}
+	}
+}
+
+// PartitionByThread groups events by the thread they belong to, preserving
+// their order within each thread. Since most events carry no thread ID, each
+// event is assigned to the active thread as tracked from lifecycle events:
+// RUN_STARTED makes its thread active, and RUN_FINISHED or RUN_ERROR ends it,
+// making the previously active thread current again. Runs are therefore
+// assumed to nest rather than run concurrently; events of interleaved runs on
+// different threads go to the thread of the most recently started run. Events
+// seen while no run is active are grouped under the empty key.
+func PartitionByThread(events []Event) map[string][]Event {
+	partitions := make(map[string][]Event)
+	var active activeStack // threads with an active run
+
+	for _, event := range events {
+		switch e := event.(type) {
+		case *RunStartedEvent:
+			active.push(e.ThreadID)
+			partitions[e.ThreadID] = append(partitions[e.ThreadID], event)
+		case *RunFinishedEvent:
+			partitions[e.ThreadID] = append(partitions[e.ThreadID], event)
+			active.end(e.ThreadID)
+		case *RunErrorEvent:
+			threadID := active.current()
+			partitions[threadID] = append(partitions[threadID], event)
+			active.end(threadID)
+		default:
+			threadID := active.current()
+			partitions[threadID] = append(partitions[threadID], event)
+		}
+	}
+
+	return partitions
+}
+
+// SplitRuns groups events by the run they belong to, preserving their order
+// within each run. Each event is assigned to the most recently started run that
+// is still active, tracked from lifecycle events as in PartitionByThread, so
+// events of a nested run are not repeated under the enclosing run. As there,
+// runs are assumed to nest: events carry no run or thread ID to separate runs
+// that interleave, even on different threads. Events seen while no run is
+// active are grouped under the empty key.
+func SplitRuns(events []Event) map[string][]Event {
+	runs := make(map[string][]Event)
+	var active activeStack // active run IDs
+
+	for _, event := range events {
+		switch e := event.(type) {
+		case *RunStartedEvent:
+			active.push(e.RunID)
+			runs[e.RunID] = append(runs[e.RunID], event)
+		case *RunFinishedEvent:
+			runs[e.RunID] = append(runs[e.RunID], event)
+			active.end(e.RunID)
+		case *RunErrorEvent:
+			runID := active.current()
+			runs[runID] = append(runs[runID], event)
+			active.end(runID)
+		default:
+			runID := active.current()
+			runs[runID] = append(runs[runID], event)
+		}
+	}
+
+	return runs
+}
+
+// WriteRunFiles splits events with SplitRuns and writes the events of each run
+// to "<run ID>.ndjson" in dir, one encoded event per line, replacing existing
+// files. Events outside any run are not written. It returns an error if a run
+// ID cannot be used as a file name or an event fails to encode; files for runs
+// sorting before the failing one have already been written by then.
+func WriteRunFiles(dir string, events []Event) error {
+	runs := SplitRuns(events)
+	runIDs := make([]string, 0, len(runs))
+	for runID := range runs {
+		if runID != "" {
+			runIDs = append(runIDs, runID)
+		}
+	}
+	sort.Strings(runIDs)
+
+	for _, runID := range runIDs {
+		if runID == "." || runID == ".." || strings.ContainsAny(runID, `/\`) {
+			return fmt.Errorf("agui: run ID %q cannot be used as a file name", runID)
+		}
+		var buf bytes.Buffer
+		encoder := NewEncoder(&buf, WithDelimiter([]byte("\n")))
+		for _, event := range runs[runID] {
+			if err := encoder.Encode(event); err != nil {
+				return fmt.Errorf("run %s: %w", runID, err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, runID+".ndjson"), buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("agui: failed to write run file: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunNode is a run in a tree of runs built by BuildRunTree.
+type RunNode struct {
+	RunID    string     // ID of the agent run, empty for the synthetic root
+	ThreadID string     // ID of the conversation thread, empty for the synthetic root
+	Children []*RunNode // Runs started with this run as their parent
+	Events   []Event    // Events of this run, in order, excluding those of child runs
+}
+
+// BuildRunTree groups events by run and nests runs by the ParentRunID of their
+// RunStartedEvent. It returns a synthetic root node, with empty IDs, whose
+// children are the runs without a parent, those whose parent run is not in
+// events, and those whose chain of parents leads back to themselves, so every
+// run is reachable from the root.
+// Each event is assigned to the innermost active run, tracked from lifecycle
+// events as in PartitionByThread; events outside any run belong to the root.
+func BuildRunTree(events []Event) *RunNode {
+	root := &RunNode{}
+	nodes := make(map[string]*RunNode)
+	var order []*RunNode
+	parents := make(map[*RunNode]string)
+	var active activeStack // active run IDs
+
+	// node returns the node of runID, or the root outside any known run.
+	node := func(runID string) *RunNode {
+		if n, ok := nodes[runID]; ok {
+			return n
+		}
+		return root
+	}
+
+	for _, event := range events {
+		switch e := event.(type) {
+		case *RunStartedEvent:
+			n, ok := nodes[e.RunID]
+			if !ok {
+				n = &RunNode{RunID: e.RunID, ThreadID: e.ThreadID}
+				nodes[e.RunID] = n
+				order = append(order, n)
+				parents[n] = e.ParentRunID
+			}
+			n.Events = append(n.Events, event)
+			active.push(e.RunID)
+		case *RunFinishedEvent:
+			runID := e.RunID
+			if _, ok := nodes[runID]; !ok {
+				runID = active.current()
+			}
+			n := node(runID)
+			n.Events = append(n.Events, event)
+			active.end(runID)
+		case *RunErrorEvent:
+			runID := active.current()
+			n := node(runID)
+			n.Events = append(n.Events, event)
+			active.end(runID)
+		default:
+			n := node(active.current())
+			n.Events = append(n.Events, event)
+		}
+	}
+
+	for _, node := range order {
+		parent, ok := nodes[parents[node]]
+		if !ok || inParentCycle(node, nodes, parents) {
+			parent = root
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	return root
+}
+
+// inParentCycle reports whether following parent run IDs from node leads back
+// to node.
+func inParentCycle(node *RunNode, nodes map[string]*RunNode, parents map[*RunNode]string) bool {
+	parent := nodes[parents[node]]
+	for steps := 0; parent != nil && steps < len(nodes); steps++ {
+		if parent == node {
+			return true
+		}
+		parent = nodes[parents[parent]]
+	}
+	return false
+}
+
+// RunDuration returns the time between the RUN_STARTED event of the first run in
+// events and that run's terminal event, a RUN_FINISHED with the same run ID or a
+// RUN_ERROR raised while the run is active, as assigned by BuildRunTree. It
+// reports false if the run has no terminal event or either event has no timestamp.
+func RunDuration(events []Event) (time.Duration, bool) {
+	var started *RunStartedEvent
+	for _, event := range events {
+		if e, ok := event.(*RunStartedEvent); ok {
+			started = e
+			break
+		}
+	}
+	if started == nil {
+		return 0, false
+	}
+
+	run := findRunNode(BuildRunTree(events), started.RunID)
+	if run == nil {
+		return 0, false
+	}
+	var terminal Event
+	for _, event := range run.Events {
+		switch e := event.(type) {
+		case *RunFinishedEvent:
+			if e.RunID == started.RunID {
+				terminal = event
+			}
+		case *RunErrorEvent:
+			terminal = event
+		}
+		if terminal != nil {
+			break
+		}
+	}
+	if terminal == nil {
+		return 0, false
+	}
+
+	start, ok := started.Time()
+	if !ok {
+		return 0, false
+	}
+	end, ok := terminal.Time()
+	if !ok {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
+
+// findRunNode returns the node for runID in the tree rooted at node, or nil.
+func findRunNode(node *RunNode, runID string) *RunNode {
+	if node.RunID == runID {
+		return node
+	}
+	for _, child := range node.Children {
+		if found := findRunNode(child, runID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package agui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPartitionByThread(t *testing.T) {
+	early := NewCustomEvent("boot", true)
+	startA := NewRunStartedEvent("thread_a", "run_1")
+	msgA := NewTextMessageStartEvent("msg_a")
+	startB := NewRunStartedEvent("thread_b", "run_2")
+	msgB := NewTextMessageStartEvent("msg_b")
+	endB := NewTextMessageEndEvent("msg_b")
+	finishB := NewRunFinishedEvent("thread_b", "run_2", nil)
+	endA := NewTextMessageEndEvent("msg_a")
+	finishA := NewRunFinishedEvent("thread_a", "run_1", nil)
+	startC := NewRunStartedEvent("thread_c", "run_3")
+	errC := NewRunErrorEvent("boom", "")
+	late := NewCustomEvent("shutdown", true)
+
+	events := []Event{early, startA, msgA, startB, msgB, endB, finishB, endA, finishA, startC, errC, late}
+
+	expected := map[string][]Event{
+		"":         {early, late},
+		"thread_a": {startA, msgA, endA, finishA},
+		"thread_b": {startB, msgB, endB, finishB},
+		"thread_c": {startC, errC},
+	}
+
+	if got := PartitionByThread(events); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Partition mismatch:\nexpected %v\ngot      %v", expected, got)
+	}
+}
+
+func TestBuildRunTree(t *testing.T) {
+	childA := NewRunStartedEvent("thread_1", "run_child_a")
+	childA.ParentRunID = "run_parent"
+	childB := NewRunStartedEvent("thread_1", "run_child_b")
+	childB.ParentRunID = "run_parent"
+
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_parent"),
+		NewTextMessageStartEvent("msg_1"),
+		childA,
+		NewToolCallStartEvent("tool_call_1", "search", ""),
+		NewRunFinishedEvent("thread_1", "run_child_a", nil),
+		childB,
+		NewRunErrorEvent("failed", ""),
+		NewTextMessageEndEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_parent", nil),
+		NewCustomEvent("after", true),
+	}
+
+	root := BuildRunTree(events)
+
+	if root.RunID != "" || len(root.Children) != 1 {
+		t.Fatalf("Expected a synthetic root with one child, got %+v", root)
+	}
+	if len(root.Events) != 1 || root.Events[0] != events[9] {
+		t.Errorf("Expected the event outside any run under the root, got %v", root.Events)
+	}
+
+	parent := root.Children[0]
+	if parent.RunID != "run_parent" || parent.ThreadID != "thread_1" {
+		t.Errorf("Unexpected parent node: %+v", parent)
+	}
+	if len(parent.Events) != 4 {
+		t.Errorf("Expected 4 parent events, got %d", len(parent.Events))
+	}
+	if len(parent.Children) != 2 {
+		t.Fatalf("Expected 2 child runs, got %d", len(parent.Children))
+	}
+
+	a, b := parent.Children[0], parent.Children[1]
+	if a.RunID != "run_child_a" || len(a.Events) != 3 {
+		t.Errorf("Unexpected first child: %s with %d events", a.RunID, len(a.Events))
+	}
+	if b.RunID != "run_child_b" || len(b.Events) != 2 {
+		t.Errorf("Unexpected second child: %s with %d events", b.RunID, len(b.Events))
+	}
+}
+
+func TestBuildRunTreeOrphan(t *testing.T) {
+	orphan := NewRunStartedEvent("thread_1", "run_orphan")
+	orphan.ParentRunID = "run_missing"
+
+	root := BuildRunTree([]Event{orphan, NewRunFinishedEvent("thread_1", "run_orphan", nil)})
+	if len(root.Children) != 1 || root.Children[0].RunID != "run_orphan" {
+		t.Errorf("Expected the orphan run to attach to the root, got %+v", root.Children)
+	}
+}
+
+func TestBuildRunTreeParentCycle(t *testing.T) {
+	a := NewRunStartedEvent("thread_1", "run_a")
+	a.ParentRunID = "run_b"
+	b := NewRunStartedEvent("thread_1", "run_b")
+	b.ParentRunID = "run_a"
+	self := NewRunStartedEvent("thread_1", "run_self")
+	self.ParentRunID = "run_self"
+	child := NewRunStartedEvent("thread_1", "run_child")
+	child.ParentRunID = "run_a"
+
+	root := BuildRunTree([]Event{
+		a, NewRunFinishedEvent("thread_1", "run_a", nil),
+		b, NewRunFinishedEvent("thread_1", "run_b", nil),
+		self, NewRunFinishedEvent("thread_1", "run_self", nil),
+		child, NewRunFinishedEvent("thread_1", "run_child", nil),
+	})
+
+	var ids []string
+	for _, node := range root.Children {
+		ids = append(ids, node.RunID)
+	}
+	if strings.Join(ids, ",") != "run_a,run_b,run_self" {
+		t.Fatalf("Expected the cyclic runs under the root, got %v", ids)
+	}
+	for _, node := range root.Children {
+		if len(node.Events) != 2 {
+			t.Errorf("Expected 2 events for %s, got %d", node.RunID, len(node.Events))
+		}
+	}
+	if a := root.Children[0]; len(a.Children) != 1 || a.Children[0].RunID != "run_child" {
+		t.Errorf("Expected run_child under run_a, got %+v", a.Children)
+	}
+}
+
+func TestRunDuration(t *testing.T) {
+	at := func(event Event, ms int64) Event {
+		event.(interface{ base() *BaseEvent }).base().Timestamp = &ms
+		return event
+	}
+
+	single := []Event{
+		at(NewRunStartedEvent("thread_1", "run_1"), 1000),
+		at(NewTextMessageStartEvent("msg_1"), 1200),
+		at(NewRunFinishedEvent("thread_1", "run_1", nil), 3500),
+	}
+	if d, ok := RunDuration(single); !ok || d != 2500*time.Millisecond {
+		t.Errorf("Expected 2.5s, got %v (ok=%v)", d, ok)
+	}
+
+	// The first run is measured, ignoring the terminal events of others
+	multiple := []Event{
+		at(NewRunStartedEvent("thread_1", "run_1"), 1000),
+		at(NewRunStartedEvent("thread_1", "run_2"), 1100),
+		at(NewRunFinishedEvent("thread_1", "run_2", nil), 1200),
+		at(NewRunErrorEvent("boom", ""), 4000),
+	}
+	if d, ok := RunDuration(multiple); !ok || d != 3*time.Second {
+		t.Errorf("Expected 3s, got %v (ok=%v)", d, ok)
+	}
+
+	truncated := []Event{
+		at(NewRunStartedEvent("thread_1", "run_1"), 1000),
+		at(NewTextMessageStartEvent("msg_1"), 1200),
+		at(NewRunFinishedEvent("thread_1", "run_other", nil), 1300),
+	}
+	if _, ok := RunDuration(truncated); ok {
+		t.Error("Expected no duration for a run without a terminal event")
+	}
+
+	untimed := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		&RunFinishedEvent{BaseEvent: BaseEvent{Type: EventTypeRunFinished}, ThreadID: "thread_1", RunID: "run_1"},
+	}
+	if _, ok := RunDuration(untimed); ok {
+		t.Error("Expected no duration when a timestamp is missing")
+	}
+}
+
+func TestSplitRuns(t *testing.T) {
+	early := NewCustomEvent("boot", true)
+	start1 := NewRunStartedEvent("thread_1", "run_1")
+	msg1 := NewTextMessageStartEvent("msg_1")
+	start2 := NewRunStartedEvent("thread_1", "run_2")
+	msg2 := NewTextMessageStartEvent("msg_2")
+	finish2 := NewRunFinishedEvent("thread_1", "run_2", nil)
+	end1 := NewTextMessageEndEvent("msg_1")
+	finish1 := NewRunFinishedEvent("thread_1", "run_1", nil)
+
+	runs := SplitRuns([]Event{early, start1, msg1, start2, msg2, finish2, end1, finish1})
+	want := map[string][]Event{
+		"":      {early},
+		"run_1": {start1, msg1, end1, finish1},
+		"run_2": {start2, msg2, finish2},
+	}
+	if !reflect.DeepEqual(runs, want) {
+		t.Errorf("SplitRuns = %v, want %v", runs, want)
+	}
+}
+
+func TestWriteRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	events := []Event{
+		NewCustomEvent("boot", true),
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageEndEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+		NewRunStartedEvent("thread_2", "run_2"),
+		NewRunErrorEvent("boom", ""),
+	}
+	if err := WriteRunFiles(dir, events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"run_1.ndjson", "run_2.ndjson"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Files = %v, want %v", names, want)
+	}
+
+	for file, want := range map[string][]Event{"run_1.ndjson": events[1:5], "run_2.ndjson": events[5:]} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		got, err := NewDecoder(bytes.NewReader(data)).DecodeAll()
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", file, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", file, got, want)
+		}
+		if lines := strings.Count(string(data), "\n"); lines != len(want) {
+			t.Errorf("%s: expected %d lines, got %d", file, len(want), lines)
+		}
+	}
+
+	unsafe := []Event{NewRunStartedEvent("thread_1", "../escape")}
+	if err := WriteRunFiles(dir, unsafe); err == nil {
+		t.Error("Expected an error for a run ID that is not a file name")
+	}
+}
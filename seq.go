@@ -0,0 +1,74 @@
+package agui
+
+// SequencingEncoder wraps an Encoder and stamps each encoded event with an
+// incrementing sequence number in its "seq" field, starting at 1, so consumers
+// can detect events lost in transport. The caller's events are not modified.
+type SequencingEncoder struct {
+	encoder *Encoder
+	next    int64
+}
+
+// NewSequencingEncoder creates a new SequencingEncoder that writes through encoder.
+func NewSequencingEncoder(encoder *Encoder) *SequencingEncoder {
+	return &SequencingEncoder{encoder: encoder, next: 1}
+}
+
+// Encode stamps a copy of event with the next sequence number and encodes it.
+// The sequence number is only consumed if encoding succeeds.
+func (s *SequencingEncoder) Encode(event Event) error {
+	seq := s.next
+	stamped := withBase(event, func(b *BaseEvent) { b.Seq = &seq })
+	if err := s.encoder.Encode(stamped); err != nil {
+		return err
+	}
+	s.next++
+	return nil
+}
+
+// SequenceGap describes sequence numbers missing between two received events.
+type SequenceGap struct {
+	After  int64 // Last sequence number received before the gap
+	Before int64 // First sequence number received after the gap
+}
+
+// Missing returns the number of events lost in the gap.
+func (g SequenceGap) Missing() int64 {
+	return g.Before - g.After - 1
+}
+
+// SequenceGapDetector tracks the sequence numbers of received events and
+// reports gaps. Events without a sequence number are ignored.
+type SequenceGapDetector struct {
+	last *int64
+}
+
+// Observe records event and returns the gap preceding it, or nil if its
+// sequence number directly follows the previous one.
+func (d *SequenceGapDetector) Observe(event Event) *SequenceGap {
+	sequenced, ok := event.(interface{ GetSeq() *int64 })
+	if !ok || sequenced.GetSeq() == nil {
+		return nil
+	}
+	seq := *sequenced.GetSeq()
+
+	var gap *SequenceGap
+	if d.last != nil && seq > *d.last+1 {
+		gap = &SequenceGap{After: *d.last, Before: seq}
+	}
+	if d.last == nil || seq > *d.last {
+		d.last = &seq
+	}
+	return gap
+}
+
+// FindSequenceGaps returns every gap in the sequence numbers of events.
+func FindSequenceGaps(events []Event) []SequenceGap {
+	var detector SequenceGapDetector
+	var gaps []SequenceGap
+	for _, event := range events {
+		if gap := detector.Observe(event); gap != nil {
+			gaps = append(gaps, *gap)
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,98 @@
+package agui
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSequencingEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewSequencingEncoder(NewEncoder(&buf, WithDelimiter([]byte("\n"))))
+
+	original := NewTextMessageStartEvent("msg_1")
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		original,
+		NewTextMessageEndEvent("msg_1"),
+	}
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+	if original.Seq != nil {
+		t.Error("Expected the caller's event to be left unmodified")
+	}
+
+	decoder := NewDecoder(&buf)
+	var decoded []Event
+	for {
+		event, err := decoder.DecodeEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		decoded = append(decoded, event)
+	}
+
+	for i, event := range decoded {
+		seq := event.(interface{ GetSeq() *int64 }).GetSeq()
+		if seq == nil || *seq != int64(i+1) {
+			t.Errorf("Event %d: expected seq %d, got %v", i, i+1, seq)
+		}
+	}
+	if gaps := FindSequenceGaps(decoded); len(gaps) != 0 {
+		t.Errorf("Expected no gaps, got %v", gaps)
+	}
+
+	// Default encoders add no seq
+	buf.Reset()
+	if err := NewEncoder(&buf).Encode(NewTextMessageEndEvent("msg_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if strings.Contains(buf.String(), "seq") {
+		t.Errorf("Expected no seq field, got %s", buf.String())
+	}
+}
+
+func TestSequencingEncoderKeepsPayload(t *testing.T) {
+	type state struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := NewSequencingEncoder(NewEncoder(&buf)).Encode(NewStateSnapshotEvent(state{UpdatedAt: at})); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"snapshot":{"updatedAt":"2024-01-02T03:04:05Z"}`) {
+		t.Errorf("Expected the snapshot time to be encoded unchanged, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"seq":1`) {
+		t.Errorf("Expected seq 1, got %s", buf.String())
+	}
+}
+
+func TestFindSequenceGaps(t *testing.T) {
+	var events []Event
+	for _, seq := range []int64{1, 2, 4, 5, 8} {
+		event := NewCustomEvent("tick", seq)
+		event.SetSeq(seq)
+		events = append(events, event)
+	}
+
+	expected := []SequenceGap{{After: 2, Before: 4}, {After: 5, Before: 8}}
+	gaps := FindSequenceGaps(events)
+	if !reflect.DeepEqual(gaps, expected) {
+		t.Errorf("Expected gaps %v, got %v", expected, gaps)
+	}
+	if gaps[1].Missing() != 2 {
+		t.Errorf("Expected 2 missing events, got %d", gaps[1].Missing())
+	}
+}
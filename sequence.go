@@ -0,0 +1,261 @@
+package agui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SequenceValidator checks that a stream of events follows the ordering rules of
+// the AG-UI protocol. Events are fed one at a time with Validate, so it can be
+// used on live streams as well as on recorded ones.
+//
+// The validator enforces that:
+//
+//   - runs are not nested, and RUN_FINISHED matches the thread and run IDs of
+//     RUN_STARTED and leaves no text messages, tool calls or steps open
+//   - no events other than RUN_STARTED and RUN_ERROR follow a finished run
+//   - text message content and end events refer to an open text message
+//   - tool call args and end events refer to an open tool call, and a tool
+//     call's ParentMessageID, when set, refers to a known text message
+//   - tool call results refer to a started tool call
+//   - step progress and finished events refer to an open step
+//
+// Events seen before any RUN_STARTED are accepted, so partial streams can be checked.
+//
+// In repair mode, enabled with WithRepair, a text message content or end event
+// without a start is accepted and a TextMessageStartEvent is synthesized for it.
+type SequenceValidator struct {
+	repair   bool
+	repaired []Event
+
+	runActive   bool
+	runFinished bool
+	threadID    string
+	runID       string
+
+	openMessages   map[string]bool
+	knownMessages  map[string]bool
+	openToolCalls  map[string]bool
+	knownToolCalls map[string]bool
+	openResults    map[string]bool
+	openSteps      map[string]bool
+}
+
+// SequenceValidatorOption configures a SequenceValidator.
+type SequenceValidatorOption func(*SequenceValidator)
+
+// WithRepair makes the SequenceValidator synthesize a missing TextMessageStartEvent
+// for orphan text message content and end events instead of rejecting them.
+// The synthesized events are available from Repaired.
+func WithRepair() SequenceValidatorOption {
+	return func(v *SequenceValidator) {
+		v.repair = true
+	}
+}
+
+// NewSequenceValidator creates a new SequenceValidator with no events seen.
+func NewSequenceValidator(opts ...SequenceValidatorOption) *SequenceValidator {
+	v := &SequenceValidator{
+		openMessages:   make(map[string]bool),
+		knownMessages:  make(map[string]bool),
+		openToolCalls:  make(map[string]bool),
+		knownToolCalls: make(map[string]bool),
+		openResults:    make(map[string]bool),
+		openSteps:      make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Repaired returns the events synthesized in repair mode, in the order they were created.
+func (v *SequenceValidator) Repaired() []Event {
+	return v.repaired
+}
+
+// repairTextMessageStart synthesizes the missing start of the text message
+// referenced by orphan, timestamped like the orphan event.
+func (v *SequenceValidator) repairTextMessageStart(messageID string, orphan *BaseEvent) {
+	start := &TextMessageStartEvent{
+		BaseEvent: BaseEvent{
+			Type:      EventTypeTextMessageStart,
+			Timestamp: orphan.Timestamp,
+		},
+		MessageID: messageID,
+		Role:      RoleAssistant,
+	}
+	v.repaired = append(v.repaired, start)
+	v.openMessages[messageID] = true
+	v.knownMessages[messageID] = true
+}
+
+// Validate checks event on its own and against the events seen so far, and
+// records its effect on the sequence state. An event that fails validation
+// does not change the state.
+func (v *SequenceValidator) Validate(event Event) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+
+	if v.runFinished {
+		switch event.GetType() {
+		case EventTypeRunStarted, EventTypeRunError:
+		default:
+			return fmt.Errorf("%w: %s after run %s finished", ErrInvalidSequence, event.GetType(), v.runID)
+		}
+	}
+
+	switch e := event.(type) {
+	case *RunStartedEvent:
+		if v.runActive {
+			return fmt.Errorf("%w: run %s started while run %s is active", ErrInvalidSequence, e.RunID, v.runID)
+		}
+		v.runActive = true
+		v.runFinished = false
+		v.threadID = e.ThreadID
+		v.runID = e.RunID
+
+	case *RunFinishedEvent:
+		if !v.runActive {
+			return fmt.Errorf("%w: run %s finished without start", ErrInvalidSequence, e.RunID)
+		}
+		if e.ThreadID != v.threadID || e.RunID != v.runID {
+			return fmt.Errorf("%w: run finished for %s/%s, expected %s/%s", ErrInvalidSequence, e.ThreadID, e.RunID, v.threadID, v.runID)
+		}
+		if err := v.checkNothingOpen(); err != nil {
+			return err
+		}
+		v.runActive = false
+		v.runFinished = true
+
+	case *RunErrorEvent:
+		// A failed run abandons whatever it left open.
+		v.runActive = false
+		v.runFinished = true
+		v.openMessages = make(map[string]bool)
+		v.openToolCalls = make(map[string]bool)
+		v.openResults = make(map[string]bool)
+		v.openSteps = make(map[string]bool)
+
+	case *StepStartedEvent:
+		if v.openSteps[e.StepName] {
+			return fmt.Errorf("%w: step %s already started", ErrInvalidSequence, e.StepName)
+		}
+		v.openSteps[e.StepName] = true
+
+	case *StepProgressEvent:
+		if !v.openSteps[e.StepName] {
+			return fmt.Errorf("%w: step progress for %s without start", ErrInvalidSequence, e.StepName)
+		}
+
+	case *StepFinishedEvent:
+		if !v.openSteps[e.StepName] {
+			return fmt.Errorf("%w: step %s finished without start", ErrInvalidSequence, e.StepName)
+		}
+		delete(v.openSteps, e.StepName)
+
+	case *TextMessageStartEvent:
+		if v.openMessages[e.MessageID] {
+			return fmt.Errorf("%w: text message %s already started", ErrInvalidSequence, e.MessageID)
+		}
+		v.openMessages[e.MessageID] = true
+		v.knownMessages[e.MessageID] = true
+
+	case *TextMessageContentEvent:
+		if !v.openMessages[e.MessageID] && v.repair {
+			v.repairTextMessageStart(e.MessageID, &e.BaseEvent)
+		}
+		if !v.openMessages[e.MessageID] {
+			return fmt.Errorf("%w: text message content for %s without start", ErrInvalidSequence, e.MessageID)
+		}
+
+	case *TextMessageEndEvent:
+		if !v.openMessages[e.MessageID] && v.repair {
+			v.repairTextMessageStart(e.MessageID, &e.BaseEvent)
+		}
+		if !v.openMessages[e.MessageID] {
+			return fmt.Errorf("%w: text message end for %s without start", ErrInvalidSequence, e.MessageID)
+		}
+		delete(v.openMessages, e.MessageID)
+
+	case *ToolCallStartEvent:
+		if v.openToolCalls[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call %s already started", ErrInvalidSequence, e.ToolCallID)
+		}
+		// A tool call may be made while its parent text message is still
+		// streaming, so open and ended messages are both valid parents.
+		if e.ParentMessageID != "" && !v.knownMessages[e.ParentMessageID] {
+			return fmt.Errorf("%w: tool call %s has unknown parent message %s", ErrInvalidSequence, e.ToolCallID, e.ParentMessageID)
+		}
+		v.openToolCalls[e.ToolCallID] = true
+		v.knownToolCalls[e.ToolCallID] = true
+
+	case *ToolCallArgsEvent:
+		if !v.openToolCalls[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call args for %s without start", ErrInvalidSequence, e.ToolCallID)
+		}
+
+	case *ToolCallEndEvent:
+		if !v.openToolCalls[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call end for %s without start", ErrInvalidSequence, e.ToolCallID)
+		}
+		delete(v.openToolCalls, e.ToolCallID)
+
+	case *ToolCallResultEvent:
+		if !v.knownToolCalls[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call result for unknown tool call %s", ErrInvalidSequence, e.ToolCallID)
+		}
+
+	case *ToolCallResultStartEvent:
+		if !v.knownToolCalls[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call result for unknown tool call %s", ErrInvalidSequence, e.ToolCallID)
+		}
+		if v.openResults[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call result for %s already started", ErrInvalidSequence, e.ToolCallID)
+		}
+		v.openResults[e.ToolCallID] = true
+
+	case *ToolCallResultChunkEvent:
+		if !v.openResults[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call result chunk for %s without start", ErrInvalidSequence, e.ToolCallID)
+		}
+
+	case *ToolCallResultEndEvent:
+		if !v.openResults[e.ToolCallID] {
+			return fmt.Errorf("%w: tool call result end for %s without start", ErrInvalidSequence, e.ToolCallID)
+		}
+		delete(v.openResults, e.ToolCallID)
+	}
+
+	return nil
+}
+
+// checkNothingOpen returns an error if any text message, tool call, tool call
+// result or step is still open, listing the open items in sorted order.
+func (v *SequenceValidator) checkNothingOpen() error {
+	if len(v.openMessages) > 0 {
+		return fmt.Errorf("%w: run finished with open text message(s) %s", ErrInvalidSequence, sortedIDs(v.openMessages))
+	}
+	if len(v.openToolCalls) > 0 {
+		return fmt.Errorf("%w: run finished with open tool call(s) %s", ErrInvalidSequence, sortedIDs(v.openToolCalls))
+	}
+	if len(v.openResults) > 0 {
+		return fmt.Errorf("%w: run finished with open tool call result(s) %s", ErrInvalidSequence, sortedIDs(v.openResults))
+	}
+	if len(v.openSteps) > 0 {
+		return fmt.Errorf("%w: run finished with open step(s) %s", ErrInvalidSequence, sortedIDs(v.openSteps))
+	}
+	return nil
+}
+
+// sortedIDs returns the keys of set sorted and joined with commas.
+func sortedIDs(set map[string]bool) string {
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ", ")
+}
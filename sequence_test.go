@@ -0,0 +1,183 @@
+package agui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validateSequence(events []Event) error {
+	validator := NewSequenceValidator()
+	for _, event := range events {
+		if err := validator.Validate(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSequenceValidatorInterleavedTextAndToolCalls(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewStepStartedEvent("answer"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Let me look that up. "),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+		NewToolCallArgsEvent("tool_call_1", `{"query":"weather"}`),
+		NewTextMessageContentEvent("msg_1", "One moment..."),
+		NewToolCallEndEvent("tool_call_1"),
+		NewTextMessageEndEvent("msg_1"),
+		NewToolCallStartEvent("tool_call_2", "lookup", "msg_1"),
+		NewToolCallEndEvent("tool_call_2"),
+		NewStepProgressEvent("answer", 0.5, ""),
+		NewToolCallResultEvent("msg_2", "tool_call_1", "Sunny"),
+		NewStepFinishedEvent("answer"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	if err := validateSequence(events); err != nil {
+		t.Errorf("Unexpected sequence error: %v", err)
+	}
+}
+
+func TestSequenceValidatorErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+	}{
+		{
+			name: "ToolCallUnknownParent",
+			events: []Event{
+				NewTextMessageStartEvent("msg_1"),
+				NewToolCallStartEvent("tool_call_1", "search", "msg_unknown"),
+			},
+		},
+		{
+			name: "ContentWithoutStart",
+			events: []Event{
+				NewTextMessageContentEvent("msg_1", "Hello"),
+			},
+		},
+		{
+			name: "ArgsAfterToolCallEnd",
+			events: []Event{
+				NewToolCallStartEvent("tool_call_1", "search", ""),
+				NewToolCallEndEvent("tool_call_1"),
+				NewToolCallArgsEvent("tool_call_1", "{}"),
+			},
+		},
+		{
+			name: "StepProgressWithoutStart",
+			events: []Event{
+				NewStepProgressEvent("step", 0.1, ""),
+			},
+		},
+		{
+			name: "RunFinishedMismatch",
+			events: []Event{
+				NewRunStartedEvent("thread_1", "run_1"),
+				NewRunFinishedEvent("thread_1", "run_2", nil),
+			},
+		},
+		{
+			name: "RunFinishedWithOpenMessage",
+			events: []Event{
+				NewRunStartedEvent("thread_1", "run_1"),
+				NewTextMessageStartEvent("msg_1"),
+				NewRunFinishedEvent("thread_1", "run_1", nil),
+			},
+		},
+		{
+			name: "EventAfterRunFinished",
+			events: []Event{
+				NewRunStartedEvent("thread_1", "run_1"),
+				NewRunFinishedEvent("thread_1", "run_1", nil),
+				NewTextMessageStartEvent("msg_1"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSequence(tt.events)
+			if !errors.Is(err, ErrInvalidSequence) {
+				t.Errorf("Expected ErrInvalidSequence, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestSequenceValidatorRunErrorClearsOpenItems(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewStepStartedEvent("answer"),
+		NewTextMessageStartEvent("msg_1"),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+		NewToolCallResultStartEvent("msg_2", "tool_call_1"),
+		NewRunErrorEvent("boom", ""),
+		NewRunStartedEvent("thread_1", "run_2"),
+		NewRunFinishedEvent("thread_1", "run_2", nil),
+	}
+
+	if err := validateSequence(events); err != nil {
+		t.Errorf("Unexpected sequence error: %v", err)
+	}
+}
+
+func TestSequenceValidatorOpenItemsSorted(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_c"),
+		NewTextMessageStartEvent("msg_a"),
+		NewTextMessageStartEvent("msg_b"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	for i := 0; i < 10; i++ {
+		err := validateSequence(events)
+		if err == nil || !strings.Contains(err.Error(), "open text message(s) msg_a, msg_b, msg_c") {
+			t.Fatalf("Expected sorted open messages, got: %v", err)
+		}
+	}
+}
+
+func TestSequenceValidatorRepair(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageContentEvent("msg_1", "Hello"), // start was dropped
+		NewTextMessageEndEvent("msg_1"),
+		NewTextMessageEndEvent("msg_2"), // start and content were dropped
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	if err := validateSequence(events); !errors.Is(err, ErrInvalidSequence) {
+		t.Fatalf("Expected strict mode to reject the orphan content, got: %v", err)
+	}
+
+	validator := NewSequenceValidator(WithRepair())
+	for i, event := range events {
+		if err := validator.Validate(event); err != nil {
+			t.Fatalf("Unexpected error at event %d in repair mode: %v", i, err)
+		}
+	}
+
+	repaired := validator.Repaired()
+	if len(repaired) != 2 {
+		t.Fatalf("Expected 2 repaired events, got %d", len(repaired))
+	}
+	for i, id := range []string{"msg_1", "msg_2"} {
+		start, ok := repaired[i].(*TextMessageStartEvent)
+		if !ok {
+			t.Fatalf("Expected *TextMessageStartEvent, got %T", repaired[i])
+		}
+		if start.MessageID != id {
+			t.Errorf("Expected repaired start for %s, got %s", id, start.MessageID)
+		}
+		if err := start.Validate(); err != nil {
+			t.Errorf("Repaired start is invalid: %v", err)
+		}
+	}
+	if *repaired[0].GetTimestamp() != *events[1].GetTimestamp() {
+		t.Error("Expected repaired start to take the orphan event's timestamp")
+	}
+}
@@ -0,0 +1,69 @@
+package agui
+
+import (
+	"fmt"
+)
+
+// RunSession brackets the events of a single agent run with the run lifecycle:
+// it emits RunStartedEvent when created and RunFinishedEvent or RunErrorEvent
+// when the run ends. No events can be emitted after the run has ended.
+type RunSession struct {
+	encoder  *Encoder
+	threadID string
+	runID    string
+	ended    bool
+}
+
+// BeginRun emits a RunStartedEvent through enc and returns a RunSession for the run.
+func BeginRun(enc *Encoder, threadID, runID string) (*RunSession, error) {
+	if err := enc.Encode(NewRunStartedEvent(threadID, runID)); err != nil {
+		return nil, err
+	}
+	return &RunSession{encoder: enc, threadID: threadID, runID: runID}, nil
+}
+
+// ThreadID returns the ID of the conversation thread.
+func (s *RunSession) ThreadID() string {
+	return s.threadID
+}
+
+// RunID returns the ID of the agent run.
+func (s *RunSession) RunID() string {
+	return s.runID
+}
+
+// Emit encodes event as part of the run. Lifecycle events for the run itself
+// are rejected; use Finish or Fail to end the run.
+func (s *RunSession) Emit(event Event) error {
+	if s.ended {
+		return fmt.Errorf("%w: %s", ErrRunEnded, s.runID)
+	}
+	switch event.GetType() {
+	case EventTypeRunStarted, EventTypeRunFinished, EventTypeRunError:
+		return fmt.Errorf("agui: %s must not be emitted within a run session", event.GetType())
+	}
+	return s.encoder.Encode(event)
+}
+
+// Finish ends the run successfully by emitting a RunFinishedEvent with result.
+func (s *RunSession) Finish(result interface{}) error {
+	return s.end(NewRunFinishedEvent(s.threadID, s.runID, result))
+}
+
+// Fail ends the run by emitting a RunErrorEvent describing err.
+func (s *RunSession) Fail(err error) error {
+	message := "unknown error"
+	if err != nil {
+		message = err.Error()
+	}
+	return s.end(NewRunErrorEvent(message, ""))
+}
+
+// end emits the terminal event and closes the session.
+func (s *RunSession) end(event Event) error {
+	if s.ended {
+		return fmt.Errorf("%w: %s", ErrRunEnded, s.runID)
+	}
+	s.ended = true
+	return s.encoder.Encode(event)
+}
@@ -0,0 +1,96 @@
+package agui
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRunSession(t *testing.T) {
+	var buf bytes.Buffer
+	session, err := BeginRun(NewEncoder(&buf), "thread_1", "run_1")
+	if err != nil {
+		t.Fatalf("Failed to begin run: %v", err)
+	}
+
+	for _, event := range []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageEndEvent("msg_1"),
+	} {
+		if err := session.Emit(event); err != nil {
+			t.Fatalf("Failed to emit event: %v", err)
+		}
+	}
+	if err := session.Emit(NewRunFinishedEvent("thread_1", "run_1", nil)); err == nil {
+		t.Error("Expected lifecycle events to be rejected by Emit")
+	}
+	if err := session.Finish(map[string]interface{}{"ok": true}); err != nil {
+		t.Fatalf("Failed to finish run: %v", err)
+	}
+
+	if err := session.Emit(NewTextMessageStartEvent("msg_2")); !errors.Is(err, ErrRunEnded) {
+		t.Errorf("Expected ErrRunEnded after finish, got: %v", err)
+	}
+	if err := session.Fail(errors.New("late")); !errors.Is(err, ErrRunEnded) {
+		t.Errorf("Expected ErrRunEnded for a second terminal event, got: %v", err)
+	}
+
+	decoder := NewDecoder(&buf)
+	validator := NewSequenceValidator()
+	var types []EventType
+	for {
+		event, err := decoder.DecodeEvent()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if err := validator.Validate(event); err != nil {
+			t.Errorf("Invalid sequence: %v", err)
+		}
+		types = append(types, event.GetType())
+	}
+
+	expected := []EventType{
+		EventTypeRunStarted,
+		EventTypeTextMessageStart,
+		EventTypeTextMessageContent,
+		EventTypeTextMessageEnd,
+		EventTypeRunFinished,
+	}
+	if len(types) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, expected[i], types[i])
+		}
+	}
+}
+
+func TestRunSessionFail(t *testing.T) {
+	var buf bytes.Buffer
+	session, err := BeginRun(NewEncoder(&buf), "thread_1", "run_1")
+	if err != nil {
+		t.Fatalf("Failed to begin run: %v", err)
+	}
+	if err := session.Fail(errors.New("model unavailable")); err != nil {
+		t.Fatalf("Failed to fail run: %v", err)
+	}
+
+	decoder := NewDecoder(&buf)
+	if _, err := decoder.DecodeEvent(); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	event, err := decoder.DecodeEvent()
+	if err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	runError, ok := event.(*RunErrorEvent)
+	if !ok || runError.Message != "model unavailable" {
+		t.Errorf("Expected RunErrorEvent with the failure message, got %+v", event)
+	}
+}
@@ -0,0 +1,79 @@
+package agui
+
+import (
+	"time"
+)
+
+// SnapshotEmitter wraps an Encoder and, while encoding a live event stream,
+// periodically encodes a MessagesSnapshotEvent of the conversation so far, so
+// clients joining mid-stream can catch up. The conversation is reconstructed as
+// described for BuildConversation.
+type SnapshotEmitter struct {
+	encoder      *Encoder
+	builder      *conversationBuilder
+	every        int
+	interval     time.Duration
+	sinceLast    int
+	lastSnapshot time.Time
+}
+
+// SnapshotEmitterOption configures a SnapshotEmitter.
+type SnapshotEmitterOption func(*SnapshotEmitter)
+
+// WithSnapshotEvery makes the SnapshotEmitter encode a snapshot after every n events.
+func WithSnapshotEvery(n int) SnapshotEmitterOption {
+	return func(s *SnapshotEmitter) {
+		s.every = n
+	}
+}
+
+// WithSnapshotInterval makes the SnapshotEmitter encode a snapshot after the
+// first event encoded at least d after the previous snapshot, or after the
+// emitter was created.
+func WithSnapshotInterval(d time.Duration) SnapshotEmitterOption {
+	return func(s *SnapshotEmitter) {
+		s.interval = d
+	}
+}
+
+// NewSnapshotEmitter creates a new SnapshotEmitter that writes through encoder.
+func NewSnapshotEmitter(encoder *Encoder, opts ...SnapshotEmitterOption) *SnapshotEmitter {
+	s := &SnapshotEmitter{
+		encoder:      encoder,
+		builder:      newConversationBuilder(),
+		lastSnapshot: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Encode encodes event and, when a snapshot is due, a MessagesSnapshotEvent
+// right after it. No snapshot is encoded while the conversation is empty.
+func (s *SnapshotEmitter) Encode(event Event) error {
+	if err := s.encoder.Encode(event); err != nil {
+		return err
+	}
+	if err := s.builder.add(event); err != nil {
+		return err
+	}
+	s.sinceLast++
+
+	due := (s.every > 0 && s.sinceLast >= s.every) ||
+		(s.interval > 0 && time.Since(s.lastSnapshot) >= s.interval)
+	if !due || len(s.builder.conversation) == 0 {
+		return nil
+	}
+
+	messages := make([]Message, len(s.builder.conversation))
+	for i, message := range s.builder.conversation {
+		messages[i] = CloneMessage(message)
+	}
+	if err := s.encoder.Encode(NewMessagesSnapshotEvent(messages)); err != nil {
+		return err
+	}
+	s.sinceLast = 0
+	s.lastSnapshot = time.Now()
+	return nil
+}
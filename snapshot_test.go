@@ -0,0 +1,70 @@
+package agui
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// decodeAllEvents decodes every event in data.
+func decodeAllEvents(t *testing.T, data []byte) []Event {
+	t.Helper()
+	decoder := NewDecoder(bytes.NewReader(data))
+	var events []Event
+	for {
+		event, err := decoder.DecodeEvent()
+		if err == io.EOF {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+func TestSnapshotEmitterEvery(t *testing.T) {
+	var buf bytes.Buffer
+	emitter := NewSnapshotEmitter(NewEncoder(&buf), WithSnapshotEvery(4))
+
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageEndEvent("msg_1"),
+		NewTextMessageStartEvent("msg_2"),
+	}
+	for _, event := range events {
+		if err := emitter.Encode(event); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+
+	written := decodeAllEvents(t, buf.Bytes())
+	if len(written) != len(events)+1 {
+		t.Fatalf("Expected %d events including one snapshot, got %d", len(events)+1, len(written))
+	}
+	snapshot, ok := written[4].(*MessagesSnapshotEvent)
+	if !ok {
+		t.Fatalf("Expected a snapshot after the 4th event, got %T", written[4])
+	}
+	if len(snapshot.Messages) != 1 || snapshot.Messages[0].(*AssistantMessage).Content != "Hello" {
+		t.Errorf("Unexpected snapshot messages: %+v", snapshot.Messages)
+	}
+	if written[5].GetType() != EventTypeTextMessageStart {
+		t.Errorf("Expected the stream to continue after the snapshot, got %s", written[5].GetType())
+	}
+}
+
+func TestSnapshotEmitterSkipsEmptyConversation(t *testing.T) {
+	var buf bytes.Buffer
+	emitter := NewSnapshotEmitter(NewEncoder(&buf), WithSnapshotEvery(1), WithSnapshotInterval(time.Hour))
+
+	if err := emitter.Encode(NewRunStartedEvent("thread_1", "run_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if written := decodeAllEvents(t, buf.Bytes()); len(written) != 1 {
+		t.Errorf("Expected no snapshot for an empty conversation, got %d events", len(written))
+	}
+}
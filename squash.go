@@ -0,0 +1,85 @@
+package agui
+
+import "encoding/json"
+
+// DedupeConsecutive returns events with every event that equals its immediate
+// predecessor removed, comparing with EventsEqual so timestamps and sequence
+// numbers are ignored.
+// Non-adjacent repeats are kept. The input slice is not modified.
+func DedupeConsecutive(events []Event) []Event {
+	result := make([]Event, 0, len(events))
+	for i, event := range events {
+		if i > 0 && EventsEqual(events[i-1], event) {
+			continue
+		}
+		result = append(result, event)
+	}
+	return result
+}
+
+// SquashStats reports the effect of squashing a slice of events.
+type SquashStats struct {
+	EventsIn   int // Number of events before squashing
+	EventsOut  int // Number of events after squashing
+	BytesSaved int // Reduction in total JSON-encoded size
+}
+
+// SquashContentEvents merges each run of consecutive TextMessageContentEvents
+// for the same message into a single event carrying the concatenated deltas
+// and the metadata of the run's first event. Other events, and content events
+// separated by them, are kept as they are. The input events are not modified.
+func SquashContentEvents(events []Event) ([]Event, SquashStats) {
+	return squash(events, func(prev, next Event) (Event, bool) {
+		a, ok := prev.(*TextMessageContentEvent)
+		b, ok2 := next.(*TextMessageContentEvent)
+		if !ok || !ok2 || a.MessageID != b.MessageID {
+			return nil, false
+		}
+		merged := *a
+		merged.Delta += b.Delta
+		return &merged, true
+	})
+}
+
+// SquashToolCallArgs merges each run of consecutive ToolCallArgsEvents for the
+// same tool call like SquashContentEvents does for text message content.
+func SquashToolCallArgs(events []Event) ([]Event, SquashStats) {
+	return squash(events, func(prev, next Event) (Event, bool) {
+		a, ok := prev.(*ToolCallArgsEvent)
+		b, ok2 := next.(*ToolCallArgsEvent)
+		if !ok || !ok2 || a.ToolCallID != b.ToolCallID {
+			return nil, false
+		}
+		merged := *a
+		merged.Delta += b.Delta
+		return &merged, true
+	})
+}
+
+// squash replaces each event and its predecessor in the output with the event
+// merge returns for them, if any. Events that fail to encode count as zero bytes.
+func squash(events []Event, merge func(prev, next Event) (Event, bool)) ([]Event, SquashStats) {
+	stats := SquashStats{EventsIn: len(events)}
+	result := make([]Event, 0, len(events))
+
+	for _, event := range events {
+		if data, err := json.Marshal(event); err == nil {
+			stats.BytesSaved += len(data)
+		}
+		if last := len(result) - 1; last >= 0 {
+			if merged, ok := merge(result[last], event); ok {
+				result[last] = merged
+				continue
+			}
+		}
+		result = append(result, event)
+	}
+
+	for _, event := range result {
+		if data, err := json.Marshal(event); err == nil {
+			stats.BytesSaved -= len(data)
+		}
+	}
+	stats.EventsOut = len(result)
+	return result, stats
+}
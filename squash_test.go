@@ -0,0 +1,156 @@
+package agui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDedupeConsecutive(t *testing.T) {
+	finish := NewRunFinishedEvent("thread_1", "run_1", nil)
+	finishAgain := NewRunFinishedEvent("thread_1", "run_1", nil)
+	later := int64(1)
+	finishAgain.Timestamp = &later
+
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageContentEvent("msg_1", "ha"),
+		NewTextMessageContentEvent("msg_1", "ha"),
+		NewTextMessageContentEvent("msg_1", "!"),
+		NewTextMessageContentEvent("msg_1", "ha"),
+		finish,
+		finishAgain,
+	}
+
+	deduped := DedupeConsecutive(events)
+
+	expected := []Event{events[0], events[1], events[3], events[4], events[5]}
+	if len(deduped) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(deduped))
+	}
+	for i := range expected {
+		if deduped[i] != expected[i] {
+			t.Errorf("Event %d: expected %+v, got %+v", i, expected[i], deduped[i])
+		}
+	}
+	if len(events) != 7 {
+		t.Error("Expected the input slice to be left unchanged")
+	}
+}
+
+func TestDedupeConsecutiveSequenced(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewSequencingEncoder(NewEncoder(&buf))
+	for _, event := range []Event{
+		NewTextMessageContentEvent("msg_1", "ha"),
+		NewTextMessageContentEvent("msg_1", "ha"),
+		NewTextMessageContentEvent("msg_1", "!"),
+	} {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+	}
+	events, err := NewDecoder(&buf).DecodeAll()
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+
+	deduped := DedupeConsecutive(events)
+	if len(deduped) != 2 || deduped[0] != events[0] || deduped[1] != events[2] {
+		t.Errorf("Expected sequenced duplicates to collapse, got %d events", len(deduped))
+	}
+}
+
+func TestDedupeConsecutiveTimePayloads(t *testing.T) {
+	first := NewCustomEvent("tick", struct{ At time.Time }{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	second := NewCustomEvent("tick", struct{ At time.Time }{time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC)})
+
+	if EventsEqual(first, second) {
+		t.Error("Expected events with different time payloads to differ")
+	}
+	if deduped := DedupeConsecutive([]Event{first, second}); len(deduped) != 2 {
+		t.Errorf("Expected both events to be kept, got %d", len(deduped))
+	}
+}
+
+func TestEventsEqual(t *testing.T) {
+	a := NewRunFinishedEvent("thread_1", "run_1", map[string]interface{}{"ok": true})
+	b := NewRunFinishedEvent("thread_1", "run_1", map[string]interface{}{"ok": true})
+	ts := int64(42)
+	b.Timestamp = &ts
+
+	if !EventsEqual(a, b) {
+		t.Error("Expected events differing only in timestamp to be equal")
+	}
+	if *b.Timestamp != 42 || a.Timestamp == nil {
+		t.Error("Expected EventsEqual to leave timestamps untouched")
+	}
+	if EventsEqual(a, NewRunFinishedEvent("thread_1", "run_2", nil)) {
+		t.Error("Expected events with different run IDs to differ")
+	}
+	if EventsEqual(a, NewRunStartedEvent("thread_1", "run_1")) {
+		t.Error("Expected events of different types to differ")
+	}
+}
+
+func TestSquashContentEvents(t *testing.T) {
+	deltas := []string{"The ", "weather ", "in ", "Paris ", "is ", "sunny ", "and ", "warm."}
+	events := []Event{NewTextMessageStartEvent("msg_1")}
+	for _, delta := range deltas {
+		events = append(events, NewTextMessageContentEvent("msg_1", delta))
+	}
+	events = append(events, NewTextMessageEndEvent("msg_1"))
+
+	inBytes := 0
+	for _, event := range events {
+		data, _ := EncodeEvent(event)
+		inBytes += len(data)
+	}
+
+	squashed, stats := SquashContentEvents(events)
+	if len(squashed) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(squashed))
+	}
+	content, ok := squashed[1].(*TextMessageContentEvent)
+	if !ok || content.Delta != "The weather in Paris is sunny and warm." {
+		t.Errorf("Unexpected squashed content: %#v", squashed[1])
+	}
+	if events[1].(*TextMessageContentEvent).Delta != "The " {
+		t.Error("Expected the input events not to be modified")
+	}
+
+	outBytes := 0
+	for _, event := range squashed {
+		data, _ := EncodeEvent(event)
+		outBytes += len(data)
+	}
+	want := SquashStats{EventsIn: 10, EventsOut: 3, BytesSaved: inBytes - outBytes}
+	if stats != want {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+	if stats.BytesSaved <= 0 {
+		t.Errorf("Expected bytes to be saved, got %d", stats.BytesSaved)
+	}
+}
+
+func TestSquashToolCallArgs(t *testing.T) {
+	events := []Event{
+		NewToolCallStartEvent("tc_1", "search", ""),
+		NewToolCallArgsEvent("tc_1", `{"query":`),
+		NewToolCallArgsEvent("tc_1", `"weather"}`),
+		NewToolCallStartEvent("tc_2", "search", ""),
+		NewToolCallArgsEvent("tc_2", `{}`),
+		NewToolCallArgsEvent("tc_1", ``),
+	}
+
+	squashed, stats := SquashToolCallArgs(events)
+	if len(squashed) != 5 || stats.EventsIn != 6 || stats.EventsOut != 5 {
+		t.Fatalf("Expected 6 events squashed to 5, got %d (%+v)", len(squashed), stats)
+	}
+	if args := squashed[1].(*ToolCallArgsEvent); args.Delta != `{"query":"weather"}` {
+		t.Errorf("Unexpected squashed args: %q", args.Delta)
+	}
+	if squashed[4] != events[5] {
+		t.Error("Expected args separated by another event to be kept")
+	}
+}
@@ -0,0 +1,222 @@
+package agui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// SSEEncoder writes AG-UI events to an io.Writer as Server-Sent Events frames.
+type SSEEncoder struct {
+	writer  io.Writer
+	started bool
+	retry   *time.Duration
+}
+
+// NewSSEEncoder creates a new SSEEncoder that writes to the provided io.Writer.
+func NewSSEEncoder(w io.Writer) *SSEEncoder {
+	return &SSEEncoder{writer: w}
+}
+
+// SetRetry sets the reconnection delay advertised to clients with an SSE
+// "retry:" field, in milliseconds. If no frame has been written yet, the field
+// is emitted once at the start of the stream; otherwise it is written immediately.
+func (s *SSEEncoder) SetRetry(d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("agui: retry delay must not be negative: %s", d)
+	}
+	if !s.started {
+		s.retry = &d
+		return nil
+	}
+	return s.write(retryField(d))
+}
+
+// Encode validates event and writes it as an SSE frame of the form
+// "event: <type>\ndata: <json>\n\n".
+func (s *SSEEncoder) Encode(event Event) error {
+	frame, err := EncodeEventSSE(event)
+	if err != nil {
+		return err
+	}
+	if !s.started && s.retry != nil {
+		frame = append(retryField(*s.retry), frame...)
+	}
+	return s.write(frame)
+}
+
+// EncodeEventSSE validates event and returns it as a single SSE frame of the
+// form "event: <type>\ndata: <json>\n\n", with one "data:" line per line of the
+// JSON encoding. The frame can be written to an http.ResponseWriter as is,
+// followed by a flush.
+func EncodeEventSSE(event Event) ([]byte, error) {
+	data, err := EncodeEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var frame bytes.Buffer
+	frame.WriteString("event: ")
+	frame.WriteString(string(event.GetType()))
+	frame.WriteString("\n")
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		frame.WriteString("data: ")
+		frame.Write(line)
+		frame.WriteString("\n")
+	}
+	frame.WriteString("\n")
+	return frame.Bytes(), nil
+}
+
+// write writes p to the underlying writer and marks the stream as started.
+func (s *SSEEncoder) write(p []byte) error {
+	s.started = true
+	if _, err := s.writer.Write(p); err != nil {
+		return fmt.Errorf("agui: failed to write SSE frame: %w", err)
+	}
+	return nil
+}
+
+// retryField formats d as an SSE retry field in its own block.
+func retryField(d time.Duration) []byte {
+	return []byte("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// sseState holds the parsing state of a Decoder reading Server-Sent Events.
+type sseState struct {
+	started     bool         // whether the first line, which may carry a BOM, was read
+	lastEventID atomic.Value // string from the most recent "id:" field
+}
+
+// NewSSEStreamDecoder creates a StreamDecoder that reads AG-UI events sent as
+// Server-Sent Events, such as those written by SSEEncoder. Lines may end in
+// "\r\n", "\n" or "\r". The "data:" lines of each event are joined with
+// newlines and decoded as one JSON value; comment lines starting with ":" and
+// "retry:" fields are skipped, and "id:" fields are available from LastEventID.
+// When the JSON value has no "type", the SSE "event:" name is used in its place.
+// An event not terminated by a blank line before the input ends is discarded,
+// as the SSE specification requires. WithDecodeDelimiter has no effect.
+func NewSSEStreamDecoder(r io.Reader, opts ...DecoderOption) *StreamDecoder {
+	d := &Decoder{config: newDecodeConfig(opts), sse: &sseState{}}
+	d.scanner = bufio.NewScanner(r)
+	d.scanner.Buffer(nil, maxDelimitedValueSize)
+	d.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := scanSSELine(data, atEOF)
+		d.consumed += int64(advance)
+		return advance, token, err
+	})
+	return &StreamDecoder{decoder: d}
+}
+
+// LastEventID returns the value of the most recent SSE "id:" field read by a
+// StreamDecoder created with NewSSEStreamDecoder, for resuming the stream with
+// a Last-Event-ID header. It returns "" for other decoders.
+func (s *StreamDecoder) LastEventID() string {
+	if s.decoder.sse == nil {
+		return ""
+	}
+	id, _ := s.decoder.sse.lastEventID.Load().(string)
+	return id
+}
+
+// readSSE reads SSE lines until an event with data is complete and returns its
+// data as a raw JSON value. It returns io.EOF unwrapped when the input is exhausted.
+func (d *Decoder) readSSE() (json.RawMessage, error) {
+	var data bytes.Buffer
+	hasData := false
+	eventName := ""
+
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if !d.sse.started {
+			d.sse.started = true
+			line = bytes.TrimPrefix(line, utf8BOM)
+		}
+
+		if len(line) == 0 {
+			payload := bytes.TrimSpace(d.config.preprocess(data.Bytes()))
+			if !hasData || len(payload) == 0 {
+				data.Reset()
+				hasData, eventName = false, ""
+				continue
+			}
+			return sseValue(payload, eventName)
+		}
+		if line[0] == ':' {
+			continue // Comment
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], bytes.TrimPrefix(line[i+1:], []byte(" "))
+		}
+		switch string(field) {
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.Write(value)
+			hasData = true
+		case "event":
+			eventName = string(value)
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				d.sse.lastEventID.Store(string(value))
+			}
+		}
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+	}
+	return nil, io.EOF
+}
+
+// sseValue returns a copy of payload, with eventName set as its "type" if the
+// payload is a JSON object without one. The default SSE event name "message"
+// is not used as a type.
+func sseValue(payload []byte, eventName string) (json.RawMessage, error) {
+	value := append(json.RawMessage(nil), payload...)
+	if eventName == "" || eventName == "message" {
+		return value, nil
+	}
+	var probe struct {
+		Type *string `json:"type"`
+	}
+	if json.Unmarshal(value, &probe) != nil || probe.Type != nil {
+		return value, nil
+	}
+	typed, err := setJSONField(value, "type", eventName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnmarshalFailed, err)
+	}
+	return typed, nil
+}
+
+// scanSSELine is a bufio.SplitFunc returning lines ended by "\r\n", "\n" or "\r".
+func scanSSELine(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 < len(data) {
+				if data[i+1] == '\n' {
+					return i + 2, data[:i], nil
+				}
+			} else if !atEOF {
+				return 0, nil, nil // A "\n" may follow
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
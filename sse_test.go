@@ -0,0 +1,166 @@
+package agui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEEncoderRetry(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewSSEEncoder(&buf)
+
+	if err := encoder.SetRetry(3 * time.Second); err != nil {
+		t.Fatalf("Failed to set retry: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected retry to be deferred until the first frame, got %q", buf.String())
+	}
+
+	if err := encoder.Encode(NewRunStartedEvent("thread_1", "run_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if err := encoder.Encode(NewRunFinishedEvent("thread_1", "run_1", nil)); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "retry: 3000\n\nevent: RUN_STARTED\ndata: {") {
+		t.Errorf("Expected stream to start with the retry field, got %q", out)
+	}
+	if strings.Count(out, "retry:") != 1 {
+		t.Errorf("Expected retry to be emitted once, got %q", out)
+	}
+	if !strings.Contains(out, "event: RUN_FINISHED\ndata: {") || !strings.HasSuffix(out, "}\n\n") {
+		t.Errorf("Unexpected SSE frames: %q", out)
+	}
+
+	// Once the stream has started, retry is written on demand
+	if err := encoder.SetRetry(1500 * time.Millisecond); err != nil {
+		t.Fatalf("Failed to set retry: %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "\n\nretry: 1500\n\n") {
+		t.Errorf("Expected on-demand retry field, got %q", buf.String())
+	}
+}
+
+func TestEncodeEventSSE(t *testing.T) {
+	event := NewTextMessageContentEvent("msg_1", "line one\nline two")
+	frame, err := EncodeEventSSE(event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := string(frame)
+	if !strings.HasPrefix(text, "event: TEXT_MESSAGE_CONTENT\ndata: {") || !strings.HasSuffix(text, "}\n\n") {
+		t.Errorf("Unexpected frame: %q", text)
+	}
+	if strings.Count(text, "data: ") != 1 || !strings.Contains(text, `"delta":"line one\nline two"`) {
+		t.Errorf("Expected a single data line with the newline escaped, got %q", text)
+	}
+
+	var buf bytes.Buffer
+	if err := NewSSEEncoder(&buf).Encode(event); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), frame) {
+		t.Errorf("Expected SSEEncoder to write the same frame, got %q", buf.String())
+	}
+
+	if _, err := EncodeEventSSE(&TextMessageContentEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageContent}}); err == nil {
+		t.Error("Expected an invalid event to be rejected")
+	}
+}
+
+func TestSSEStreamDecoderRoundTrip(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello\nworld"),
+		NewTextMessageEndEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	var buf bytes.Buffer
+	encoder := NewSSEEncoder(&buf)
+	if err := encoder.SetRetry(time.Second); err != nil {
+		t.Fatalf("Failed to set retry: %v", err)
+	}
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode: %v", err)
+		}
+	}
+
+	eventChan, errorChan := NewSSEStreamDecoder(&buf).DecodeEvents()
+	var decoded []Event
+	for event := range eventChan {
+		decoded = append(decoded, event)
+	}
+	if err := <-errorChan; err != nil {
+		t.Fatalf("Unexpected stream error: %v", err)
+	}
+	if len(decoded) != len(events) {
+		t.Fatalf("Expected %d events, got %d", len(events), len(decoded))
+	}
+	for i := range events {
+		if !EventsEqual(decoded[i], events[i]) {
+			t.Errorf("Event %d: got %#v, want %#v", i, decoded[i], events[i])
+		}
+	}
+}
+
+func TestSSEStreamDecoderParsing(t *testing.T) {
+	stream := "\xEF\xBB\xBF: keep-alive\r\n" +
+		"\r\n" +
+		"id: 1\r\n" +
+		"event: RUN_STARTED\r\n" +
+		"data: {\"threadId\":\"thread_1\",\r\n" +
+		"data:\"runId\":\"run_1\"}\r\n" +
+		"\r\n" +
+		"retry: 500\r" +
+		"id: 2\r" +
+		"data: {\"type\":\"TEXT_MESSAGE_START\",\"messageId\":\"msg_1\",\"role\":\"assistant\"}\r" +
+		"\r" +
+		"event: message\n" +
+		"data: {\"type\":\"TEXT_MESSAGE_END\",\"messageId\":\"msg_1\"}\n" +
+		"\n" +
+		"id: 4\n" +
+		"data: {\"type\":\"RUN_FINISHED\",\"threadId\":\"thread_1\",\"runId\":\"run_1\"}\n"
+
+	decoder := NewSSEStreamDecoder(strings.NewReader(stream))
+	eventChan, errorChan := decoder.DecodeEvents()
+
+	var got []EventType
+	for event := range eventChan {
+		got = append(got, event.GetType())
+	}
+	if err := <-errorChan; err != nil {
+		t.Fatalf("Unexpected stream error: %v", err)
+	}
+
+	want := []EventType{EventTypeRunStarted, EventTypeTextMessageStart, EventTypeTextMessageEnd}
+	if len(got) != len(want) {
+		t.Fatalf("Decoded %v, want %v (unterminated trailing event must be dropped)", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+	if id := decoder.LastEventID(); id != "4" {
+		t.Errorf("LastEventID = %q, want %q", id, "4")
+	}
+}
+
+func TestSSEStreamDecoderInvalidData(t *testing.T) {
+	stream := "data: {\"type\":\"UNKNOWN\"}\n\n"
+	eventChan, errorChan := NewSSEStreamDecoder(strings.NewReader(stream)).DecodeEvents()
+	for range eventChan {
+		t.Error("Expected no events")
+	}
+	if err := <-errorChan; !errors.Is(err, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType, got %v", err)
+	}
+}
@@ -0,0 +1,664 @@
+package agui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// JSON Patch operation names as defined in RFC 6902.
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+	PatchOpMove    = "move"
+	PatchOpCopy    = "copy"
+	PatchOpTest    = "test"
+)
+
+// StateMarshaler serializes the State carried by a StateSnapshotEvent.
+type StateMarshaler func(State) ([]byte, error)
+
+// stateMarshaler holds the package-wide StateMarshaler, if one is set.
+var stateMarshaler atomic.Value
+
+// SetStateMarshaler sets the function StateSnapshotEvent.MarshalJSON uses to
+// serialize the snapshot. Passing nil restores the default, json.Marshal.
+func SetStateMarshaler(marshal StateMarshaler) {
+	if marshal == nil {
+		marshal = func(state State) ([]byte, error) { return json.Marshal(state) }
+	}
+	stateMarshaler.Store(marshal)
+}
+
+// marshalState serializes state with the StateMarshaler set by SetStateMarshaler.
+func marshalState(state State) ([]byte, error) {
+	if marshal, ok := stateMarshaler.Load().(StateMarshaler); ok {
+		return marshal(state)
+	}
+	return json.Marshal(state)
+}
+
+// MarshalJSON implements json.Marshaler, serializing the snapshot with the
+// StateMarshaler set by SetStateMarshaler.
+func (s *StateSnapshotEvent) MarshalJSON() ([]byte, error) {
+	snapshot, err := marshalState(s.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	type alias StateSnapshotEvent
+	return json.Marshal(struct {
+		*alias
+		Snapshot json.RawMessage `json:"snapshot"`
+	}{
+		alias:    (*alias)(s),
+		Snapshot: snapshot,
+	})
+}
+
+// ToRunState returns a deep copy of the snapshot's state, suitable for seeding
+// the State of a RunAgentInput when resuming a thread. Changes to the result do
+// not affect the snapshot, and vice versa, except through unexported fields of
+// structs in the state, which are copied by value as described for CloneEvent.
+func (s *StateSnapshotEvent) ToRunState() State {
+	if s == nil || s.Snapshot == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(s.Snapshot)).Interface()
+}
+
+// SeedState sets the input's State to a deep copy of the state in snap, as
+// returned by ToRunState. A nil snap clears the State.
+func (r *RunAgentInput) SeedState(snap *StateSnapshotEvent) {
+	r.State = snap.ToRunState()
+}
+
+// MarshalJSON implements json.Marshaler. When RawDelta is set, its operations are
+// written as stored instead of Delta, keeping key order and number formatting.
+// Note that json.Marshal still compacts insignificant whitespace in the output.
+func (s *StateDeltaEvent) MarshalJSON() ([]byte, error) {
+	type alias StateDeltaEvent
+	if s.RawDelta == nil {
+		return json.Marshal((*alias)(s))
+	}
+
+	// Encode everything but the delta, then append the raw operations untouched
+	data, err := json.Marshal(struct {
+		*alias
+		Delta []interface{} `json:"delta,omitempty"`
+	}{alias: (*alias)(s)})
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.Write(data[:len(data)-1])
+	b.WriteString(`,"delta":[`)
+	for i, op := range s.RawDelta {
+		if !json.Valid(op) {
+			return nil, fmt.Errorf("raw delta operation at index %d is not valid JSON", i)
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(op)
+	}
+	b.WriteString("]}")
+	return b.Bytes(), nil
+}
+
+// Operations returns the delta's JSON Patch operations in parsed form, parsing
+// RawDelta on demand when it is set.
+func (s *StateDeltaEvent) Operations() ([]interface{}, error) {
+	if s.RawDelta == nil {
+		return s.Delta, nil
+	}
+	ops := make([]interface{}, len(s.RawDelta))
+	for i, raw := range s.RawDelta {
+		if err := json.Unmarshal(raw, &ops[i]); err != nil {
+			return nil, fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+	}
+	return ops, nil
+}
+
+// patchOperation is the decoded form of a single JSON Patch operation.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// decodePatchOperation converts a delta entry into a patchOperation.
+func decodePatchOperation(op interface{}) (patchOperation, error) {
+	var decoded patchOperation
+	data, err := json.Marshal(op)
+	if err != nil {
+		return decoded, err
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return decoded, err
+	}
+	return decoded, nil
+}
+
+// normalizeState converts an arbitrary state value into its generic JSON form
+// (maps, slices, strings, float64, bool and nil) without touching the original.
+func normalizeState(state State) (interface{}, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// JSONPointer builds an RFC 6901 JSON Pointer from unescaped reference tokens,
+// escaping "~" and "/" within each token. JSONPointer() returns "", the pointer
+// to the whole document.
+func JSONPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		token = strings.ReplaceAll(token, "~", "~0")
+		b.WriteString(strings.ReplaceAll(token, "/", "~1"))
+	}
+	return b.String()
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer must start with '/': %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens, nil
+}
+
+// resolveJSONPointer returns the value referenced by tokens within doc.
+func resolveJSONPointer(doc interface{}, tokens []string) (interface{}, bool) {
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, ok := arrayIndex(token, len(node))
+			if !ok {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// arrayIndex parses token as an index into an array of the given length.
+func arrayIndex(token string, length int) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index >= length {
+		return 0, false
+	}
+	return index, true
+}
+
+// canAddAt reports whether an "add" operation could target tokens within doc,
+// which requires the parent container to exist.
+func canAddAt(doc interface{}, tokens []string) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	parent, ok := resolveJSONPointer(doc, tokens[:len(tokens)-1])
+	if !ok {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		if last == "-" {
+			return true
+		}
+		// Adding at index len(node) appends to the array.
+		_, ok := arrayIndex(last, len(node)+1)
+		return ok
+	default:
+		return false
+	}
+}
+
+// ValidateAgainst checks that every operation in the delta targets a path that
+// exists in base where RFC 6902 requires one: the path of remove, replace and
+// test operations, the from location of move and copy operations, and the parent
+// of add, move and copy targets. Each operation is checked against base as given;
+// effects of earlier operations in the delta are not taken into account.
+// Neither base nor the delta is modified.
+func (s *StateDeltaEvent) ValidateAgainst(base State) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	doc, err := normalizeState(base)
+	if err != nil {
+		return fmt.Errorf("invalid base state: %w", err)
+	}
+
+	ops, err := s.Operations()
+	if err != nil {
+		return err
+	}
+	for i, raw := range ops {
+		op, err := decodePatchOperation(raw)
+		if err != nil {
+			return fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+		path, err := parseJSONPointer(op.Path)
+		if err != nil {
+			return fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+
+		switch op.Op {
+		case PatchOpRemove, PatchOpReplace, PatchOpTest:
+			if _, ok := resolveJSONPointer(doc, path); !ok {
+				return fmt.Errorf("invalid delta operation at index %d: %s path %q does not exist", i, op.Op, op.Path)
+			}
+		case PatchOpAdd:
+			if !canAddAt(doc, path) {
+				return fmt.Errorf("invalid delta operation at index %d: add path %q has no parent", i, op.Path)
+			}
+		case PatchOpMove, PatchOpCopy:
+			from, err := parseJSONPointer(op.From)
+			if err != nil {
+				return fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+			}
+			if _, ok := resolveJSONPointer(doc, from); !ok {
+				return fmt.Errorf("invalid delta operation at index %d: %s from %q does not exist", i, op.Op, op.From)
+			}
+			if !canAddAt(doc, path) {
+				return fmt.Errorf("invalid delta operation at index %d: %s path %q has no parent", i, op.Op, op.Path)
+			}
+		default:
+			return fmt.Errorf("invalid delta operation at index %d: unknown op %q", i, op.Op)
+		}
+	}
+
+	return nil
+}
+
+// Normalize checks that the delta is a well-formed sequence of operations and
+// rewrites it into a canonical order. It reports an error for unknown or
+// malformed operations, for a move into its own child, and for contradictory
+// sequences where an operation refers to an object member, or a location
+// beneath it, that an earlier operation in the delta removed or moved away
+// without recreating it.
+//
+// Reordering only swaps neighbouring add, replace and remove operations that
+// commute: their paths are not nested within one another, and neither inserts,
+// removes or replaces an array element of an array the other touches. Such runs
+// are sorted by path, so deltas that differ only in the order of independent
+// operations normalize to the same order while applying to the same result.
+// Operations read from RawDelta are reordered in place, keeping their bytes.
+func (s *StateDeltaEvent) Normalize() error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	raw, err := s.Operations()
+	if err != nil {
+		return err
+	}
+
+	ops := make([]parsedPatchOperation, len(raw))
+	removed := make(map[string]int) // removed member pointer to the index removing it
+	for i := range raw {
+		op, err := parsePatchOperation(raw[i])
+		if err != nil {
+			return fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+		ops[i] = op
+
+		// Locations the operation reads must still exist
+		var refs [][]string
+		switch op.Op {
+		case PatchOpRemove, PatchOpReplace, PatchOpTest:
+			refs = append(refs, op.path)
+		case PatchOpMove, PatchOpCopy:
+			refs = append(refs, op.from)
+		}
+		for _, ref := range refs {
+			if at, ok := removedWithin(removed, ref, len(ref)); ok {
+				return fmt.Errorf("invalid delta operation at index %d: %s of %q after it was removed at index %d", i, op.Op, JSONPointer(ref...), at)
+			}
+		}
+		if op.Op != PatchOpRemove {
+			if at, ok := removedWithin(removed, op.path, len(op.path)-1); ok {
+				return fmt.Errorf("invalid delta operation at index %d: %s of %q after its parent was removed at index %d", i, op.Op, op.Path, at)
+			}
+		}
+
+		// Track removals of object members and clear those recreated
+		switch op.Op {
+		case PatchOpRemove:
+			markRemoved(removed, op.path, i)
+		case PatchOpMove:
+			markRemoved(removed, op.from, i)
+		}
+		if op.Op == PatchOpAdd || op.Op == PatchOpCopy || op.Op == PatchOpMove {
+			pointer := JSONPointer(op.path...)
+			for key := range removed {
+				if key == pointer || strings.HasPrefix(key, pointer+"/") {
+					delete(removed, key)
+				}
+			}
+		}
+	}
+
+	// Sort runs of commuting operations by path using adjacent swaps only
+	order := make([]int, len(ops))
+	for i := range order {
+		order[i] = i
+	}
+	for i := 1; i < len(order); i++ {
+		for j := i; j > 0; j-- {
+			a, b := ops[order[j-1]], ops[order[j]]
+			if !patchOperationsCommute(a, b) || a.Path <= b.Path {
+				break
+			}
+			order[j-1], order[j] = order[j], order[j-1]
+		}
+	}
+
+	if s.RawDelta != nil {
+		reordered := make([]json.RawMessage, len(order))
+		for i, j := range order {
+			reordered[i] = s.RawDelta[j]
+		}
+		s.RawDelta = reordered
+		return nil
+	}
+	reordered := make([]interface{}, len(order))
+	for i, j := range order {
+		reordered[i] = s.Delta[j]
+	}
+	s.Delta = reordered
+	return nil
+}
+
+// parsedPatchOperation is a patchOperation with its pointers split into tokens.
+type parsedPatchOperation struct {
+	patchOperation
+	path []string
+	from []string
+}
+
+// parsePatchOperation decodes a delta entry and checks that it is a well-formed
+// RFC 6902 operation.
+func parsePatchOperation(raw interface{}) (parsedPatchOperation, error) {
+	var parsed parsedPatchOperation
+	op, err := decodePatchOperation(raw)
+	if err != nil {
+		return parsed, err
+	}
+	parsed.patchOperation = op
+	if parsed.path, err = parseJSONPointer(op.Path); err != nil {
+		return parsed, err
+	}
+
+	switch op.Op {
+	case PatchOpAdd, PatchOpRemove, PatchOpReplace, PatchOpTest:
+	case PatchOpMove, PatchOpCopy:
+		if parsed.from, err = parseJSONPointer(op.From); err != nil {
+			return parsed, err
+		}
+		if op.Op == PatchOpMove && len(parsed.path) > len(parsed.from) && tokensHavePrefix(parsed.path, parsed.from) {
+			return parsed, fmt.Errorf("cannot move %q into its own child %q", op.From, op.Path)
+		}
+	default:
+		return parsed, fmt.Errorf("unknown op %q", op.Op)
+	}
+	return parsed, nil
+}
+
+// patchOperationsCommute reports whether applying a and b in either order is
+// guaranteed to give the same result, whatever the document.
+func patchOperationsCommute(a, b parsedPatchOperation) bool {
+	for _, op := range []parsedPatchOperation{a, b} {
+		if op.Op != PatchOpAdd && op.Op != PatchOpReplace && op.Op != PatchOpRemove {
+			return false
+		}
+	}
+	if tokensHavePrefix(a.path, b.path) || tokensHavePrefix(b.path, a.path) {
+		return false
+	}
+	// An operation on an array element may shift the indexes of its siblings
+	for _, pair := range [][2][]string{{a.path, b.path}, {b.path, a.path}} {
+		op, other := pair[0], pair[1]
+		if len(op) > 0 && isArrayToken(op[len(op)-1]) && tokensHavePrefix(other, op[:len(op)-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// markRemoved records that the object member at path was removed by the
+// operation at index. Removed array elements are not tracked, since the
+// following elements shift into their place.
+func markRemoved(removed map[string]int, path []string, index int) {
+	if len(path) > 0 && !isArrayToken(path[len(path)-1]) {
+		removed[JSONPointer(path...)] = index
+	}
+}
+
+// removedWithin reports whether path, cut to its first n tokens, is or lies
+// beneath a removed member, and the index of the operation that removed it.
+func removedWithin(removed map[string]int, path []string, n int) (int, bool) {
+	for k := 1; k <= n; k++ {
+		if at, ok := removed[JSONPointer(path[:k]...)]; ok {
+			return at, true
+		}
+	}
+	return 0, false
+}
+
+// tokensHavePrefix reports whether prefix is a leading subsequence of tokens.
+func tokensHavePrefix(tokens, prefix []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if tokens[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isArrayToken reports whether token can address an array element: "-" or a
+// non-empty string of digits.
+func isArrayToken(token string) bool {
+	if token == "-" {
+		return true
+	}
+	if token == "" {
+		return false
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPatch applies the JSON Patch operations in delta to doc, which must be in
+// the generic form produced by normalizeState, and returns the patched document.
+// doc may be modified in place, so callers should pass a copy they own.
+func applyPatch(doc interface{}, delta []interface{}) (interface{}, error) {
+	for i, raw := range delta {
+		op, err := decodePatchOperation(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+		path, err := parseJSONPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+		value, err := normalizeState(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+
+		switch op.Op {
+		case PatchOpAdd:
+			doc, err = addAt(doc, path, value)
+		case PatchOpRemove:
+			doc, _, err = removeAt(doc, path)
+		case PatchOpReplace:
+			if doc, _, err = removeAt(doc, path); err == nil {
+				doc, err = addAt(doc, path, value)
+			}
+		case PatchOpMove, PatchOpCopy:
+			var from []string
+			if from, err = parseJSONPointer(op.From); err != nil {
+				break
+			}
+			var moved interface{}
+			if op.Op == PatchOpMove {
+				doc, moved, err = removeAt(doc, from)
+			} else if current, ok := resolveJSONPointer(doc, from); !ok {
+				err = fmt.Errorf("copy from %q does not exist", op.From)
+			} else {
+				moved, err = normalizeState(current)
+			}
+			if err == nil {
+				doc, err = addAt(doc, path, moved)
+			}
+		case PatchOpTest:
+			current, ok := resolveJSONPointer(doc, path)
+			if !ok {
+				err = fmt.Errorf("test path %q does not exist", op.Path)
+			} else if !reflect.DeepEqual(current, value) {
+				err = fmt.Errorf("test failed at path %q", op.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown op %q", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta operation at index %d: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+// addAt inserts value at the location referenced by tokens and returns the
+// updated document. Adding at the root replaces the whole document.
+func addAt(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	last := tokens[len(tokens)-1]
+	return updateParent(doc, tokens, func(parent interface{}) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[last] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if last != "-" {
+				var ok bool
+				if index, ok = arrayIndex(last, len(node)+1); !ok {
+					return nil, fmt.Errorf("array index %q out of range", last)
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("parent of %q is not a container", last)
+		}
+	})
+}
+
+// removeAt deletes the value referenced by tokens and returns the updated
+// document along with the removed value.
+func removeAt(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+	last := tokens[len(tokens)-1]
+	var removed interface{}
+	updated, err := updateParent(doc, tokens, func(parent interface{}) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[last]
+			if !ok {
+				return nil, fmt.Errorf("path member %q does not exist", last)
+			}
+			removed = value
+			delete(node, last)
+			return node, nil
+		case []interface{}:
+			index, ok := arrayIndex(last, len(node))
+			if !ok {
+				return nil, fmt.Errorf("array index %q out of range", last)
+			}
+			removed = node[index]
+			return append(node[:index], node[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("parent of %q is not a container", last)
+		}
+	})
+	return updated, removed, err
+}
+
+// updateParent resolves the parent of the location referenced by tokens, passes
+// it to update and stores the result back so that array growth is preserved.
+func updateParent(doc interface{}, tokens []string, update func(interface{}) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(doc)
+	}
+	parentTokens := tokens[:len(tokens)-1]
+	parent, ok := resolveJSONPointer(doc, parentTokens)
+	if !ok {
+		return nil, fmt.Errorf("parent path of %q does not exist", "/"+strings.Join(tokens, "/"))
+	}
+	updated, err := update(parent)
+	if err != nil {
+		return nil, err
+	}
+	// Store the updated parent back into its own container
+	grandparent, _ := resolveJSONPointer(doc, parentTokens[:len(parentTokens)-1])
+	key := parentTokens[len(parentTokens)-1]
+	switch node := grandparent.(type) {
+	case map[string]interface{}:
+		node[key] = updated
+	case []interface{}:
+		index, _ := arrayIndex(key, len(node))
+		node[index] = updated
+	}
+	return doc, nil
+}
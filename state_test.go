@@ -0,0 +1,377 @@
+package agui
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateDeltaValidateAgainst(t *testing.T) {
+	base := map[string]interface{}{
+		"count": 1,
+		"user": map[string]interface{}{
+			"name": "Ada",
+			"tags": []interface{}{"a", "b"},
+		},
+		"a/b": true,
+	}
+
+	tests := []struct {
+		name    string
+		delta   []interface{}
+		wantErr string
+	}{
+		{
+			name: "Valid",
+			delta: []interface{}{
+				map[string]interface{}{"op": "replace", "path": "/count", "value": 2},
+				map[string]interface{}{"op": "test", "path": "/user/name", "value": "Ada"},
+				map[string]interface{}{"op": "remove", "path": "/user/tags/1"},
+				map[string]interface{}{"op": "add", "path": "/user/tags/-", "value": "c"},
+				map[string]interface{}{"op": "add", "path": "/user/email", "value": "ada@example.com"},
+				map[string]interface{}{"op": "copy", "from": "/count", "path": "/total"},
+				map[string]interface{}{"op": "remove", "path": "/a~1b"},
+			},
+		},
+		{
+			name: "ReplaceMissingPath",
+			delta: []interface{}{
+				map[string]interface{}{"op": "replace", "path": "/count", "value": 2},
+				map[string]interface{}{"op": "replace", "path": "/missing", "value": 3},
+			},
+			wantErr: "index 1",
+		},
+		{
+			name: "RemoveOutOfRange",
+			delta: []interface{}{
+				map[string]interface{}{"op": "remove", "path": "/user/tags/5"},
+			},
+			wantErr: "index 0",
+		},
+		{
+			name: "AddWithoutParent",
+			delta: []interface{}{
+				map[string]interface{}{"op": "add", "path": "/profile/age", "value": 30},
+			},
+			wantErr: "index 0",
+		},
+		{
+			name: "MoveMissingFrom",
+			delta: []interface{}{
+				map[string]interface{}{"op": "test", "path": "/count", "value": 1},
+				map[string]interface{}{"op": "test", "path": "/count", "value": 1},
+				map[string]interface{}{"op": "move", "from": "/nope", "path": "/count"},
+			},
+			wantErr: "index 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := normalizeState(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewStateDeltaEvent(tt.delta).ValidateAgainst(base)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+
+			after, err := normalizeState(base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(before, after) {
+				t.Error("ValidateAgainst must not modify the base state")
+			}
+		})
+	}
+}
+
+func TestSetStateMarshaler(t *testing.T) {
+	type agentState struct {
+		Count  int    `json:"count"`
+		Secret string `json:"secret"`
+	}
+	event := NewStateSnapshotEvent(agentState{Count: 3, Secret: "hunter2"})
+	event.Timestamp = nil
+
+	SetStateMarshaler(func(state State) ([]byte, error) {
+		if s, ok := state.(agentState); ok {
+			return json.Marshal(map[string]interface{}{"count": s.Count})
+		}
+		return json.Marshal(state)
+	})
+	defer SetStateMarshaler(nil)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	if expected := `{"type":"STATE_SNAPSHOT","snapshot":{"count":3}}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	SetStateMarshaler(nil)
+	data, err = json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal snapshot: %v", err)
+	}
+	if !strings.Contains(string(data), `"secret":"hunter2"`) {
+		t.Errorf("Expected default marshaling to include all fields, got %s", data)
+	}
+}
+
+func TestRawDeltaPreservesBytes(t *testing.T) {
+	delta := `[{"path":"/count","op":"replace","value":2},{"value":{"z":1,"a":[1,2.50]},"op":"add","path":"/items"}]`
+	input := `{"type":"STATE_DELTA","delta":` + delta + `}`
+
+	event, err := DecodeEventFromBytes([]byte(input), WithRawDelta())
+	if err != nil {
+		t.Fatalf("Failed to decode delta: %v", err)
+	}
+	stateDelta := event.(*StateDeltaEvent)
+	if stateDelta.Delta != nil || len(stateDelta.RawDelta) != 2 {
+		t.Fatalf("Expected 2 raw operations and no parsed delta, got %d raw and %v", len(stateDelta.RawDelta), stateDelta.Delta)
+	}
+
+	data, err := json.Marshal(stateDelta)
+	if err != nil {
+		t.Fatalf("Failed to encode delta: %v", err)
+	}
+	if string(data) != input {
+		t.Errorf("Expected re-encoded bytes to match input:\n got: %s\nwant: %s", data, input)
+	}
+
+	ops, err := stateDelta.Operations()
+	if err != nil {
+		t.Fatalf("Failed to parse operations: %v", err)
+	}
+	first, ok := ops[0].(map[string]interface{})
+	if !ok || first["op"] != "replace" || first["path"] != "/count" {
+		t.Errorf("Unexpected parsed operation: %v", ops[0])
+	}
+	if err := stateDelta.ValidateAgainst(map[string]interface{}{"count": 1}); err != nil {
+		t.Errorf("Unexpected error validating raw delta: %v", err)
+	}
+
+	// The default keeps the parsed form
+	event, err = DecodeEventFromBytes([]byte(input))
+	if err != nil {
+		t.Fatalf("Failed to decode delta: %v", err)
+	}
+	if parsed := event.(*StateDeltaEvent); len(parsed.Delta) != 2 || parsed.RawDelta != nil {
+		t.Errorf("Expected parsed delta by default, got %+v", parsed)
+	}
+}
+
+func TestStateDeltaNormalize(t *testing.T) {
+	op := func(name, path string, value interface{}) map[string]interface{} {
+		o := map[string]interface{}{"op": name, "path": path}
+		if value != nil {
+			o["value"] = value
+		}
+		return o
+	}
+	paths := func(delta []interface{}) []string {
+		var out []string
+		for _, o := range delta {
+			out = append(out, o.(map[string]interface{})["path"].(string))
+		}
+		return out
+	}
+
+	t.Run("Reorderable", func(t *testing.T) {
+		base := map[string]interface{}{"b": 1, "c": true, "list": []interface{}{"x", "y"}}
+		first := NewStateDeltaEvent([]interface{}{
+			op(PatchOpReplace, "/b", 2),
+			op(PatchOpRemove, "/list/1", nil),
+			op(PatchOpAdd, "/list/0", "w"),
+			op(PatchOpAdd, "/a", "new"),
+			op(PatchOpRemove, "/c", nil),
+		})
+		second := NewStateDeltaEvent([]interface{}{
+			op(PatchOpRemove, "/c", nil),
+			op(PatchOpRemove, "/list/1", nil),
+			op(PatchOpAdd, "/a", "new"),
+			op(PatchOpAdd, "/list/0", "w"),
+			op(PatchOpReplace, "/b", 2),
+		})
+
+		want := []string{"/a", "/b", "/c", "/list/1", "/list/0"}
+		for name, delta := range map[string]*StateDeltaEvent{"First": first, "Second": second} {
+			before, err := applyPatch(mustNormalize(t, base), delta.Delta)
+			if err != nil {
+				t.Fatalf("%s: failed to apply delta: %v", name, err)
+			}
+			if err := delta.Normalize(); err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+			if got := paths(delta.Delta); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: normalized order %v, want %v", name, got, want)
+			}
+			after, err := applyPatch(mustNormalize(t, base), delta.Delta)
+			if err != nil {
+				t.Fatalf("%s: failed to apply normalized delta: %v", name, err)
+			}
+			if !reflect.DeepEqual(before, after) {
+				t.Errorf("%s: normalizing changed the result from %v to %v", name, before, after)
+			}
+		}
+	})
+
+	t.Run("OrderDependentOpsKeepOrder", func(t *testing.T) {
+		delta := NewStateDeltaEvent([]interface{}{
+			op(PatchOpTest, "/z", 1),
+			op(PatchOpReplace, "/y", 2),
+			map[string]interface{}{"op": PatchOpMove, "from": "/x", "path": "/w"},
+			op(PatchOpAdd, "/v", 3),
+		})
+		if err := delta.Normalize(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		want := []string{"/z", "/y", "/w", "/v"}
+		if got := paths(delta.Delta); !reflect.DeepEqual(got, want) {
+			t.Errorf("Normalized order %v, want %v", got, want)
+		}
+	})
+
+	t.Run("RawDelta", func(t *testing.T) {
+		event, err := DecodeEventFromBytes([]byte(`{"type":"STATE_DELTA","delta":[{"op":"add","path":"/b","value":1.50},{"op":"add","path":"/a","value":1}]}`), WithRawDelta())
+		if err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		delta := event.(*StateDeltaEvent)
+		if err := delta.Normalize(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(delta.RawDelta[0]) != `{"op":"add","path":"/a","value":1}` || string(delta.RawDelta[1]) != `{"op":"add","path":"/b","value":1.50}` {
+			t.Errorf("Unexpected raw delta: %s", delta.RawDelta)
+		}
+	})
+
+	contradictory := []struct {
+		name    string
+		delta   []interface{}
+		wantErr string
+	}{
+		{
+			name:    "RemoveThenReplace",
+			delta:   []interface{}{op(PatchOpRemove, "/a", nil), op(PatchOpReplace, "/a", 1)},
+			wantErr: `index 1: replace of "/a" after it was removed at index 0`,
+		},
+		{
+			name:    "RemoveParentThenAddChild",
+			delta:   []interface{}{op(PatchOpRemove, "/user", nil), op(PatchOpAdd, "/user/name", "Ada")},
+			wantErr: `index 1: add of "/user/name" after its parent was removed at index 0`,
+		},
+		{
+			name:    "MoveAwayThenCopy",
+			delta:   []interface{}{map[string]interface{}{"op": PatchOpMove, "from": "/a", "path": "/b"}, map[string]interface{}{"op": PatchOpCopy, "from": "/a", "path": "/c"}},
+			wantErr: `index 1: copy of "/a" after it was removed at index 0`,
+		},
+		{
+			name:    "MoveIntoChild",
+			delta:   []interface{}{map[string]interface{}{"op": PatchOpMove, "from": "/a", "path": "/a/b"}},
+			wantErr: "into its own child",
+		},
+		{
+			name:    "UnknownOp",
+			delta:   []interface{}{op("merge", "/a", 1)},
+			wantErr: `unknown op "merge"`,
+		},
+	}
+	for _, tt := range contradictory {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewStateDeltaEvent(tt.delta).Normalize()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	recreated := NewStateDeltaEvent([]interface{}{
+		op(PatchOpRemove, "/a", nil),
+		op(PatchOpAdd, "/a", map[string]interface{}{}),
+		op(PatchOpAdd, "/a/b", 1),
+		op(PatchOpRemove, "/list/0", nil),
+		op(PatchOpReplace, "/list/0", 2),
+	})
+	if err := recreated.Normalize(); err != nil {
+		t.Errorf("Unexpected error for recreated paths: %v", err)
+	}
+}
+
+func mustNormalize(t *testing.T, state State) interface{} {
+	t.Helper()
+	doc, err := normalizeState(state)
+	if err != nil {
+		t.Fatalf("Failed to normalize state: %v", err)
+	}
+	return doc
+}
+
+func TestSeedStateFromStructSnapshot(t *testing.T) {
+	type session struct {
+		UpdatedAt time.Time
+		Counter   opaqueCounter
+		Tags      []string
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	snap := NewStateSnapshotEvent(session{UpdatedAt: at, Counter: opaqueCounter{n: 5}, Tags: []string{"a"}})
+
+	input := &RunAgentInput{ThreadID: "thread_1", RunID: "run_2"}
+	input.SeedState(snap)
+	seeded := input.State.(session)
+	if !seeded.UpdatedAt.Equal(at) || seeded.Counter.n != 5 {
+		t.Errorf("Expected the struct state to be copied intact, got %+v", seeded)
+	}
+
+	seeded.Tags[0] = "b"
+	if snap.Snapshot.(session).Tags[0] != "a" {
+		t.Error("Expected the snapshot's exported slice not to be aliased")
+	}
+}
+
+func TestSeedStateFromSnapshot(t *testing.T) {
+	snap := NewStateSnapshotEvent(map[string]interface{}{
+		"step": float64(2),
+		"user": map[string]interface{}{"name": "Ada", "tags": []interface{}{"a"}},
+	})
+
+	input := &RunAgentInput{ThreadID: "thread_1", RunID: "run_2"}
+	input.SeedState(snap)
+	if err := input.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+	if !reflect.DeepEqual(input.State, snap.Snapshot) {
+		t.Fatalf("State = %v, want %v", input.State, snap.Snapshot)
+	}
+
+	user := input.State.(map[string]interface{})["user"].(map[string]interface{})
+	user["name"] = "Grace"
+	user["tags"].([]interface{})[0] = "b"
+	original := snap.Snapshot.(map[string]interface{})["user"].(map[string]interface{})
+	if original["name"] != "Ada" || original["tags"].([]interface{})[0] != "a" {
+		t.Errorf("Expected the snapshot not to be aliased, got %v", original)
+	}
+
+	data, err := input.Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode input: %v", err)
+	}
+	if !strings.Contains(string(data), `"step":2`) {
+		t.Errorf("Expected encoded input to carry the seeded state, got %s", data)
+	}
+
+	input.SeedState(nil)
+	if input.State != nil {
+		t.Errorf("Expected a nil snapshot to clear the state, got %v", input.State)
+	}
+}
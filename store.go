@@ -0,0 +1,97 @@
+package agui
+
+import (
+	"fmt"
+	"sync"
+)
+
+// StateStore maintains the current agent state as state events stream in,
+// replacing it on STATE_SNAPSHOT and patching it on STATE_DELTA. It is safe
+// for concurrent use.
+type StateStore struct {
+	mu       sync.RWMutex
+	state    interface{}
+	hasState bool
+}
+
+// NewStateStore creates a new StateStore with no state.
+func NewStateStore() *StateStore {
+	return &StateStore{}
+}
+
+// Apply updates the store from event. Events other than state snapshots and
+// deltas are ignored. Applying a delta before any snapshot is an error, as is a
+// delta that does not apply cleanly; in either case the state is left unchanged.
+func (s *StateStore) Apply(event Event) error {
+	switch e := event.(type) {
+	case *StateSnapshotEvent:
+		state, err := normalizeState(e.Snapshot)
+		if err != nil {
+			return fmt.Errorf("invalid state snapshot: %w", err)
+		}
+		s.mu.Lock()
+		s.state, s.hasState = state, true
+		s.mu.Unlock()
+
+	case *StateDeltaEvent:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.hasState {
+			return fmt.Errorf("state delta received before any state snapshot")
+		}
+		// Patch a copy so a failing operation leaves the current state intact
+		doc, err := normalizeState(s.state)
+		if err != nil {
+			return err
+		}
+		ops, err := e.Operations()
+		if err != nil {
+			return err
+		}
+		patched, err := applyPatch(doc, ops)
+		if err != nil {
+			return err
+		}
+		s.state = patched
+	}
+	return nil
+}
+
+// State returns a copy of the current state, or nil if no snapshot has been applied.
+func (s *StateStore) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.hasState {
+		return nil
+	}
+	state, _ := normalizeState(s.state)
+	return state
+}
+
+// CheckpointState folds deltas, in order, into snapshot and returns a new
+// StateSnapshotEvent holding the resulting state with a fresh timestamp. Neither
+// snapshot nor deltas are modified.
+func CheckpointState(snapshot *StateSnapshotEvent, deltas ...*StateDeltaEvent) (*StateSnapshotEvent, error) {
+	if snapshot == nil {
+		return nil, fmt.Errorf("state snapshot is required")
+	}
+	state, err := normalizeState(snapshot.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state snapshot: %w", err)
+	}
+
+	for i, delta := range deltas {
+		if delta == nil {
+			return nil, fmt.Errorf("invalid delta at index %d: delta is nil", i)
+		}
+		ops, err := delta.Operations()
+		if err == nil {
+			state, err = applyPatch(state, ops)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta at index %d: %w", i, err)
+		}
+	}
+
+	return NewStateSnapshotEvent(state), nil
+}
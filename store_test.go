@@ -0,0 +1,131 @@
+package agui
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStateStore(t *testing.T) {
+	store := NewStateStore()
+
+	snapshot := NewStateSnapshotEvent(map[string]interface{}{
+		"count": 1,
+		"items": []interface{}{"a"},
+	})
+	if err := store.Apply(snapshot); err != nil {
+		t.Fatalf("Failed to apply snapshot: %v", err)
+	}
+
+	deltas := []Event{
+		NewStateDeltaEvent([]interface{}{
+			map[string]interface{}{"op": "replace", "path": "/count", "value": 2},
+			map[string]interface{}{"op": "add", "path": "/items/-", "value": "b"},
+		}),
+		NewStateDeltaEvent([]interface{}{
+			map[string]interface{}{"op": "add", "path": "/items/0", "value": "first"},
+			map[string]interface{}{"op": "move", "from": "/count", "path": "/total"},
+		}),
+	}
+	for _, delta := range deltas {
+		if err := store.Apply(delta); err != nil {
+			t.Fatalf("Failed to apply delta: %v", err)
+		}
+	}
+
+	expected := map[string]interface{}{
+		"total": float64(2),
+		"items": []interface{}{"first", "a", "b"},
+	}
+	if !reflect.DeepEqual(store.State(), expected) {
+		t.Errorf("Expected state %v, got %v", expected, store.State())
+	}
+
+	// A failing delta leaves the state untouched
+	failing := NewStateDeltaEvent([]interface{}{
+		map[string]interface{}{"op": "remove", "path": "/items/0"},
+		map[string]interface{}{"op": "test", "path": "/total", "value": 3},
+	})
+	if err := store.Apply(failing); err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("Expected error for failed test operation, got %v", err)
+	}
+	if !reflect.DeepEqual(store.State(), expected) {
+		t.Errorf("State changed after failed delta: %v", store.State())
+	}
+}
+
+func TestStateStoreDeltaBeforeSnapshot(t *testing.T) {
+	store := NewStateStore()
+	delta := NewStateDeltaEvent([]interface{}{
+		map[string]interface{}{"op": "add", "path": "/count", "value": 1},
+	})
+	if err := store.Apply(delta); err == nil || !strings.Contains(err.Error(), "before any state snapshot") {
+		t.Errorf("Expected error for delta before snapshot, got %v", err)
+	}
+	if store.State() != nil {
+		t.Errorf("Expected nil state, got %v", store.State())
+	}
+}
+
+func TestStateStoreConcurrentAccess(t *testing.T) {
+	store := NewStateStore()
+	if err := store.Apply(NewStateSnapshotEvent(map[string]interface{}{"count": 0})); err != nil {
+		t.Fatalf("Failed to apply snapshot: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = store.Apply(NewStateDeltaEvent([]interface{}{
+				map[string]interface{}{"op": "replace", "path": "/count", "value": 1},
+			}))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = store.State()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCheckpointState(t *testing.T) {
+	snapshot := NewStateSnapshotEvent(map[string]interface{}{"count": 1, "items": []interface{}{"a"}})
+	*snapshot.Timestamp = 1
+
+	deltas := []*StateDeltaEvent{
+		NewStateDeltaEvent([]interface{}{
+			map[string]interface{}{"op": "replace", "path": "/count", "value": 2},
+		}),
+		NewStateDeltaEvent([]interface{}{
+			map[string]interface{}{"op": "add", "path": "/items/-", "value": "b"},
+		}),
+	}
+
+	checkpoint, err := CheckpointState(snapshot, deltas...)
+	if err != nil {
+		t.Fatalf("Failed to checkpoint state: %v", err)
+	}
+	expected := map[string]interface{}{"count": float64(2), "items": []interface{}{"a", "b"}}
+	if !reflect.DeepEqual(checkpoint.Snapshot, expected) {
+		t.Errorf("Expected state %v, got %v", expected, checkpoint.Snapshot)
+	}
+	if checkpoint.Timestamp == nil || *checkpoint.Timestamp == 1 {
+		t.Error("Expected a fresh timestamp on the checkpoint")
+	}
+	if err := checkpoint.Validate(); err != nil {
+		t.Errorf("Checkpoint is invalid: %v", err)
+	}
+	if snapshot.Snapshot.(map[string]interface{})["count"] != 1 {
+		t.Error("Original snapshot was modified")
+	}
+
+	bad := NewStateDeltaEvent([]interface{}{
+		map[string]interface{}{"op": "remove", "path": "/missing"},
+	})
+	if _, err := CheckpointState(snapshot, deltas[0], bad); err == nil || !strings.Contains(err.Error(), "delta at index 1") {
+		t.Errorf("Expected error naming delta index 1, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package agui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// streamTee buffers the bytes a StreamDecoder reads so that they can be
+// forwarded, unchanged, once the values they hold have been decoded.
+type streamTee struct {
+	buffered  bytes.Buffer
+	writer    io.Writer
+	forwarded int64 // input offset up to which bytes have been forwarded
+}
+
+// NewTeeStreamDecoder creates a StreamDecoder that reads from r and, as each
+// value is decoded, writes the input bytes up to the end of that value to w
+// before delivering it. The bytes are copied exactly as read, including
+// whitespace and delimiters, so a proxy can forward the upstream stream while
+// inspecting its events. Bytes of a value that fails to decode are not written;
+// any input after the last value is written when the stream ends. WithLenientJSON
+// must not be used, since it makes input offsets refer to the cleaned input.
+func NewTeeStreamDecoder(r io.Reader, w io.Writer, opts ...DecoderOption) *StreamDecoder {
+	tee := &streamTee{writer: w}
+	return &StreamDecoder{
+		decoder: NewDecoder(io.TeeReader(r, &tee.buffered), opts...),
+		tee:     tee,
+	}
+}
+
+// forward writes the buffered input up to offset. It does nothing on a nil tee.
+func (t *streamTee) forward(offset int64) error {
+	if t == nil || offset <= t.forwarded {
+		return nil
+	}
+	chunk := t.buffered.Next(int(offset - t.forwarded))
+	t.forwarded = offset
+	if _, err := t.writer.Write(chunk); err != nil {
+		return fmt.Errorf("agui: failed to write tee'd input: %w", err)
+	}
+	return nil
+}
+
+// flush writes all remaining buffered input. It does nothing on a nil tee.
+func (t *streamTee) flush() error {
+	if t == nil {
+		return nil
+	}
+	return t.forward(t.forwarded + int64(t.buffered.Len()))
+}
@@ -0,0 +1,56 @@
+package agui
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTeeStreamDecoder(t *testing.T) {
+	input := "  {\"type\":\"RUN_STARTED\",  \"threadId\":\"thread_1\",\"runId\":\"run_1\"}\n" +
+		"{\"type\":\"TEXT_MESSAGE_START\",\"messageId\":\"msg_1\",\"role\":\"assistant\",\"extra\":1.50}" +
+		"{\"type\":\"TEXT_MESSAGE_CONTENT\",\"messageId\":\"msg_1\",\"delta\":\"caf\\u00e9\"}\r\n" +
+		"{\"type\":\"TEXT_MESSAGE_END\",\"messageId\":\"msg_1\"}\n\n"
+
+	var out bytes.Buffer
+	eventChan, errorChan := NewTeeStreamDecoder(strings.NewReader(input), &out).DecodeEvents()
+
+	var got []EventType
+	for event := range eventChan {
+		got = append(got, event.GetType())
+	}
+	if err := <-errorChan; err != nil {
+		t.Fatalf("Unexpected stream error: %v", err)
+	}
+
+	want := []EventType{EventTypeRunStarted, EventTypeTextMessageStart, EventTypeTextMessageContent, EventTypeTextMessageEnd}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decoded %v, want %v", got, want)
+	}
+	if out.String() != input {
+		t.Errorf("Tee'd output differs from input:\n got %q\nwant %q", out.String(), input)
+	}
+}
+
+func TestTeeStreamDecoderStopsAtInvalidEvent(t *testing.T) {
+	valid := `{"type":"RUN_STARTED","threadId":"thread_1","runId":"run_1"}`
+	input := valid + "\n" + `{"type":"UNKNOWN"}` + "\n"
+
+	var out bytes.Buffer
+	eventChan, errorChan := NewTeeStreamDecoder(strings.NewReader(input), &out, WithDecodeDelimiter([]byte("\n"))).DecodeEvents()
+	count := 0
+	for range eventChan {
+		count++
+	}
+	if err := <-errorChan; !errors.Is(err, ErrInvalidEventType) {
+		t.Errorf("Expected ErrInvalidEventType, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 event, got %d", count)
+	}
+	if out.String() != valid+"\n" {
+		t.Errorf("Expected only the valid event to be forwarded, got %q", out.String())
+	}
+}
@@ -0,0 +1,146 @@
+package agui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextMessageAssembler reassembles text messages streamed as TextMessageStartEvent,
+// TextMessageContentEvent and TextMessageEndEvent into AssistantMessages, or
+// messages of the role declared by the start event when created with
+// WithLenientRoles. Messages with different IDs may be interleaved.
+type TextMessageAssembler struct {
+	pending      map[string]*pendingTextMessage
+	lenientRoles bool
+}
+
+// pendingTextMessage accumulates a streamed text message until its end event.
+type pendingTextMessage struct {
+	role    Role
+	content strings.Builder
+}
+
+// TextMessageAssemblerOption configures a TextMessageAssembler.
+type TextMessageAssemblerOption func(*TextMessageAssembler)
+
+// WithLenientRoles makes the assembler produce messages with the role declared
+// by each TextMessageStartEvent, which must be assistant, user, system or
+// developer; an empty role means assistant. Use AddMessage to receive messages
+// of any role. By default the declared role is ignored and every message is an
+// AssistantMessage, as the AG-UI specification requires. Decode such events
+// with WithLenientTextRoles, since they otherwise fail validation.
+func WithLenientRoles() TextMessageAssemblerOption {
+	return func(a *TextMessageAssembler) {
+		a.lenientRoles = true
+	}
+}
+
+// NewTextMessageAssembler creates a new, empty TextMessageAssembler.
+func NewTextMessageAssembler(opts ...TextMessageAssemblerOption) *TextMessageAssembler {
+	a := &TextMessageAssembler{pending: make(map[string]*pendingTextMessage)}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Add feeds an event to the assembler. When event completes a text message,
+// the reassembled AssistantMessage is returned; otherwise the result is nil.
+// Events other than text message events are ignored. With WithLenientRoles,
+// completing a message with another role is an error; use AddMessage instead.
+func (a *TextMessageAssembler) Add(event Event) (*AssistantMessage, error) {
+	message, err := a.AddMessage(event)
+	if message == nil || err != nil {
+		return nil, err
+	}
+	assistant, ok := message.(*AssistantMessage)
+	if !ok {
+		return nil, fmt.Errorf("text message %s has role %s, not assistant", message.GetID(), message.GetRole())
+	}
+	return assistant, nil
+}
+
+// AddMessage is like Add, but returns the completed message as a Message of the
+// role declared by its start event when WithLenientRoles is enabled.
+func (a *TextMessageAssembler) AddMessage(event Event) (Message, error) {
+	switch e := event.(type) {
+	case *TextMessageStartEvent:
+		if _, ok := a.pending[e.MessageID]; ok {
+			return nil, fmt.Errorf("text message %s already started", e.MessageID)
+		}
+		role := RoleAssistant
+		if a.lenientRoles && e.Role != "" {
+			switch e.Role {
+			case RoleAssistant, RoleUser, RoleSystem, RoleDeveloper:
+				role = e.Role
+			default:
+				return nil, fmt.Errorf("text message %s cannot have role %s", e.MessageID, e.Role)
+			}
+		}
+		a.pending[e.MessageID] = &pendingTextMessage{role: role}
+
+	case *TextMessageContentEvent:
+		p, ok := a.pending[e.MessageID]
+		if !ok {
+			return nil, fmt.Errorf("text message content for %s without start", e.MessageID)
+		}
+		p.content.WriteString(e.Delta)
+
+	case *TextMessageEndEvent:
+		p, ok := a.pending[e.MessageID]
+		if !ok {
+			return nil, fmt.Errorf("text message end for %s without start", e.MessageID)
+		}
+		delete(a.pending, e.MessageID)
+
+		content := p.content.String()
+		switch p.role {
+		case RoleUser:
+			return NewUserMessage(e.MessageID, content, ""), nil
+		case RoleSystem:
+			return NewSystemMessage(e.MessageID, content, ""), nil
+		case RoleDeveloper:
+			return NewDeveloperMessage(e.MessageID, content, ""), nil
+		}
+		return NewAssistantMessage(e.MessageID, content, "", nil), nil
+	}
+
+	return nil, nil
+}
+
+// Pending returns the number of text messages that have started but not ended.
+func (a *TextMessageAssembler) Pending() int {
+	return len(a.pending)
+}
+
+// FinalAssistantText returns the content of the last text message completed in
+// events, reassembled from its content deltas, and false if no text message
+// was completed. Malformed text message events are skipped.
+func FinalAssistantText(events []Event) (string, bool) {
+	assembler := NewTextMessageAssembler()
+	var final *AssistantMessage
+	for _, event := range events {
+		message, err := assembler.Add(event)
+		if err == nil && message != nil {
+			final = message
+		}
+	}
+	if final == nil {
+		return "", false
+	}
+	return final.Content, true
+}
+
+// MessageContentStats returns the number of characters streamed for each text
+// message in events, keyed by message ID. Characters are counted as runes
+// across the message's TextMessageContentEvent deltas.
+func MessageContentStats(events []Event) map[string]int {
+	stats := make(map[string]int)
+	for _, event := range events {
+		if content, ok := event.(*TextMessageContentEvent); ok {
+			stats[content.MessageID] += utf8.RuneCountInString(content.Delta)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,146 @@
+package agui
+
+import (
+	"testing"
+)
+
+// exampleStreamingEvents returns the conversation used by ExampleStreamingEvents.
+func exampleStreamingEvents() []Event {
+	events := []Event{
+		&RunStartedEvent{BaseEvent: BaseEvent{Type: EventTypeRunStarted}, ThreadID: "thread_1", RunID: "run_1"},
+		&TextMessageStartEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageStart}, MessageID: "msg_1", Role: RoleAssistant},
+	}
+	for _, delta := range []string{"Hello", " there!", " How", " can", " I", " help?"} {
+		events = append(events, &TextMessageContentEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageContent}, MessageID: "msg_1", Delta: delta})
+	}
+	return append(events,
+		&TextMessageEndEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageEnd}, MessageID: "msg_1"},
+		&RunFinishedEvent{BaseEvent: BaseEvent{Type: EventTypeRunFinished}, ThreadID: "thread_1", RunID: "run_1"},
+	)
+}
+
+func TestFinalAssistantText(t *testing.T) {
+	text, ok := FinalAssistantText(exampleStreamingEvents())
+	if !ok {
+		t.Fatal("Expected a final assistant text")
+	}
+	if text != "Hello there! How can I help?" {
+		t.Errorf("Expected %q, got %q", "Hello there! How can I help?", text)
+	}
+
+	// An unfinished message doesn't count
+	events := append(exampleStreamingEvents(),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_2", "Partial"),
+	)
+	if text, _ := FinalAssistantText(events); text != "Hello there! How can I help?" {
+		t.Errorf("Expected the last completed message, got %q", text)
+	}
+
+	if _, ok := FinalAssistantText([]Event{NewRunStartedEvent("thread_1", "run_1")}); ok {
+		t.Error("Expected no final text without text messages")
+	}
+}
+
+func TestTextMessageAssembler(t *testing.T) {
+	assembler := NewTextMessageAssembler()
+	events := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageContentEvent("msg_2", "Bye"),
+		NewTextMessageContentEvent("msg_1", " world"),
+		NewTextMessageEndEvent("msg_1"),
+	}
+
+	var completed []*AssistantMessage
+	for _, event := range events {
+		message, err := assembler.Add(event)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if message != nil {
+			completed = append(completed, message)
+		}
+	}
+
+	if len(completed) != 1 || completed[0].ID != "msg_1" || completed[0].Content != "Hello world" {
+		t.Errorf("Unexpected completed messages: %+v", completed)
+	}
+	if err := completed[0].Validate(); err != nil {
+		t.Errorf("Reassembled message is invalid: %v", err)
+	}
+	if assembler.Pending() != 1 {
+		t.Errorf("Expected 1 pending message, got %d", assembler.Pending())
+	}
+	if _, err := assembler.Add(NewTextMessageEndEvent("msg_3")); err == nil {
+		t.Error("Expected error for end without start")
+	}
+}
+
+func TestMessageContentStats(t *testing.T) {
+	events := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Héllo"),
+		NewTextMessageContentEvent("msg_1", ", 世界"),
+		NewTextMessageContentEvent("msg_2", "🙂"),
+		NewTextMessageEndEvent("msg_1"),
+	}
+
+	stats := MessageContentStats(events)
+	if stats["msg_1"] != 9 {
+		t.Errorf("Expected 9 runes for msg_1, got %d", stats["msg_1"])
+	}
+	if stats["msg_2"] != 1 {
+		t.Errorf("Expected 1 rune for msg_2, got %d", stats["msg_2"])
+	}
+	if len(stats) != 2 {
+		t.Errorf("Expected stats for 2 messages, got %d", len(stats))
+	}
+}
+
+func TestTextMessageAssemblerLenientRoles(t *testing.T) {
+	userStart := &TextMessageStartEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageStart}, MessageID: "msg_1", Role: RoleUser}
+	events := []Event{
+		userStart,
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageEndEvent("msg_1"),
+	}
+
+	lenient := NewTextMessageAssembler(WithLenientRoles())
+	var message Message
+	for _, event := range events {
+		m, err := lenient.AddMessage(event)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if m != nil {
+			message = m
+		}
+	}
+	user, ok := message.(*UserMessage)
+	if !ok || user.ID != "msg_1" || user.Content != "Hello" {
+		t.Fatalf("Expected a user message, got %#v", message)
+	}
+	if err := user.Validate(); err != nil {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+
+	lenient = NewTextMessageAssembler(WithLenientRoles())
+	lenient.Add(userStart)
+	if _, err := lenient.Add(NewTextMessageEndEvent("msg_1")); err == nil {
+		t.Error("Expected Add to reject a completed user message")
+	}
+
+	toolStart := &TextMessageStartEvent{BaseEvent: BaseEvent{Type: EventTypeTextMessageStart}, MessageID: "msg_2", Role: RoleTool}
+	if _, err := NewTextMessageAssembler(WithLenientRoles()).AddMessage(toolStart); err == nil {
+		t.Error("Expected a tool role to be rejected")
+	}
+
+	strict := NewTextMessageAssembler()
+	strict.Add(userStart)
+	assistant, err := strict.Add(NewTextMessageEndEvent("msg_1"))
+	if err != nil || assistant == nil || assistant.Role != RoleAssistant {
+		t.Errorf("Expected the default assembler to produce an assistant message, got %#v, %v", assistant, err)
+	}
+}
@@ -0,0 +1,327 @@
+package agui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ToolCallRecord is a flattened view of a single tool call and its result.
+type ToolCallRecord struct {
+	ID        string // Unique identifier for the tool call
+	Name      string // Name of the tool that was called
+	Arguments string // JSON-encoded arguments passed to the tool
+	Result    string // Result content, empty if no result was seen
+}
+
+// ExtractToolCalls collects every tool call found in events, in order of first
+// appearance, and links each call to its result by tool call ID.
+//
+// Both the streamed form (ToolCallStartEvent, ToolCallArgsEvent and
+// ToolCallResultEvent) and the snapshot form (AssistantMessage.ToolCalls and
+// ToolMessage inside a MessagesSnapshotEvent) are supported. When a call appears
+// in both forms, fields already set from the stream are kept.
+func ExtractToolCalls(events []Event) []ToolCallRecord {
+	var records []ToolCallRecord
+	index := make(map[string]int)
+	args := make(map[string]*strings.Builder)
+
+	record := func(id string) *ToolCallRecord {
+		i, ok := index[id]
+		if !ok {
+			i = len(records)
+			index[id] = i
+			records = append(records, ToolCallRecord{ID: id})
+		}
+		return &records[i]
+	}
+
+	for _, event := range events {
+		switch e := event.(type) {
+		case *ToolCallStartEvent:
+			r := record(e.ToolCallID)
+			if r.Name == "" {
+				r.Name = e.ToolCallName
+			}
+		case *ToolCallArgsEvent:
+			record(e.ToolCallID)
+			b, ok := args[e.ToolCallID]
+			if !ok {
+				b = &strings.Builder{}
+				args[e.ToolCallID] = b
+			}
+			b.WriteString(e.Delta)
+		case *ToolCallResultEvent:
+			record(e.ToolCallID).Result = e.Content
+		case *MessagesSnapshotEvent:
+			for _, msg := range e.Messages {
+				switch m := msg.(type) {
+				case *AssistantMessage:
+					for _, tc := range m.ToolCalls {
+						r := record(tc.ID)
+						if r.Name == "" {
+							r.Name = tc.Function.Name
+						}
+						if _, streamed := args[tc.ID]; !streamed && r.Arguments == "" {
+							r.Arguments = tc.Function.Arguments
+						}
+					}
+				case *ToolMessage:
+					r := record(m.ToolCallID)
+					if r.Result == "" {
+						r.Result = m.Content
+					}
+				}
+			}
+		}
+	}
+
+	for id, b := range args {
+		records[index[id]].Arguments = b.String()
+	}
+	return records
+}
+
+// JSONArgumentsEqual reports whether two JSON-encoded tool call argument strings
+// hold the same value, ignoring object key order and whitespace. It returns an
+// error if either string is not valid JSON.
+func JSONArgumentsEqual(a, b string) (bool, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal([]byte(a), &va); err != nil {
+		return false, fmt.Errorf("first arguments are not valid JSON: %w", err)
+	}
+	if err := json.Unmarshal([]byte(b), &vb); err != nil {
+		return false, fmt.Errorf("second arguments are not valid JSON: %w", err)
+	}
+	return reflect.DeepEqual(va, vb), nil
+}
+
+// ToolCallResultAssembler reassembles tool call results streamed as
+// ToolCallResultStartEvent, ToolCallResultChunkEvent and ToolCallResultEndEvent
+// into single ToolCallResultEvents. Results for different tool calls may be interleaved.
+type ToolCallResultAssembler struct {
+	pending map[string]*pendingToolCallResult
+}
+
+// pendingToolCallResult accumulates the chunks of a tool call result in progress.
+type pendingToolCallResult struct {
+	start   *ToolCallResultStartEvent
+	content strings.Builder
+}
+
+// NewToolCallResultAssembler creates a new, empty ToolCallResultAssembler.
+func NewToolCallResultAssembler() *ToolCallResultAssembler {
+	return &ToolCallResultAssembler{pending: make(map[string]*pendingToolCallResult)}
+}
+
+// Add feeds an event to the assembler. When event completes a streamed result,
+// the reassembled ToolCallResultEvent is returned; otherwise the result is nil.
+// Events other than chunked tool call result events are ignored.
+func (a *ToolCallResultAssembler) Add(event Event) (*ToolCallResultEvent, error) {
+	switch e := event.(type) {
+	case *ToolCallResultStartEvent:
+		if _, ok := a.pending[e.ToolCallID]; ok {
+			return nil, fmt.Errorf("tool call result for %s already started", e.ToolCallID)
+		}
+		a.pending[e.ToolCallID] = &pendingToolCallResult{start: e}
+		return nil, nil
+
+	case *ToolCallResultChunkEvent:
+		p, ok := a.pending[e.ToolCallID]
+		if !ok {
+			return nil, fmt.Errorf("tool call result chunk for %s without start", e.ToolCallID)
+		}
+		if e.MessageID != p.start.MessageID {
+			return nil, fmt.Errorf("tool call result chunk for %s has message ID %s, expected %s", e.ToolCallID, e.MessageID, p.start.MessageID)
+		}
+		p.content.WriteString(e.Delta)
+		return nil, nil
+
+	case *ToolCallResultEndEvent:
+		p, ok := a.pending[e.ToolCallID]
+		if !ok {
+			return nil, fmt.Errorf("tool call result end for %s without start", e.ToolCallID)
+		}
+		if e.MessageID != p.start.MessageID {
+			return nil, fmt.Errorf("tool call result end for %s has message ID %s, expected %s", e.ToolCallID, e.MessageID, p.start.MessageID)
+		}
+		delete(a.pending, e.ToolCallID)
+
+		result := &ToolCallResultEvent{
+			BaseEvent: BaseEvent{
+				Type:      EventTypeToolCallResult,
+				Timestamp: e.Timestamp,
+			},
+			MessageID:  p.start.MessageID,
+			ToolCallID: e.ToolCallID,
+			Content:    p.content.String(),
+			Role:       p.start.Role,
+		}
+		return result, result.Validate()
+	}
+
+	return nil, nil
+}
+
+// Pending returns the number of streamed tool call results that have started but not ended.
+func (a *ToolCallResultAssembler) Pending() int {
+	return len(a.pending)
+}
+
+// ToolResultBuilder reassembles tool call results streamed as chunked
+// ToolCallResult* events into ToolMessages.
+type ToolResultBuilder struct {
+	assembler *ToolCallResultAssembler
+}
+
+// NewToolResultBuilder creates a new, empty ToolResultBuilder.
+func NewToolResultBuilder() *ToolResultBuilder {
+	return &ToolResultBuilder{assembler: NewToolCallResultAssembler()}
+}
+
+// Add feeds an event to the builder. When event ends a streamed result, a
+// ToolMessage with the concatenated content is returned; otherwise the result
+// is nil. Chunks and end events for tool calls without a started result are
+// errors. Events other than chunked tool call result events are ignored.
+func (b *ToolResultBuilder) Add(event Event) (*ToolMessage, error) {
+	result, err := b.assembler.Add(event)
+	if result == nil || err != nil {
+		return nil, err
+	}
+	return NewToolMessage(result.MessageID, result.Content, result.ToolCallID, "", ""), nil
+}
+
+// ToolRegistry holds the tools an agent may call, by name, with their parameter
+// schemas normalized for validating tool call arguments.
+type ToolRegistry struct {
+	tools   map[string]Tool
+	schemas map[string]map[string]interface{}
+}
+
+// NewToolRegistry creates a ToolRegistry holding tools. It returns an error if a
+// tool is invalid or its parameters do not form a JSON object.
+func NewToolRegistry(tools ...Tool) (*ToolRegistry, error) {
+	r := &ToolRegistry{
+		tools:   make(map[string]Tool),
+		schemas: make(map[string]map[string]interface{}),
+	}
+	for _, tool := range tools {
+		if err := r.Register(tool); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds tool to the registry, replacing any tool with the same name.
+func (r *ToolRegistry) Register(tool Tool) error {
+	if err := tool.Validate(); err != nil {
+		return fmt.Errorf("invalid tool %s: %w", tool.Name, err)
+	}
+	normalized, err := normalizeState(tool.Parameters)
+	if err != nil {
+		return fmt.Errorf("invalid parameters for tool %s: %w", tool.Name, err)
+	}
+	schema, ok := normalized.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid parameters for tool %s: schema must be a JSON object", tool.Name)
+	}
+	r.tools[tool.Name] = tool
+	r.schemas[tool.Name] = schema
+	return nil
+}
+
+// Lookup returns the tool registered under name.
+func (r *ToolRegistry) Lookup(name string) (Tool, bool) {
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// ValidateArguments checks call's arguments against the parameter schema of the
+// tool it names, using the JSON Schema subset supported by RegisterCustomSchema.
+// Empty arguments are treated as an empty object, so they fail only when the
+// tool declares required parameters. Calls to unregistered tools are not checked.
+func (r *ToolRegistry) ValidateArguments(call FunctionCall) error {
+	schema, ok := r.schemas[call.Name]
+	if !ok {
+		return nil
+	}
+	arguments := call.Arguments
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(arguments), &value); err != nil {
+		return fmt.Errorf("function %s: arguments must be valid JSON: %w", call.Name, err)
+	}
+	if err := matchSchema(schema, value, "arguments"); err != nil {
+		return fmt.Errorf("function %s: %w", call.Name, err)
+	}
+	return nil
+}
+
+// ToolCallAccumulator reassembles tool calls streamed as ToolCallStartEvent,
+// ToolCallArgsEvent and ToolCallEndEvent into ToolCalls. Calls with different
+// IDs may be interleaved. When created with a ToolRegistry, the completed
+// arguments are validated against the called tool's parameter schema.
+type ToolCallAccumulator struct {
+	registry *ToolRegistry
+	pending  map[string]*pendingToolCall
+}
+
+// NewToolCallAccumulator creates a new, empty ToolCallAccumulator. registry may
+// be nil, in which case arguments are not validated.
+func NewToolCallAccumulator(registry *ToolRegistry) *ToolCallAccumulator {
+	return &ToolCallAccumulator{registry: registry, pending: make(map[string]*pendingToolCall)}
+}
+
+// Add feeds an event to the accumulator. When event completes a tool call, the
+// reassembled ToolCall is returned; otherwise the result is nil. If the
+// arguments do not match the tool's schema, the ToolCall is returned together
+// with the error. Events other than tool call events are ignored.
+func (a *ToolCallAccumulator) Add(event Event) (*ToolCall, error) {
+	switch e := event.(type) {
+	case *ToolCallStartEvent:
+		if _, ok := a.pending[e.ToolCallID]; ok {
+			return nil, fmt.Errorf("tool call %s already started", e.ToolCallID)
+		}
+		a.pending[e.ToolCallID] = &pendingToolCall{name: e.ToolCallName, parentID: e.ParentMessageID}
+
+	case *ToolCallArgsEvent:
+		call, ok := a.pending[e.ToolCallID]
+		if !ok {
+			return nil, fmt.Errorf("tool call args for %s without start", e.ToolCallID)
+		}
+		call.arguments.WriteString(e.Delta)
+
+	case *ToolCallEndEvent:
+		call, ok := a.pending[e.ToolCallID]
+		if !ok {
+			return nil, fmt.Errorf("tool call end for %s without start", e.ToolCallID)
+		}
+		delete(a.pending, e.ToolCallID)
+
+		toolCall := &ToolCall{
+			ID:   e.ToolCallID,
+			Type: ToolCallTypeFunction,
+			Function: FunctionCall{
+				Name:      call.name,
+				Arguments: call.arguments.String(),
+			},
+		}
+		if a.registry != nil {
+			if err := a.registry.ValidateArguments(toolCall.Function); err != nil {
+				return toolCall, fmt.Errorf("tool call %s: %w", e.ToolCallID, err)
+			}
+		}
+		return toolCall, nil
+	}
+
+	return nil, nil
+}
+
+// Pending returns the number of tool calls that have started but not ended.
+func (a *ToolCallAccumulator) Pending() int {
+	return len(a.pending)
+}
@@ -0,0 +1,294 @@
+package agui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractToolCallsStreamed(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+		NewToolCallArgsEvent("tool_call_1", `{"query":`),
+		NewToolCallArgsEvent("tool_call_1", `"weather"}`),
+		NewToolCallEndEvent("tool_call_1"),
+		NewToolCallStartEvent("tool_call_2", "lookup", "msg_1"),
+		NewToolCallArgsEvent("tool_call_2", `{}`),
+		NewToolCallEndEvent("tool_call_2"),
+		NewToolCallResultEvent("msg_2", "tool_call_1", "Sunny"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	expected := []ToolCallRecord{
+		{ID: "tool_call_1", Name: "search", Arguments: `{"query":"weather"}`, Result: "Sunny"},
+		{ID: "tool_call_2", Name: "lookup", Arguments: `{}`},
+	}
+
+	if got := ExtractToolCalls(events); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExtractToolCalls mismatch:\nexpected %+v\ngot      %+v", expected, got)
+	}
+}
+
+func TestExtractToolCallsSnapshot(t *testing.T) {
+	events := []Event{
+		NewMessagesSnapshotEvent([]Message{
+			NewUserMessage("msg_1", "What's the weather?", ""),
+			NewAssistantMessage("msg_2", "", "", []ToolCall{
+				{ID: "tool_call_1", Type: ToolCallTypeFunction, Function: FunctionCall{Name: "search", Arguments: `{"query":"weather"}`}},
+				{ID: "tool_call_2", Type: ToolCallTypeFunction, Function: FunctionCall{Name: "lookup", Arguments: `{}`}},
+			}),
+			NewToolMessage("msg_3", "Sunny", "tool_call_1", "", ""),
+		}),
+	}
+
+	expected := []ToolCallRecord{
+		{ID: "tool_call_1", Name: "search", Arguments: `{"query":"weather"}`, Result: "Sunny"},
+		{ID: "tool_call_2", Name: "lookup", Arguments: `{}`},
+	}
+
+	if got := ExtractToolCalls(events); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExtractToolCalls mismatch:\nexpected %+v\ngot      %+v", expected, got)
+	}
+}
+
+func TestToolCallResultChunkEncoding(t *testing.T) {
+	events := []Event{
+		NewToolCallResultStartEvent("msg_result", "tool_call_1"),
+		NewToolCallResultChunkEvent("msg_result", "tool_call_1", "line 1\n"),
+		NewToolCallResultEndEvent("msg_result", "tool_call_1"),
+	}
+
+	for _, event := range events {
+		data, err := EncodeEvent(event)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", event.EventTypeName(), err)
+		}
+		decoded, err := DecodeEventFromBytes(data)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", event.EventTypeName(), err)
+		}
+		if decoded.EventTypeName() != event.EventTypeName() {
+			t.Errorf("Event type name mismatch: expected %s, got %s", event.EventTypeName(), decoded.EventTypeName())
+		}
+	}
+
+	if err := NewToolCallResultChunkEvent("msg_result", "tool_call_1", "").Validate(); err == nil {
+		t.Error("Expected validation error for empty chunk delta")
+	}
+}
+
+func TestToolCallResultAssembler(t *testing.T) {
+	events := []Event{
+		NewToolCallResultStartEvent("msg_a", "tool_call_a"),
+		NewToolCallResultChunkEvent("msg_a", "tool_call_a", "line 1\n"),
+		NewToolCallResultStartEvent("msg_b", "tool_call_b"),
+		NewToolCallResultChunkEvent("msg_b", "tool_call_b", "other"),
+		NewToolCallResultChunkEvent("msg_a", "tool_call_a", "line 2\n"),
+		NewToolCallResultEndEvent("msg_b", "tool_call_b"),
+		NewToolCallResultChunkEvent("msg_a", "tool_call_a", "line 3\n"),
+		NewToolCallResultEndEvent("msg_a", "tool_call_a"),
+	}
+
+	assembler := NewToolCallResultAssembler()
+	var results []*ToolCallResultEvent
+	for _, event := range events {
+		result, err := assembler.Add(event)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].ToolCallID != "tool_call_b" || results[0].Content != "other" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].ToolCallID != "tool_call_a" || results[1].MessageID != "msg_a" || results[1].Content != "line 1\nline 2\nline 3\n" {
+		t.Errorf("Unexpected second result: %+v", results[1])
+	}
+	if results[1].Role != RoleTool {
+		t.Errorf("Expected role %s, got %s", RoleTool, results[1].Role)
+	}
+	if assembler.Pending() != 0 {
+		t.Errorf("Expected no pending results, got %d", assembler.Pending())
+	}
+
+	if _, err := NewToolCallResultAssembler().Add(NewToolCallResultChunkEvent("msg_a", "tool_call_a", "x")); err == nil {
+		t.Error("Expected error for chunk without start")
+	}
+}
+
+func TestJSONArgumentsEqual(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		equal   bool
+		wantErr bool
+	}{
+		{name: "ReorderedKeys", a: `{"query":"weather","limit":5}`, b: "{\n  \"limit\": 5,\n  \"query\": \"weather\"\n}", equal: true},
+		{name: "NestedReordered", a: `{"a":{"x":1,"y":[1,2]}}`, b: `{"a":{"y":[1,2],"x":1}}`, equal: true},
+		{name: "DifferentValues", a: `{"query":"weather"}`, b: `{"query":"news"}`, equal: false},
+		{name: "ArrayOrderMatters", a: `[1,2]`, b: `[2,1]`, equal: false},
+		{name: "InvalidJSON", a: `{"query":`, b: `{}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, err := JSONArgumentsEqual(tt.a, tt.b)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error for invalid JSON")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if equal != tt.equal {
+				t.Errorf("Expected equal=%v, got %v", tt.equal, equal)
+			}
+		})
+	}
+}
+
+func TestFunctionCallValidateRequired(t *testing.T) {
+	required := []string{"query", "limit"}
+	tests := []struct {
+		name      string
+		arguments string
+		wantErr   string
+	}{
+		{name: "AllPresent", arguments: `{"query":"weather","limit":5,"extra":true}`},
+		{name: "FalsyValues", arguments: `{"query":"","limit":0}`},
+		{name: "Empty", arguments: `{}`, wantErr: `"query"`},
+		{name: "MissingOne", arguments: `{"query":"weather"}`, wantErr: `"limit"`},
+		{name: "Null", arguments: `{"query":null,"limit":5}`, wantErr: `"query"`},
+		{name: "NotObject", arguments: `["weather"]`, wantErr: "JSON object"},
+		{name: "NullArguments", arguments: `null`, wantErr: "JSON object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := &FunctionCall{Name: "search", Arguments: tt.arguments}
+			err := call.ValidateRequired(required)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error mentioning %s, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestToolResultBuilder(t *testing.T) {
+	builder := NewToolResultBuilder()
+	events := []Event{
+		NewToolCallResultStartEvent("msg_1", "tool_call_1"),
+		NewToolCallResultChunkEvent("msg_1", "tool_call_1", `{"temp":`),
+		NewToolCallResultChunkEvent("msg_1", "tool_call_1", `22,`),
+		NewToolCallResultChunkEvent("msg_1", "tool_call_1", `"sky":"clear"}`),
+		NewToolCallResultEndEvent("msg_1", "tool_call_1"),
+	}
+
+	var messages []*ToolMessage
+	for _, event := range events {
+		message, err := builder.Add(event)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if message != nil {
+			messages = append(messages, message)
+		}
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 tool message, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.ID != "msg_1" || message.ToolCallID != "tool_call_1" || message.Content != `{"temp":22,"sky":"clear"}` {
+		t.Errorf("Unexpected tool message: %+v", message)
+	}
+	if err := message.Validate(); err != nil {
+		t.Errorf("Reassembled message is invalid: %v", err)
+	}
+
+	if _, err := builder.Add(NewToolCallResultChunkEvent("msg_2", "tool_call_unknown", "x")); err == nil {
+		t.Error("Expected error for a chunk with an unknown tool call ID")
+	}
+}
+
+func TestToolCallAccumulatorValidatesArguments(t *testing.T) {
+	registry, err := NewToolRegistry(Tool{
+		Name:        "search",
+		Description: "Search the web",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer"},
+			},
+			"required": []string{"query"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	accumulator := NewToolCallAccumulator(registry)
+	events := []Event{
+		NewToolCallStartEvent("tc_1", "search", "msg_1"),
+		NewToolCallStartEvent("tc_2", "search", "msg_1"),
+		NewToolCallStartEvent("tc_3", "search", "msg_1"),
+		NewToolCallArgsEvent("tc_1", `{"query":`),
+		NewToolCallArgsEvent("tc_2", `{"limit":5}`),
+		NewToolCallArgsEvent("tc_1", `"weather"}`),
+		NewToolCallEndEvent("tc_1"),
+		NewToolCallEndEvent("tc_2"),
+		NewToolCallEndEvent("tc_3"),
+	}
+
+	var calls []*ToolCall
+	var errs []error
+	for _, event := range events {
+		call, err := accumulator.Add(event)
+		if call != nil {
+			calls = append(calls, call)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 completed calls, got %d", len(calls))
+	}
+	if calls[0].ID != "tc_1" || calls[0].Function.Arguments != `{"query":"weather"}` {
+		t.Errorf("Unexpected first call: %+v", calls[0])
+	}
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	for i, id := range []string{"tc_2", "tc_3"} {
+		if !strings.Contains(errs[i].Error(), "tool call "+id) || !strings.Contains(errs[i].Error(), `missing required property "query"`) {
+			t.Errorf("Unexpected error for %s: %v", id, errs[i])
+		}
+	}
+	if accumulator.Pending() != 0 {
+		t.Errorf("Expected no pending calls, got %d", accumulator.Pending())
+	}
+
+	unchecked := NewToolCallAccumulator(nil)
+	unchecked.Add(NewToolCallStartEvent("tc_1", "search", ""))
+	if call, err := unchecked.Add(NewToolCallEndEvent("tc_1")); call == nil || err != nil {
+		t.Errorf("Expected an unchecked call without error, got %v, %v", call, err)
+	}
+	if _, err := unchecked.Add(NewToolCallEndEvent("tc_9")); err == nil {
+		t.Error("Expected an error for an end without start")
+	}
+}
@@ -0,0 +1,46 @@
+package agui
+
+import "context"
+
+// traceParentKey is the context key under which ContextWithTraceParent stores
+// the traceparent.
+type traceParentKey struct{}
+
+// ContextWithTraceParent returns a copy of ctx carrying traceParent, a W3C
+// Trace Context traceparent header value such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParentFromContext returns the traceparent stored in ctx by
+// ContextWithTraceParent, and false if there is none.
+func TraceParentFromContext(ctx context.Context) (string, bool) {
+	traceParent, ok := ctx.Value(traceParentKey{}).(string)
+	return traceParent, ok && traceParent != ""
+}
+
+// WithTraceContext makes the Encoder stamp every encoded event with the
+// traceparent carried by ctx, replacing any traceparent the event already has.
+// The caller's events are not modified. If ctx carries no traceparent, events
+// are encoded unchanged.
+func WithTraceContext(ctx context.Context) EncoderOption {
+	return func(e *Encoder) {
+		e.traceParent, _ = TraceParentFromContext(ctx)
+	}
+}
+
+// TraceParent returns the traceparent of a decoded event, and false if the
+// event does not carry one.
+func TraceParent(event Event) (string, bool) {
+	traced, ok := event.(interface{ GetTraceParent() string })
+	if !ok || traced.GetTraceParent() == "" {
+		return "", false
+	}
+	return traced.GetTraceParent(), true
+}
+
+// withTraceParent returns a copy of event stamped with traceParent.
+func withTraceParent(event Event, traceParent string) Event {
+	return withBase(event, func(b *BaseEvent) { b.TraceParent = traceParent })
+}
@@ -0,0 +1,75 @@
+package agui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTraceContext(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := ContextWithTraceParent(context.Background(), traceParent)
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf, WithDelimiter([]byte("\n")), WithTraceContext(ctx))
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			t.Fatalf("Failed to encode event: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(events) {
+		t.Fatalf("Expected %d lines, got %d", len(events), len(lines))
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, `"traceparent":"`+traceParent+`"`) {
+			t.Errorf("Line %d is missing the traceparent: %s", i, line)
+		}
+		event, err := DecodeEventFromBytes([]byte(line))
+		if err != nil {
+			t.Fatalf("Failed to decode line %d: %v", i, err)
+		}
+		if got, ok := TraceParent(event); !ok || got != traceParent {
+			t.Errorf("TraceParent of line %d = %q, %v", i, got, ok)
+		}
+	}
+
+	if _, ok := TraceParent(events[0]); ok {
+		t.Error("Expected the caller's event not to be modified")
+	}
+}
+
+func TestWithTraceContextWithoutTraceParent(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf, WithTraceContext(context.Background()))
+	if err := encoder.Encode(NewRunStartedEvent("thread_1", "run_1")); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if strings.Contains(buf.String(), "traceparent") {
+		t.Errorf("Expected no traceparent, got %s", buf.String())
+	}
+}
+
+func TestWithTraceContextKeepsPayload(t *testing.T) {
+	type state struct {
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+	ctx := ContextWithTraceParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	var buf bytes.Buffer
+	event := NewStateSnapshotEvent(state{UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	if err := NewEncoder(&buf, WithTraceContext(ctx)).Encode(event); err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"snapshot":{"updatedAt":"2024-01-02T03:04:05Z"}`) {
+		t.Errorf("Expected the snapshot time to be encoded unchanged, got %s", buf.String())
+	}
+}
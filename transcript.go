@@ -0,0 +1,323 @@
+package agui
+
+import (
+	"fmt"
+)
+
+// Batch validations check a complete recorded transcript of events at once,
+// independently of the streaming SequenceValidator. Each returns every problem
+// found rather than stopping at the first, and errors wrap ErrInvalidSequence.
+
+// ValidateStepPairs checks that every STEP_FINISHED has a prior STEP_STARTED
+// with the same step name, and that no step is left unfinished at the end.
+func ValidateStepPairs(events []Event) []error {
+	var errs []error
+	open := make(map[string]int)
+	var started []string // open step names in start order
+
+	for i, event := range events {
+		switch e := event.(type) {
+		case *StepStartedEvent:
+			open[e.StepName]++
+			started = append(started, e.StepName)
+		case *StepFinishedEvent:
+			if open[e.StepName] == 0 {
+				errs = append(errs, fmt.Errorf("%w: event %d: step %s finished without start", ErrInvalidSequence, i, e.StepName))
+				continue
+			}
+			open[e.StepName]--
+			for j := len(started) - 1; j >= 0; j-- {
+				if started[j] == e.StepName {
+					started = append(started[:j], started[j+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	for _, name := range started {
+		errs = append(errs, fmt.Errorf("%w: step %s started but never finished", ErrInvalidSequence, name))
+	}
+	return errs
+}
+
+// ValidateToolCallParents checks that every TOOL_CALL_START with a parent message
+// ID references an assistant message of the same run, either started with
+// TEXT_MESSAGE_START or included in a MESSAGES_SNAPSHOT. Runs are delimited by
+// RUN_STARTED; the reference may appear before or after the tool call. Empty
+// parent IDs are allowed.
+func ValidateToolCallParents(events []Event) []error {
+	// Collect the known message IDs of each run first
+	var known []map[string]bool
+	run := make([]int, len(events))
+	for i, event := range events {
+		if _, ok := event.(*RunStartedEvent); ok || len(known) == 0 {
+			known = append(known, make(map[string]bool))
+		}
+		run[i] = len(known) - 1
+		switch e := event.(type) {
+		case *TextMessageStartEvent:
+			known[run[i]][e.MessageID] = true
+		case *MessagesSnapshotEvent:
+			for _, message := range e.Messages {
+				if message.GetRole() == RoleAssistant {
+					known[run[i]][message.GetID()] = true
+				}
+			}
+		}
+	}
+
+	var errs []error
+	for i, event := range events {
+		start, ok := event.(*ToolCallStartEvent)
+		if !ok || start.ParentMessageID == "" {
+			continue
+		}
+		if !known[run[i]][start.ParentMessageID] {
+			errs = append(errs, fmt.Errorf("%w: event %d: tool call %s references unknown parent message %s", ErrInvalidSequence, i, start.ToolCallID, start.ParentMessageID))
+		}
+	}
+	return errs
+}
+
+// ValidateTextMessagePairing checks that every TEXT_MESSAGE_CONTENT and
+// TEXT_MESSAGE_END references a message opened by a prior TEXT_MESSAGE_START and
+// not yet ended, and that no message is started twice while open.
+func ValidateTextMessagePairing(events []Event) []error {
+	var errs []error
+	open := make(map[string]bool)
+	ended := make(map[string]bool)
+
+	for i, event := range events {
+		switch e := event.(type) {
+		case *TextMessageStartEvent:
+			if open[e.MessageID] {
+				errs = append(errs, fmt.Errorf("%w: event %d: text message %s started twice", ErrInvalidSequence, i, e.MessageID))
+				continue
+			}
+			open[e.MessageID] = true
+			delete(ended, e.MessageID)
+		case *TextMessageContentEvent:
+			if !open[e.MessageID] {
+				errs = append(errs, unopenedTextMessageError(i, "content", e.MessageID, ended[e.MessageID]))
+			}
+		case *TextMessageEndEvent:
+			if !open[e.MessageID] {
+				errs = append(errs, unopenedTextMessageError(i, "end", e.MessageID, ended[e.MessageID]))
+				continue
+			}
+			delete(open, e.MessageID)
+			ended[e.MessageID] = true
+		}
+	}
+	return errs
+}
+
+// unopenedTextMessageError reports a text message event for a message that is not open.
+func unopenedTextMessageError(index int, kind, messageID string, ended bool) error {
+	if ended {
+		return fmt.Errorf("%w: event %d: text message %s %s after end", ErrInvalidSequence, index, messageID, kind)
+	}
+	return fmt.Errorf("%w: event %d: text message %s %s without start", ErrInvalidSequence, index, messageID, kind)
+}
+
+// lastRunLifecycleEvent returns the last RUN_STARTED, RUN_FINISHED or RUN_ERROR
+// event in events and its index, or nil and -1 if there is none.
+func lastRunLifecycleEvent(events []Event) (Event, int) {
+	for i := len(events) - 1; i >= 0; i-- {
+		switch events[i].(type) {
+		case *RunStartedEvent, *RunFinishedEvent, *RunErrorEvent:
+			return events[i], i
+		}
+	}
+	return nil, -1
+}
+
+// HasTerminalEvent reports whether the last run lifecycle event in events is a
+// RUN_FINISHED or RUN_ERROR.
+func HasTerminalEvent(events []Event) bool {
+	switch event, _ := lastRunLifecycleEvent(events); event.(type) {
+	case *RunFinishedEvent, *RunErrorEvent:
+		return true
+	}
+	return false
+}
+
+// ValidateRunCompletion checks that events do not end with an open run, that is
+// a RUN_STARTED with no later RUN_FINISHED or RUN_ERROR. Events without any run
+// lifecycle event are accepted.
+func ValidateRunCompletion(events []Event) error {
+	event, i := lastRunLifecycleEvent(events)
+	if started, ok := event.(*RunStartedEvent); ok {
+		return fmt.Errorf("%w: event %d: run %s started but never finished", ErrInvalidSequence, i, started.RunID)
+	}
+	return nil
+}
+
+// ValidateRunConsistency pairs each RUN_FINISHED with the open RUN_STARTED of
+// the same run ID and reports finished runs that were never started or whose
+// thread ID differs from the one the run started with.
+func ValidateRunConsistency(events []Event) []error {
+	var errs []error
+	started := make(map[string]string) // open run ID to its thread ID
+
+	for i, event := range events {
+		switch e := event.(type) {
+		case *RunStartedEvent:
+			started[e.RunID] = e.ThreadID
+		case *RunFinishedEvent:
+			threadID, ok := started[e.RunID]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%w: event %d: run %s finished without start", ErrInvalidSequence, i, e.RunID))
+				continue
+			}
+			delete(started, e.RunID)
+			if e.ThreadID != threadID {
+				errs = append(errs, fmt.Errorf("%w: event %d: run %s finished in thread %s but started in thread %s", ErrInvalidSequence, i, e.RunID, e.ThreadID, threadID))
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateUniqueIDs checks that no ID is used for two different kinds of entity:
+// text messages, tool calls, tool result messages, and user, system and
+// developer messages from MESSAGES_SNAPSHOT events. Assistant and tool messages
+// in snapshots count as text messages and tool result messages respectively.
+// References, such as a tool call's parent message ID, are not uses. Each
+// conflicting kind is reported once per ID.
+func ValidateUniqueIDs(events []Event) []error {
+	var errs []error
+	kinds := make(map[string]string)  // ID to the kind that first used it
+	reported := make(map[string]bool) // ID and conflicting kind already reported
+
+	use := func(i int, id, kind string) {
+		if id == "" {
+			return
+		}
+		first, ok := kinds[id]
+		if !ok {
+			kinds[id] = kind
+			return
+		}
+		if first != kind && !reported[id+"\x00"+kind] {
+			reported[id+"\x00"+kind] = true
+			errs = append(errs, fmt.Errorf("%w: event %d: ID %s used for a %s is already used for a %s", ErrInvalidSequence, i, id, kind, first))
+		}
+	}
+
+	for i, event := range events {
+		switch e := event.(type) {
+		case *TextMessageStartEvent:
+			use(i, e.MessageID, "text message")
+		case *TextMessageContentEvent:
+			use(i, e.MessageID, "text message")
+		case *TextMessageEndEvent:
+			use(i, e.MessageID, "text message")
+		case *ToolCallStartEvent:
+			use(i, e.ToolCallID, "tool call")
+		case *ToolCallArgsEvent:
+			use(i, e.ToolCallID, "tool call")
+		case *ToolCallEndEvent:
+			use(i, e.ToolCallID, "tool call")
+		case *ToolCallResultEvent:
+			use(i, e.MessageID, "tool result message")
+		case *ToolCallResultStartEvent:
+			use(i, e.MessageID, "tool result message")
+		case *ToolCallResultChunkEvent:
+			use(i, e.MessageID, "tool result message")
+		case *ToolCallResultEndEvent:
+			use(i, e.MessageID, "tool result message")
+		case *MessagesSnapshotEvent:
+			for _, message := range e.Messages {
+				switch m := message.(type) {
+				case *AssistantMessage:
+					use(i, m.ID, "text message")
+					for _, call := range m.ToolCalls {
+						use(i, call.ID, "tool call")
+					}
+				case *ToolMessage:
+					use(i, m.ID, "tool result message")
+				default:
+					use(i, m.GetID(), string(m.GetRole())+" message")
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateNonEmptyMessages reassembles the text messages in events and reports
+// each message whose content is empty when its TEXT_MESSAGE_END arrives, such as
+// a message streamed with no or only empty content deltas. Pairing problems are
+// left to ValidateTextMessagePairing and are not reported here.
+func ValidateNonEmptyMessages(events []Event) []error {
+	var errs []error
+	assembler := NewTextMessageAssembler()
+
+	for i, event := range events {
+		message, err := assembler.Add(event)
+		if err != nil || message == nil {
+			continue
+		}
+		if message.Content == "" {
+			errs = append(errs, fmt.Errorf("%w: event %d: text message %s ended without content", ErrInvalidSequence, i, message.ID))
+		}
+	}
+	return errs
+}
+
+// ValidateUniqueToolCallIDs reports tool call IDs used for more than one call:
+// a ToolCallStartEvent reusing the ID of an earlier one, an ID appearing twice
+// within one MESSAGES_SNAPSHOT, and a snapshot tool call whose name differs
+// from the earlier call with its ID. A snapshot repeating a call seen before
+// under the same name is not a reuse, since snapshots restate the conversation.
+func ValidateUniqueToolCallIDs(events []Event) []error {
+	var errs []error
+	names := make(map[string]string)  // tool call ID to the name of its first call
+	started := make(map[string]int)   // tool call ID to the event that started it
+	firstSeen := make(map[string]int) // tool call ID to the event that first used it
+
+	for i, event := range events {
+		switch e := event.(type) {
+		case *ToolCallStartEvent:
+			if first, ok := started[e.ToolCallID]; ok {
+				errs = append(errs, fmt.Errorf("%w: event %d: tool call ID %s already started at event %d", ErrInvalidSequence, i, e.ToolCallID, first))
+				continue
+			}
+			if name, ok := names[e.ToolCallID]; ok && name != e.ToolCallName {
+				errs = append(errs, fmt.Errorf("%w: event %d: tool call ID %s used for %s is already used for %s at event %d", ErrInvalidSequence, i, e.ToolCallID, e.ToolCallName, name, firstSeen[e.ToolCallID]))
+				continue
+			}
+			started[e.ToolCallID] = i
+			if _, ok := names[e.ToolCallID]; !ok {
+				names[e.ToolCallID] = e.ToolCallName
+				firstSeen[e.ToolCallID] = i
+			}
+		case *MessagesSnapshotEvent:
+			inSnapshot := make(map[string]bool)
+			for _, msg := range e.Messages {
+				assistant, ok := msg.(*AssistantMessage)
+				if !ok {
+					continue
+				}
+				for _, tc := range assistant.ToolCalls {
+					if inSnapshot[tc.ID] {
+						errs = append(errs, fmt.Errorf("%w: event %d: tool call ID %s appears more than once in the snapshot", ErrInvalidSequence, i, tc.ID))
+						continue
+					}
+					inSnapshot[tc.ID] = true
+					if name, ok := names[tc.ID]; ok {
+						if name != tc.Function.Name {
+							errs = append(errs, fmt.Errorf("%w: event %d: tool call ID %s used for %s is already used for %s at event %d", ErrInvalidSequence, i, tc.ID, tc.Function.Name, name, firstSeen[tc.ID]))
+						}
+						continue
+					}
+					names[tc.ID] = tc.Function.Name
+					firstSeen[tc.ID] = i
+				}
+			}
+		}
+	}
+	return errs
+}
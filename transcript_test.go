@@ -0,0 +1,246 @@
+package agui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// assertErrors checks that errs wrap ErrInvalidSequence and mention each of want, in order.
+func assertErrors(t *testing.T, errs []error, want ...string) {
+	t.Helper()
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, ErrInvalidSequence) {
+			t.Errorf("Error %d does not wrap ErrInvalidSequence: %v", i, err)
+		}
+		if !strings.Contains(err.Error(), want[i]) {
+			t.Errorf("Error %d: expected it to mention %q, got: %v", i, want[i], err)
+		}
+	}
+}
+
+func TestValidateStepPairs(t *testing.T) {
+	balanced := []Event{
+		NewStepStartedEvent("plan"),
+		NewStepStartedEvent("search"),
+		NewStepFinishedEvent("search"),
+		NewStepStartedEvent("search"),
+		NewStepFinishedEvent("search"),
+		NewStepFinishedEvent("plan"),
+	}
+	assertErrors(t, ValidateStepPairs(balanced))
+
+	unbalanced := []Event{
+		NewStepFinishedEvent("warmup"),
+		NewStepStartedEvent("plan"),
+		NewStepStartedEvent("search"),
+		NewStepFinishedEvent("plan"),
+	}
+	assertErrors(t, ValidateStepPairs(unbalanced), "step warmup finished without start", "step search started but never finished")
+}
+
+func TestValidateToolCallParents(t *testing.T) {
+	valid := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+		NewToolCallStartEvent("tool_call_2", "search", ""),
+		NewMessagesSnapshotEvent([]Message{NewAssistantMessage("msg_2", "", "", nil)}),
+		NewToolCallStartEvent("tool_call_3", "search", "msg_2"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+	assertErrors(t, ValidateToolCallParents(valid))
+
+	dangling := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewMessagesSnapshotEvent([]Message{NewUserMessage("msg_user", "Hi", "")}),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_missing"),
+		NewToolCallStartEvent("tool_call_2", "search", "msg_user"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+		NewRunStartedEvent("thread_1", "run_2"),
+		NewToolCallStartEvent("tool_call_3", "search", "msg_1"),
+	}
+	assertErrors(t, ValidateToolCallParents(dangling), "msg_missing", "msg_user", "msg_1")
+}
+
+func TestValidateTextMessagePairing(t *testing.T) {
+	valid := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageContentEvent("msg_2", "Hi"),
+		NewTextMessageEndEvent("msg_2"),
+		NewTextMessageEndEvent("msg_1"),
+	}
+	assertErrors(t, ValidateTextMessagePairing(valid))
+
+	orphaned := []Event{
+		NewTextMessageContentEvent("msg_1", "orphan"),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageEndEvent("msg_2"),
+		NewTextMessageContentEvent("msg_2", "late"),
+		NewTextMessageEndEvent("msg_3"),
+	}
+	assertErrors(t, ValidateTextMessagePairing(orphaned),
+		"msg_1 content without start",
+		"msg_2 content after end",
+		"msg_3 end without start",
+	)
+}
+
+func TestValidateRunCompletion(t *testing.T) {
+	complete := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageEndEvent("msg_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+	failed := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewRunErrorEvent("boom", ""),
+	}
+	truncated := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+		NewRunStartedEvent("thread_1", "run_2"),
+		NewTextMessageStartEvent("msg_1"),
+	}
+
+	for name, events := range map[string][]Event{"Complete": complete, "Failed": failed} {
+		if !HasTerminalEvent(events) {
+			t.Errorf("%s: expected a terminal event", name)
+		}
+		if err := ValidateRunCompletion(events); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	if HasTerminalEvent(truncated) {
+		t.Error("Truncated: expected no terminal event")
+	}
+	assertErrors(t, []error{ValidateRunCompletion(truncated)}, "run run_2 started but never finished")
+
+	if HasTerminalEvent(nil) {
+		t.Error("Expected no terminal event for an empty transcript")
+	}
+	if err := ValidateRunCompletion(nil); err != nil {
+		t.Errorf("Unexpected error for an empty transcript: %v", err)
+	}
+}
+
+func TestValidateRunConsistency(t *testing.T) {
+	consistent := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewRunStartedEvent("thread_2", "run_2"),
+		NewRunFinishedEvent("thread_2", "run_2", nil),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+	assertErrors(t, ValidateRunConsistency(consistent))
+
+	mismatched := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewRunFinishedEvent("thread_2", "run_1", nil),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+		NewRunFinishedEvent("thread_1", "run_3", nil),
+	}
+	assertErrors(t, ValidateRunConsistency(mismatched),
+		"event 1: run run_1 finished in thread thread_2 but started in thread thread_1",
+		"event 2: run run_1 finished without start",
+		"event 3: run run_3 finished without start",
+	)
+}
+
+func TestValidateUniqueIDs(t *testing.T) {
+	clean := []Event{
+		NewMessagesSnapshotEvent([]Message{NewUserMessage("msg_1", "Hi", "")}),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageEndEvent("msg_2"),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_2"),
+		NewToolCallEndEvent("tool_call_1"),
+		NewToolCallResultEvent("msg_3", "tool_call_1", "done"),
+		NewMessagesSnapshotEvent([]Message{
+			NewUserMessage("msg_1", "Hi", ""),
+			NewAssistantMessage("msg_2", "", "", []ToolCall{searchCall("tool_call_1")}),
+			NewToolMessage("msg_3", "done", "tool_call_1", "", ""),
+		}),
+	}
+	assertErrors(t, ValidateUniqueIDs(clean))
+
+	reused := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageEndEvent("msg_1"),
+		NewToolCallStartEvent("tool_call_1", "search", "msg_1"),
+		NewToolCallEndEvent("tool_call_1"),
+		NewToolCallResultEvent("msg_1", "tool_call_1", "done"),
+		NewToolCallResultEvent("msg_1", "tool_call_1", "again"),
+		NewTextMessageStartEvent("tool_call_1"),
+	}
+	assertErrors(t, ValidateUniqueIDs(reused),
+		"event 4: ID msg_1 used for a tool result message is already used for a text message",
+		"event 6: ID tool_call_1 used for a text message is already used for a tool call",
+	)
+}
+
+func TestValidateNonEmptyMessages(t *testing.T) {
+	normal := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageContentEvent("msg_1", ""),
+		NewTextMessageEndEvent("msg_1"),
+	}
+	assertErrors(t, ValidateNonEmptyMessages(normal))
+
+	silent := []Event{
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", ""),
+		NewTextMessageContentEvent("msg_1", ""),
+		NewTextMessageEndEvent("msg_1"),
+		NewTextMessageStartEvent("msg_2"),
+		NewTextMessageContentEvent("msg_2", "Visible"),
+		NewTextMessageEndEvent("msg_2"),
+		NewTextMessageStartEvent("msg_3"),
+		NewTextMessageEndEvent("msg_3"),
+	}
+	assertErrors(t, ValidateNonEmptyMessages(silent),
+		"event 3: text message msg_1 ended without content",
+		"event 8: text message msg_3 ended without content",
+	)
+}
+
+func TestValidateUniqueToolCallIDs(t *testing.T) {
+	call := func(id, name string) ToolCall {
+		return ToolCall{ID: id, Type: ToolCallTypeFunction, Function: FunctionCall{Name: name, Arguments: "{}"}}
+	}
+
+	unique := []Event{
+		NewToolCallStartEvent("tc_1", "search", "msg_1"),
+		NewToolCallEndEvent("tc_1"),
+		NewToolCallStartEvent("tc_2", "lookup", "msg_1"),
+		NewToolCallEndEvent("tc_2"),
+		NewMessagesSnapshotEvent([]Message{
+			NewAssistantMessage("msg_1", "", "", []ToolCall{call("tc_1", "search"), call("tc_2", "lookup")}),
+			NewAssistantMessage("msg_2", "", "", []ToolCall{call("tc_3", "search")}),
+		}),
+	}
+	assertErrors(t, ValidateUniqueToolCallIDs(unique))
+
+	duplicated := []Event{
+		NewToolCallStartEvent("tc_1", "search", "msg_1"),
+		NewToolCallEndEvent("tc_1"),
+		NewToolCallStartEvent("tc_1", "search", "msg_2"),
+		NewMessagesSnapshotEvent([]Message{
+			NewAssistantMessage("msg_1", "", "", []ToolCall{call("tc_1", "lookup")}),
+			NewAssistantMessage("msg_2", "", "", []ToolCall{call("tc_2", "search")}),
+			NewAssistantMessage("msg_3", "", "", []ToolCall{call("tc_2", "search")}),
+		}),
+	}
+	assertErrors(t, ValidateUniqueToolCallIDs(duplicated),
+		"event 2: tool call ID tc_1 already started at event 0",
+		"event 3: tool call ID tc_1 used for lookup is already used for search at event 0",
+		"event 3: tool call ID tc_2 appears more than once in the snapshot",
+	)
+}
@@ -6,6 +6,8 @@ package agui
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 // EventType represents all possible event types in the AG-UI protocol.
@@ -13,23 +15,27 @@ type EventType string
 
 // Event type constants as defined in the AG-UI protocol specification.
 const (
-	EventTypeTextMessageStart   EventType = "TEXT_MESSAGE_START"
-	EventTypeTextMessageContent EventType = "TEXT_MESSAGE_CONTENT"
-	EventTypeTextMessageEnd     EventType = "TEXT_MESSAGE_END"
-	EventTypeToolCallStart      EventType = "TOOL_CALL_START"
-	EventTypeToolCallArgs       EventType = "TOOL_CALL_ARGS"
-	EventTypeToolCallEnd        EventType = "TOOL_CALL_END"
-	EventTypeToolCallResult     EventType = "TOOL_CALL_RESULT"
-	EventTypeStateSnapshot      EventType = "STATE_SNAPSHOT"
-	EventTypeStateDelta         EventType = "STATE_DELTA"
-	EventTypeMessagesSnapshot   EventType = "MESSAGES_SNAPSHOT"
-	EventTypeRaw                EventType = "RAW"
-	EventTypeCustom             EventType = "CUSTOM"
-	EventTypeRunStarted         EventType = "RUN_STARTED"
-	EventTypeRunFinished        EventType = "RUN_FINISHED"
-	EventTypeRunError           EventType = "RUN_ERROR"
-	EventTypeStepStarted        EventType = "STEP_STARTED"
-	EventTypeStepFinished       EventType = "STEP_FINISHED"
+	EventTypeTextMessageStart    EventType = "TEXT_MESSAGE_START"
+	EventTypeTextMessageContent  EventType = "TEXT_MESSAGE_CONTENT"
+	EventTypeTextMessageEnd      EventType = "TEXT_MESSAGE_END"
+	EventTypeToolCallStart       EventType = "TOOL_CALL_START"
+	EventTypeToolCallArgs        EventType = "TOOL_CALL_ARGS"
+	EventTypeToolCallEnd         EventType = "TOOL_CALL_END"
+	EventTypeToolCallResult      EventType = "TOOL_CALL_RESULT"
+	EventTypeToolCallResultStart EventType = "TOOL_CALL_RESULT_START"
+	EventTypeToolCallResultChunk EventType = "TOOL_CALL_RESULT_CHUNK"
+	EventTypeToolCallResultEnd   EventType = "TOOL_CALL_RESULT_END"
+	EventTypeStateSnapshot       EventType = "STATE_SNAPSHOT"
+	EventTypeStateDelta          EventType = "STATE_DELTA"
+	EventTypeMessagesSnapshot    EventType = "MESSAGES_SNAPSHOT"
+	EventTypeRaw                 EventType = "RAW"
+	EventTypeCustom              EventType = "CUSTOM"
+	EventTypeRunStarted          EventType = "RUN_STARTED"
+	EventTypeRunFinished         EventType = "RUN_FINISHED"
+	EventTypeRunError            EventType = "RUN_ERROR"
+	EventTypeStepStarted         EventType = "STEP_STARTED"
+	EventTypeStepFinished        EventType = "STEP_FINISHED"
+	EventTypeStepProgress        EventType = "STEP_PROGRESS"
 )
 
 // IsValid checks if the EventType is a valid AG-UI event type.
@@ -37,10 +43,11 @@ func (e EventType) IsValid() bool {
 	switch e {
 	case EventTypeTextMessageStart, EventTypeTextMessageContent, EventTypeTextMessageEnd,
 		EventTypeToolCallStart, EventTypeToolCallArgs, EventTypeToolCallEnd, EventTypeToolCallResult,
+		EventTypeToolCallResultStart, EventTypeToolCallResultChunk, EventTypeToolCallResultEnd,
 		EventTypeStateSnapshot, EventTypeStateDelta, EventTypeMessagesSnapshot,
 		EventTypeRaw, EventTypeCustom,
 		EventTypeRunStarted, EventTypeRunFinished, EventTypeRunError,
-		EventTypeStepStarted, EventTypeStepFinished:
+		EventTypeStepStarted, EventTypeStepFinished, EventTypeStepProgress:
 		return true
 	default:
 		return false
@@ -146,6 +153,25 @@ func (f *FunctionCall) Validate() error {
 	return nil
 }
 
+// ValidateRequired checks that the arguments are a JSON object in which every
+// key in required is present and not null.
+func (f *FunctionCall) ValidateRequired(required []string) error {
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(f.Arguments), &args); err != nil {
+		return fmt.Errorf("function arguments must be a JSON object: %w", err)
+	}
+	if args == nil {
+		return fmt.Errorf("function arguments must be a JSON object")
+	}
+	for _, key := range required {
+		value, ok := args[key]
+		if !ok || string(value) == "null" {
+			return fmt.Errorf("function %s: required argument %q is missing", f.Name, key)
+		}
+	}
+	return nil
+}
+
 // ToolCall represents a tool call made by an agent.
 type ToolCall struct {
 	ID       string       `json:"id"`       // Unique identifier for the tool call
@@ -169,20 +195,36 @@ type RunAgentInput struct {
 	ThreadID       string      `json:"threadId"`       // ID of the conversation thread
 	RunID          string      `json:"runId"`          // ID of the current run
 	State          State       `json:"state"`          // Current state of the agent
-	Messages       []Message   `json:"messages"`       // List of messages in the conversation
+	Messages       Messages    `json:"messages"`       // List of messages in the conversation
 	Tools          []Tool      `json:"tools"`          // List of tools available to the agent
 	Context        []Context   `json:"context"`        // List of context objects provided to the agent
 	ForwardedProps interface{} `json:"forwardedProps"` // Additional properties forwarded to the agent
 }
 
 // Validate checks if the RunAgentInput is valid according to AG-UI schema constraints.
+// Nil or empty Messages, Tools and Context are allowed; use ValidateWith and
+// RequireMessages to insist on at least one message.
 func (r *RunAgentInput) Validate() error {
+	return r.ValidateWith()
+}
+
+// ValidateWith checks if the RunAgentInput is valid like Validate, additionally
+// enforcing the rules enabled by opts.
+func (r *RunAgentInput) ValidateWith(opts ...ValidateOption) error {
+	config := newValidateConfig(opts)
+
 	if r.ThreadID == "" {
 		return fmt.Errorf("thread ID is required")
 	}
 	if r.RunID == "" {
 		return fmt.Errorf("run ID is required")
 	}
+	if config.requireMessages && len(r.Messages) == 0 {
+		return fmt.Errorf("at least one message is required")
+	}
+	if config.requireContext && len(r.Context) == 0 {
+		return fmt.Errorf("at least one context is required")
+	}
 
 	// Validate messages
 	for i, msg := range r.Messages {
@@ -199,11 +241,69 @@ func (r *RunAgentInput) Validate() error {
 	}
 
 	// Validate context
+	descriptions := make(map[string]int)
 	for i, ctx := range r.Context {
 		if err := ctx.Validate(); err != nil {
 			return fmt.Errorf("invalid context at index %d: %w", i, err)
 		}
+		if config.uniqueContextDescriptions {
+			if first, ok := descriptions[ctx.Description]; ok {
+				return fmt.Errorf("invalid context at index %d: duplicate description %q (first at index %d)", i, ctx.Description, first)
+			}
+			descriptions[ctx.Description] = i
+		}
+	}
+
+	return nil
+}
+
+// ValidateIDs checks that the thread and run IDs, message IDs, tool call IDs of
+// assistant messages and tool call references of tool messages are valid UTF-8,
+// not only whitespace, and at most DefaultMaxIDLength bytes long, or the length
+// set with MaxIDLength. It returns an error naming the first offending field.
+// These checks are stricter than Validate and are only applied on request.
+func (r *RunAgentInput) ValidateIDs(opts ...ValidateOption) error {
+	config := newValidateConfig(opts)
+	maxLength := config.maxIDLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxIDLength
+	}
+
+	check := func(field, id string) error {
+		if !utf8.ValidString(id) {
+			return fmt.Errorf("%s must be valid UTF-8", field)
+		}
+		if strings.TrimSpace(id) == "" {
+			return fmt.Errorf("%s must not be empty or whitespace", field)
+		}
+		if len(id) > maxLength {
+			return fmt.Errorf("%s is %d bytes long, exceeding the maximum of %d", field, len(id), maxLength)
+		}
+		return nil
 	}
 
+	if err := check("threadId", r.ThreadID); err != nil {
+		return err
+	}
+	if err := check("runId", r.RunID); err != nil {
+		return err
+	}
+	for i, msg := range r.Messages {
+		if err := check(fmt.Sprintf("messages[%d].id", i), msg.GetID()); err != nil {
+			return err
+		}
+		switch m := msg.(type) {
+		case *AssistantMessage:
+			for j, tc := range m.ToolCalls {
+				if err := check(fmt.Sprintf("messages[%d].toolCalls[%d].id", i, j), tc.ID); err != nil {
+					return err
+				}
+			}
+		case *ToolMessage:
+			if err := check(fmt.Sprintf("messages[%d].toolCallId", i), m.ToolCallID); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
@@ -0,0 +1,166 @@
+package agui
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ValidateOption enables an opt-in validation rule for the ValidateWith methods.
+type ValidateOption func(*validateConfig)
+
+// validateConfig holds the rules enabled by ValidateOptions.
+type validateConfig struct {
+	requireMessages           bool
+	requireContext            bool
+	uniqueContextDescriptions bool
+	requireResult             bool
+	maxIDLength               int
+	lenientTextRoles          bool
+}
+
+// newValidateConfig applies opts to a default validateConfig.
+func newValidateConfig(opts []ValidateOption) *validateConfig {
+	c := &validateConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RequireMessages makes RunAgentInput validation fail when Messages is empty.
+func RequireMessages() ValidateOption {
+	return func(c *validateConfig) {
+		c.requireMessages = true
+	}
+}
+
+// RequireContext makes RunAgentInput validation fail when Context is empty.
+func RequireContext() ValidateOption {
+	return func(c *validateConfig) {
+		c.requireContext = true
+	}
+}
+
+// UniqueContextDescriptions makes RunAgentInput validation fail when two
+// Context entries share the same Description.
+func UniqueContextDescriptions() ValidateOption {
+	return func(c *validateConfig) {
+		c.uniqueContextDescriptions = true
+	}
+}
+
+// RequireResult makes RunFinishedEvent validation fail when Result is nil.
+func RequireResult() ValidateOption {
+	return func(c *validateConfig) {
+		c.requireResult = true
+	}
+}
+
+// LenientTextRoles makes TextMessageStartEvent validation accept the user,
+// system and developer roles, and an empty role meaning assistant, in addition
+// to the assistant role the AG-UI specification requires.
+func LenientTextRoles() ValidateOption {
+	return func(c *validateConfig) {
+		c.lenientTextRoles = true
+	}
+}
+
+// DefaultMaxIDLength is the maximum ID length, in bytes, enforced by
+// RunAgentInput.ValidateIDs unless MaxIDLength is given.
+const DefaultMaxIDLength = 256
+
+// MaxIDLength sets the maximum ID length, in bytes, enforced by
+// RunAgentInput.ValidateIDs.
+func MaxIDLength(n int) ValidateOption {
+	return func(c *validateConfig) {
+		c.maxIDLength = n
+	}
+}
+
+// ValidateStrict checks event like its Validate method, additionally requiring
+// a timestamp, for pipelines that order events by time.
+func ValidateStrict(event Event) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	return requireTimestamp(event)
+}
+
+// requireTimestamp returns an error if event has no timestamp.
+func requireTimestamp(event Event) error {
+	if event.GetTimestamp() == nil {
+		return fmt.Errorf("timestamp is required on %s event", event.GetType())
+	}
+	return nil
+}
+
+// validateFinite returns an error naming the JSON Pointer path of the first NaN
+// or infinite float in value, which json.Marshal cannot encode. field names the
+// event field holding value in the error.
+func validateFinite(field string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	number, path, ok := findNonFinite(reflect.ValueOf(value), nil)
+	if !ok {
+		return nil
+	}
+	if len(path) == 0 {
+		return fmt.Errorf("%s is not a finite number: %v", field, number)
+	}
+	return fmt.Errorf("%s contains a non-finite number at %s: %v", field, JSONPointer(path...), number)
+}
+
+// findNonFinite walks v depth-first and returns the first NaN or infinite float
+// found and the reference tokens leading to it from v.
+func findNonFinite(v reflect.Value, path []string) (float64, []string, bool) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return f, path, true
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return findNonFinite(v.Elem(), path)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if f, p, ok := findNonFinite(v.Index(i), append(path, strconv.Itoa(i))); ok {
+				return f, p, true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if f, p, ok := findNonFinite(iter.Value(), append(path, fmt.Sprint(iter.Key()))); ok {
+				return f, p, true
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			fieldPath := path
+			if !field.Anonymous {
+				if name == "" {
+					name = field.Name
+				}
+				fieldPath = append(path, name)
+			}
+			if f, p, ok := findNonFinite(v.Field(i), fieldPath); ok {
+				return f, p, true
+			}
+		}
+	}
+	return 0, nil, false
+}
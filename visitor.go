@@ -0,0 +1,134 @@
+package agui
+
+// EventVisitor has one method per concrete event type. Passing a visitor to
+// Event.Accept calls the method matching the event's type, so a type that
+// implements EventVisitor is checked by the compiler to handle every event type,
+// unlike a type switch.
+type EventVisitor interface {
+	VisitRunStarted(*RunStartedEvent)
+	VisitRunFinished(*RunFinishedEvent)
+	VisitRunError(*RunErrorEvent)
+	VisitStepStarted(*StepStartedEvent)
+	VisitStepFinished(*StepFinishedEvent)
+	VisitStepProgress(*StepProgressEvent)
+	VisitTextMessageStart(*TextMessageStartEvent)
+	VisitTextMessageContent(*TextMessageContentEvent)
+	VisitTextMessageEnd(*TextMessageEndEvent)
+	VisitToolCallStart(*ToolCallStartEvent)
+	VisitToolCallArgs(*ToolCallArgsEvent)
+	VisitToolCallEnd(*ToolCallEndEvent)
+	VisitToolCallResult(*ToolCallResultEvent)
+	VisitToolCallResultStart(*ToolCallResultStartEvent)
+	VisitToolCallResultChunk(*ToolCallResultChunkEvent)
+	VisitToolCallResultEnd(*ToolCallResultEndEvent)
+	VisitStateSnapshot(*StateSnapshotEvent)
+	VisitStateDelta(*StateDeltaEvent)
+	VisitMessagesSnapshot(*MessagesSnapshotEvent)
+	VisitRaw(*RawEvent)
+	VisitCustom(*CustomEvent)
+}
+
+// Accept calls v.VisitRunStarted with the event.
+func (r *RunStartedEvent) Accept(v EventVisitor) {
+	v.VisitRunStarted(r)
+}
+
+// Accept calls v.VisitRunFinished with the event.
+func (r *RunFinishedEvent) Accept(v EventVisitor) {
+	v.VisitRunFinished(r)
+}
+
+// Accept calls v.VisitRunError with the event.
+func (r *RunErrorEvent) Accept(v EventVisitor) {
+	v.VisitRunError(r)
+}
+
+// Accept calls v.VisitStepStarted with the event.
+func (s *StepStartedEvent) Accept(v EventVisitor) {
+	v.VisitStepStarted(s)
+}
+
+// Accept calls v.VisitStepFinished with the event.
+func (s *StepFinishedEvent) Accept(v EventVisitor) {
+	v.VisitStepFinished(s)
+}
+
+// Accept calls v.VisitStepProgress with the event.
+func (s *StepProgressEvent) Accept(v EventVisitor) {
+	v.VisitStepProgress(s)
+}
+
+// Accept calls v.VisitTextMessageStart with the event.
+func (t *TextMessageStartEvent) Accept(v EventVisitor) {
+	v.VisitTextMessageStart(t)
+}
+
+// Accept calls v.VisitTextMessageContent with the event.
+func (t *TextMessageContentEvent) Accept(v EventVisitor) {
+	v.VisitTextMessageContent(t)
+}
+
+// Accept calls v.VisitTextMessageEnd with the event.
+func (t *TextMessageEndEvent) Accept(v EventVisitor) {
+	v.VisitTextMessageEnd(t)
+}
+
+// Accept calls v.VisitToolCallStart with the event.
+func (t *ToolCallStartEvent) Accept(v EventVisitor) {
+	v.VisitToolCallStart(t)
+}
+
+// Accept calls v.VisitToolCallArgs with the event.
+func (t *ToolCallArgsEvent) Accept(v EventVisitor) {
+	v.VisitToolCallArgs(t)
+}
+
+// Accept calls v.VisitToolCallEnd with the event.
+func (t *ToolCallEndEvent) Accept(v EventVisitor) {
+	v.VisitToolCallEnd(t)
+}
+
+// Accept calls v.VisitToolCallResult with the event.
+func (t *ToolCallResultEvent) Accept(v EventVisitor) {
+	v.VisitToolCallResult(t)
+}
+
+// Accept calls v.VisitToolCallResultStart with the event.
+func (t *ToolCallResultStartEvent) Accept(v EventVisitor) {
+	v.VisitToolCallResultStart(t)
+}
+
+// Accept calls v.VisitToolCallResultChunk with the event.
+func (t *ToolCallResultChunkEvent) Accept(v EventVisitor) {
+	v.VisitToolCallResultChunk(t)
+}
+
+// Accept calls v.VisitToolCallResultEnd with the event.
+func (t *ToolCallResultEndEvent) Accept(v EventVisitor) {
+	v.VisitToolCallResultEnd(t)
+}
+
+// Accept calls v.VisitStateSnapshot with the event.
+func (s *StateSnapshotEvent) Accept(v EventVisitor) {
+	v.VisitStateSnapshot(s)
+}
+
+// Accept calls v.VisitStateDelta with the event.
+func (s *StateDeltaEvent) Accept(v EventVisitor) {
+	v.VisitStateDelta(s)
+}
+
+// Accept calls v.VisitMessagesSnapshot with the event.
+func (m *MessagesSnapshotEvent) Accept(v EventVisitor) {
+	v.VisitMessagesSnapshot(m)
+}
+
+// Accept calls v.VisitRaw with the event.
+func (r *RawEvent) Accept(v EventVisitor) {
+	v.VisitRaw(r)
+}
+
+// Accept calls v.VisitCustom with the event.
+func (c *CustomEvent) Accept(v EventVisitor) {
+	v.VisitCustom(c)
+}
@@ -0,0 +1,89 @@
+package agui
+
+import (
+	"reflect"
+	"testing"
+)
+
+// countingVisitor counts the events it visits by type name.
+type countingVisitor struct {
+	counts map[string]int
+}
+
+func (c *countingVisitor) VisitRunStarted(*RunStartedEvent)     { c.counts["RunStarted"]++ }
+func (c *countingVisitor) VisitRunFinished(*RunFinishedEvent)   { c.counts["RunFinished"]++ }
+func (c *countingVisitor) VisitRunError(*RunErrorEvent)         { c.counts["RunError"]++ }
+func (c *countingVisitor) VisitStepStarted(*StepStartedEvent)   { c.counts["StepStarted"]++ }
+func (c *countingVisitor) VisitStepFinished(*StepFinishedEvent) { c.counts["StepFinished"]++ }
+func (c *countingVisitor) VisitStepProgress(*StepProgressEvent) { c.counts["StepProgress"]++ }
+func (c *countingVisitor) VisitTextMessageStart(*TextMessageStartEvent) {
+	c.counts["TextMessageStart"]++
+}
+func (c *countingVisitor) VisitTextMessageContent(*TextMessageContentEvent) {
+	c.counts["TextMessageContent"]++
+}
+func (c *countingVisitor) VisitTextMessageEnd(*TextMessageEndEvent) { c.counts["TextMessageEnd"]++ }
+func (c *countingVisitor) VisitToolCallStart(*ToolCallStartEvent)   { c.counts["ToolCallStart"]++ }
+func (c *countingVisitor) VisitToolCallArgs(*ToolCallArgsEvent)     { c.counts["ToolCallArgs"]++ }
+func (c *countingVisitor) VisitToolCallEnd(*ToolCallEndEvent)       { c.counts["ToolCallEnd"]++ }
+func (c *countingVisitor) VisitToolCallResult(*ToolCallResultEvent) { c.counts["ToolCallResult"]++ }
+func (c *countingVisitor) VisitToolCallResultStart(*ToolCallResultStartEvent) {
+	c.counts["ToolCallResultStart"]++
+}
+func (c *countingVisitor) VisitToolCallResultChunk(*ToolCallResultChunkEvent) {
+	c.counts["ToolCallResultChunk"]++
+}
+func (c *countingVisitor) VisitToolCallResultEnd(*ToolCallResultEndEvent) {
+	c.counts["ToolCallResultEnd"]++
+}
+func (c *countingVisitor) VisitStateSnapshot(*StateSnapshotEvent) { c.counts["StateSnapshot"]++ }
+func (c *countingVisitor) VisitStateDelta(*StateDeltaEvent)       { c.counts["StateDelta"]++ }
+func (c *countingVisitor) VisitMessagesSnapshot(*MessagesSnapshotEvent) {
+	c.counts["MessagesSnapshot"]++
+}
+func (c *countingVisitor) VisitRaw(*RawEvent)       { c.counts["Raw"]++ }
+func (c *countingVisitor) VisitCustom(*CustomEvent) { c.counts["Custom"]++ }
+
+func TestEventAccept(t *testing.T) {
+	events := []Event{
+		NewRunStartedEvent("thread_1", "run_1"),
+		NewTextMessageStartEvent("msg_1"),
+		NewTextMessageContentEvent("msg_1", "Hello"),
+		NewTextMessageContentEvent("msg_1", " world"),
+		NewTextMessageEndEvent("msg_1"),
+		NewToolCallStartEvent("tc_1", "search", "msg_1"),
+		NewToolCallEndEvent("tc_1"),
+		NewStateDeltaEvent([]interface{}{}),
+		NewCustomEvent("ping", true),
+		NewRunFinishedEvent("thread_1", "run_1", nil),
+	}
+
+	visitor := &countingVisitor{counts: make(map[string]int)}
+	for _, event := range events {
+		event.Accept(visitor)
+	}
+
+	want := map[string]int{
+		"RunStarted":         1,
+		"TextMessageStart":   1,
+		"TextMessageContent": 2,
+		"TextMessageEnd":     1,
+		"ToolCallStart":      1,
+		"ToolCallEnd":        1,
+		"StateDelta":         1,
+		"Custom":             1,
+		"RunFinished":        1,
+	}
+	if !reflect.DeepEqual(visitor.counts, want) {
+		t.Errorf("Counts = %v, want %v", visitor.counts, want)
+	}
+
+	for _, event := range events {
+		visitor := &countingVisitor{counts: make(map[string]int)}
+		event.Accept(visitor)
+		name := event.EventTypeName()
+		if visitor.counts[name[:len(name)-len("Event")]] != 1 {
+			t.Errorf("%s dispatched to %v", name, visitor.counts)
+		}
+	}
+}